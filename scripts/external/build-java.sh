@@ -0,0 +1,18 @@
+#!/bin/sh
+
+OUTPUT=$1
+CCPATH=$2
+
+set -e
+cd $CCPATH
+if [ -f pom.xml ]; then
+	mvn -q package -DskipTests
+	JARS=target
+else
+	if [ -x gradlew ]; then GRADLE=./gradlew; else GRADLE=gradle; fi
+	$GRADLE -q shadowJar
+	JARS=build/libs
+fi
+# the fat jar is the largest one
+JAR=$(ls -S $JARS/*.jar | head -n 1)
+cp $JAR $OUTPUT
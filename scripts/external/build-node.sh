@@ -0,0 +1,17 @@
+#!/bin/sh
+
+OUTPUT=$1
+CCPATH=$2
+
+set -e
+rm -rf $OUTPUT
+cp -r $CCPATH $OUTPUT
+cd $OUTPUT
+# typescript contracts are compiled by their build script, which needs the dev dependencies
+if grep -q '"build"' package.json; then
+	npm install
+	npm run build
+	npm prune --production
+else
+	npm install --production
+fi
@@ -11,6 +11,7 @@ import (
 	"mictract/model/response"
 	"mictract/service"
 	"mictract/service/factory"
+	respFactory "mictract/service/factory/response"
 	"mictract/service/factory/sdk"
 	"net/http"
 	"path/filepath"
@@ -18,16 +19,10 @@ import (
 )
 
 // POST /api/chaincode
-
+// the source is uploaded, then deployed by service.ChaincodePipeline:
+// unpack, build, install, approve (channel's org), commit, start cc container.
+// with manualApproval, the pipeline waits at approve until every org has approved by POST /api/chaincode/approve
 func CreateChaincode(c *gin.Context)  {
-	// 如果这里数组越界，应该时网络创建时的问题
-	// 1. upload
-	// 2. unpack
-	// 3. build
-	// 4. install (one peer)
-	// 5. approve (channel's org)
-	// 6. commmit
-	// 7. start cc container
 	var (
 		nickname 		= c.PostForm("nickname")
 
@@ -36,6 +31,8 @@ func CreateChaincode(c *gin.Context)  {
 		version			= c.PostForm("version")
 		sequence		= c.PostForm("sequence")
 		initRequired	= c.PostForm("initRequired")
+		// every org approves on its own by POST /api/chaincode/approve
+		manualApproval	= c.PostForm("manualApproval")
 
 		channelID		= c.PostForm("channelID")
 	)
@@ -59,17 +56,16 @@ func CreateChaincode(c *gin.Context)  {
 	}
 	_sequence, _ := strconv.Atoi(sequence)
 	_initReq, _ := strconv.ParseBool(initRequired)
+	_manualApproval, _ := strconv.ParseBool(manualApproval)
 
 	cc, err := factory.NewChaincodeFactory().
-		NewChaincode(nickname, ch.ID, ch.NetworkID, label, policyStr, version, int64(_sequence), _initReq)
+		NewChaincode(nickname, ch.ID, ch.NetworkID, label, policyStr, version, int64(_sequence), _initReq, _manualApproval)
 	if err != nil {
 		response.Err(http.StatusInternalServerError, enum.CodeErrNotFound).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
-	ccSvc := service.NewChaincodeService(cc)
-
 	err = c.SaveUploadedFile(srcTarGz, filepath.Join(cc.GetCCPath(), "src.tar.gz"))
 	if err != nil {
 		response.Err(http.StatusInternalServerError, enum.CodeErrNotFound).
@@ -78,175 +74,190 @@ func CreateChaincode(c *gin.Context)  {
 		return
 	}
 
-	go func(ccSvc service.ChaincodeService, ch model.Channel) {
-		chorgs, err := dao.FindAllOrganizationsInChannel(&ch)
-		if err != nil {
-			global.Logger.Error("fail to get orgs", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
-		orderers, err := dao.FindAllOrderersInNetwork(ch.NetworkID)
-		if err != nil {
-			global.Logger.Error("fail to get peers", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
-		peers, err := dao.FindAllPeersInChannel(&ch)
-		if err != nil {
-			global.Logger.Error("fail to get peer", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
+	job, err := factory.NewJobFactory().NewJob(enum.JobCreateChaincode, ch.NetworkID, cc.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 
-		// 2. unpack
-		global.Logger.Info("unpack chaincode")
-		if err := ccSvc.Unpack(); err != nil {
-			global.Logger.Error("fail to unpack cc", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		return service.NewChaincodePipeline(cc).Start(jSvc)
+	})
 
-		// install all peer in channel
-		go func() {
-			orgs, _ := dao.FindAllOrganizationsInChannel(&ch)
-			for _, org := range orgs {
-				go func(org model.Organization) {
-					global.Logger.Info(fmt.Sprintf("install %s to %s", cc.GetName(), org.GetName()))
-					adminUser, err := dao.FindSystemUserInOrganization(org.ID)
-					if err != nil {
-						return
-					}
-					rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
-					if err != nil {
-						return
-					}
-					if err := ccSvc.InstallCC(rc); err != nil {
-						return
-					}
-				}(org)
-			}
-		}()
-
-		// 3. build
-		global.Logger.Info("build chaincode")
-		dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusBuilding)
-		if err := ccSvc.Build(); err != nil {
-			global.Logger.Error("fail to build cc ", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
 
-		// 5. approve (channel's org)
-		global.Logger.Info("approve chaincode")
-		for _, org := range chorgs {
-			global.Logger.Info(fmt.Sprintf("%s approve cc", org.GetName()))
-			adminUser, err := dao.FindSystemUserInOrganization(org.ID)
-			if err != nil {
-				global.Logger.Error("fail to get adminUser", zap.Error(err))
-				dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-				return
-			}
-			rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
-			if err != nil {
-				global.Logger.Error("fail to get rc", zap.Error(err))
-				dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-				return
-			}
-
-			peers, err := dao.FindAllPeersInOrganization(org.ID)
-			if err != nil {
-				global.Logger.Error("fail to get peers", zap.Error(err))
-				dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-				return
-			}
-
-			retry_count := 3
-			for retry_count >= 0 {
-				if err := ccSvc.ApproveCC(
-					rc,
-					orderers[0].GetName(),
-					peers[0].GetName()); err != nil {
-					global.Logger.Error("fail to approve cc", zap.Error(err))
-					if retry_count <= 0 {
-						dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-						return
-					}
-					global.Logger.Info("Retrying")
-					retry_count --
-				} else {
-					break
-				}
-			}
-
-			resp, _ := ccSvc.CheckCCCommitReadiness(rc)
-			global.Logger.Info(fmt.Sprintf("%v", resp))
-		}
+// POST /api/chaincode/retry
+// param: id
+// runs the pipeline of a failed or pending chaincode again from the stage it stopped at,
+// organizations which have installed or approved are skipped
+func RetryChaincode(c *gin.Context) {
+	var info struct{
+		ChaincodeID 	int 	`form:"id" json:"id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 
-		// 6. commmit
-		// note: "implicit policy evaluation failed" if use rc(include org)
-		//       you should use rc(include network) to get enough endoerment
-		global.Logger.Info("commit chaincode")
-		orgID := chorgs[0].ID
-		peerURLs := []string{}
-		for _, peer := range peers {
-			peerURLs = append(peerURLs, peer.GetName())
-		}
+	cc, err := dao.FindChaincodeByID(info.ChaincodeID)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	status := cc.Status
+	pipeline := service.NewChaincodePipeline(cc)
+	if ok, err := pipeline.Claim(enum.StatusError, enum.StatusPending); err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	} else if !ok {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(fmt.Sprintf("%s is %s, only failed or pending chaincodes can be retried", cc.GetName(), cc.Status)).
+			Result(c.JSON)
+		return
+	}
 
-		global.Logger.Info("Obtaining rc(include network)...")
-		adminUser, err := dao.FindSystemUserInOrganization(orgID)
-		if err != nil {
-			global.Logger.Error("fail to get adminUser", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
-		rc, err := sdk.NewSDKClientFactory().NewResmgmtClientIncludeNetwork(adminUser)
-		if err != nil {
-			global.Logger.Error("fail to get rc", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
+	job, err := factory.NewJobFactory().NewJob(enum.JobCreateChaincode, cc.NetworkID, cc.ID)
+	if err != nil {
+		dao.UpdateChaincodeStatusByID(cc.ID, status)
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 
-		retry_count := 3
-		for retry_count >= 0 {
-			if err := ccSvc.CommitCC(
-				rc,
-				orderers[0].GetName(),
-				peerURLs...,
-			); err != nil {
-				global.Logger.Error("fail to commit cc", zap.Error(err))
-				if retry_count == 0{
-					dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-					return
-				}
-				global.Logger.Error("Retrying")
-				retry_count --
-			} else {
-				break
-			}
-		}
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		return pipeline.Retry(jSvc)
+	})
 
-		// 7. start cc container
-		global.Logger.Info("start cc container")
-		if err := ccSvc.CreateEntity(); err != nil {
-			global.Logger.Error("fail to create cc container", zap.Error(err))
-			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
-			return
-		}
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// POST /api/chaincode/upgrade
+// param: id, file, label, version, policy, only id and file are required
+// the sequence is increased by one, empty fields keep the current definition.
+// The id is a form field rather than POST /api/chaincode/:id/upgrade, because gin can't route a wildcard
+// next to the static /install, /approve, ... of the chaincode group.
+func UpgradeChaincode(c *gin.Context) {
+	var (
+		label		= c.PostForm("label")
+		version		= c.PostForm("version")
+		policyStr	= c.PostForm("policy")
+	)
+	id, err := strconv.Atoi(c.PostForm("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	srcTarGz, err := c.FormFile("file")
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	cc, err := dao.FindChaincodeByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	// of concurrent upgrades only the one which claims the running chaincode saves its upload
+	if ok, err := dao.SwapChaincodeStatusByID(cc.ID, []string{enum.StatusRunning}, enum.StatusUpgrading); err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	} else if !ok {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(fmt.Sprintf("%s is %s, only running chaincodes can be upgraded", cc.GetName(), cc.Status)).
+			Result(c.JSON)
+		return
+	}
+	cc.Status = enum.StatusUpgrading
 
+	upload := filepath.Join(cc.GetCCPath(), "upgrade.tar.gz")
+	if err := c.SaveUploadedFile(srcTarGz, upload); err != nil {
 		dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusRunning)
+		response.Err(http.StatusInternalServerError, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobUpgradeChaincode, cc.NetworkID, cc.ID)
+	if err != nil {
+		dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusRunning)
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("upgrade %s", cc.GetName())); err != nil {
+			dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusRunning)
+			return err
+		}
+		return service.NewChaincodeService(cc).Upgrade(upload, label, version, policyStr)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
 
-		global.Logger.Info(fmt.Sprintf("chaincode%d has been created successfully", cc.ID))
-	}(*ccSvc, *ch)
+// GET /api/chaincode/version
+// param: id
+// the committed definitions of the chaincode, the oldest first
+func ListChaincodeVersions(c *gin.Context) {
+	info := struct {
+		ChaincodeID int `form:"id" binding:"required"`
+	}{}
+	if err := c.ShouldBindQuery(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ccvs, err := dao.FindAllVersionsOfChaincode(info.ChaincodeID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 
 	response.Ok().
+		SetPayload(response.NewChaincodeVersions(ccvs)).
 		Result(c.JSON)
 }
 
 // POST /api/chaincode/install
+// param: id, and organizationID or peers
+// with organizationID, the package is installed on all peers of the organization
 func InstallChaincode(c *gin.Context)  {
 	var info struct{
-		Peers 		[]string 	`form:"peers" json:"peers"  binding:"required"`
-		ChaincodeID int 		`form:"id" json:"id" binding:"required"`
+		Peers 			[]string 	`form:"peers" json:"peers"`
+		OrganizationID 	int 		`form:"organizationID" json:"organizationID"`
+		ChaincodeID 	int 		`form:"id" json:"id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&info); err != nil {
 		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
@@ -254,6 +265,12 @@ func InstallChaincode(c *gin.Context)  {
 			Result(c.JSON)
 		return
 	}
+	if info.OrganizationID == 0 && len(info.Peers) == 0 {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage("organizationID or peers is required").
+			Result(c.JSON)
+		return
+	}
 
 	cc, err := dao.FindChaincodeByID(info.ChaincodeID)
 	if err != nil {
@@ -265,6 +282,26 @@ func InstallChaincode(c *gin.Context)  {
 	}
 	ccSvc := service.NewChaincodeService(cc)
 
+	if info.OrganizationID != 0 {
+		org, err := dao.FindOrganizationByID(info.OrganizationID)
+		if err != nil {
+			response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		if err := ccSvc.InstallForOrg(org); err != nil {
+			response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		response.Ok().
+			SetPayload(response.NewChaincode(cc)).
+			Result(c.JSON)
+		return
+	}
+
 	for _, p := range info.Peers {
 		global.Logger.Info("Obtaining rc...")
 		pCauser := factory.NewCaUserFactory().NewCaUserFromDomainName(p)
@@ -305,6 +342,10 @@ func InstallChaincode(c *gin.Context)  {
 }
 
 // POST /api/chaincode/approve
+// param: organizationID, id
+// approves the definition on behalf of the organization only.
+// A pipeline waiting for manual approvals is resumed once all organizations have approved,
+// the job of it is returned then.
 func ApproveChaincode(c *gin.Context)  {
 	var info struct{
 		OrganizationID 	int 	`form:"organizationID" json:"organizationID" binding:"required"`
@@ -319,64 +360,155 @@ func ApproveChaincode(c *gin.Context)  {
 
 	cc, err := dao.FindChaincodeByID(info.ChaincodeID)
 	if err != nil {
-		global.Logger.Error("fail to get cc", zap.Error(err))
-		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	org, err := dao.FindOrganizationByID(info.OrganizationID)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
 	ccSvc := service.NewChaincodeService(cc)
+	if err := ccSvc.ApproveForOrg(org); err != nil {
+		global.Logger.Error("fail to approve cc", zap.Error(err))
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	// of concurrent approvals only the one which claims the pending chaincode resumes the pipeline
+	pipeline := service.NewChaincodePipeline(cc)
+	if ok, err := pipeline.Claim(enum.StatusPending); err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	} else if !ok {
+		response.Ok().
+			SetPayload(response.NewChaincode(cc)).
+			Result(c.JSON)
+		return
+	}
 
-	adminUser, err := dao.FindSystemUserInOrganization(info.OrganizationID)
+	job, err := factory.NewJobFactory().NewJob(enum.JobCreateChaincode, cc.NetworkID, cc.ID)
 	if err != nil {
-		global.Logger.Error("fail to get adminUser", zap.Error(err))
+		dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusPending)
 		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
-	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		return pipeline.Retry(jSvc)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET /api/chaincode/readiness
+// param: id, organizationID
+// which organizations have approved the current definition, as seen by the peer of the organization
+func CheckChaincodeCommitReadiness(c *gin.Context) {
+	info := struct {
+		ChaincodeID 	int `form:"id" binding:"required"`
+		OrganizationID 	int `form:"organizationID" binding:"required"`
+	}{}
+	if err := c.ShouldBindQuery(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	cc, err := dao.FindChaincodeByID(info.ChaincodeID)
 	if err != nil {
-		global.Logger.Error("fail to get rc", zap.Error(err))
-		dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	org, err := dao.FindOrganizationByID(info.OrganizationID)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
 		return
 	}
 
-	peers, err := dao.FindAllPeersInOrganization(info.OrganizationID)
+	approvals, err := service.NewChaincodeService(cc).CheckCommitReadinessForOrg(org)
 	if err != nil {
-		global.Logger.Error("fail to get peers", zap.Error(err))
-		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	respondChaincodeApprovals(c, cc, approvals)
+}
+
+// GET /api/chaincode/approval
+// param: id, version, sequence, only id is required
+// the approval state of each organization of the channel for the definition,
+// empty version and sequence mean the current one
+func ListChaincodeApprovals(c *gin.Context) {
+	info := struct {
+		ChaincodeID int 	`form:"id" binding:"required"`
+		Version 	string 	`form:"version"`
+		Sequence 	int64 	`form:"sequence"`
+	}{}
+	if err := c.ShouldBindQuery(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
-	orderers, err := dao.FindAllOrderersInNetwork(adminUser.NetworkID)
+	cc, err := dao.FindChaincodeByID(info.ChaincodeID)
 	if err != nil {
-		global.Logger.Error("fail to get orderers", zap.Error(err))
-		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
-	if err := ccSvc.ApproveCC(
-		rc,
-		orderers[0].GetName(),
-		peers[0].GetName()); err != nil {
-		global.Logger.Error("fail to approve cc", zap.Error(err))
+	approvals, err := service.NewChaincodeService(cc).GetApprovals(info.Version, info.Sequence)
+	if err != nil {
 		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
+	respondChaincodeApprovals(c, cc, approvals)
+}
 
-	resp, _ := ccSvc.CheckCCCommitReadiness(rc)
-	global.Logger.Info(fmt.Sprintf("%v", resp))
+// respondChaincodeApprovals responds with the approvals of the organizations in the chaincode's channel
+func respondChaincodeApprovals(c *gin.Context, cc *model.Chaincode, approvals map[string]bool) {
+	ch, err := dao.FindChannelByID(cc.ChannelID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	orgs, err := dao.FindAllOrganizationsInChannel(ch)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 
-	response.Ok().Result(c.JSON)
+	response.Ok().
+		SetPayload(response.NewChaincodeApprovals(orgs, approvals)).
+		Result(c.JSON)
 }
 
 // POST /api/chaincode/commit
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"mictract/dao"
 	"mictract/enum"
@@ -11,6 +13,8 @@ import (
 	"mictract/model/response"
 	respFactory "mictract/service/factory/response"
 	"mictract/service"
+	"mictract/service/configtx"
+	"mictract/service/factory"
 	"net/http"
 	"strconv"
 )
@@ -37,18 +41,31 @@ func AddChannel(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		var ch *model.Channel
-		if ch, err = service.NewNetworkService(net).AddChannel(info.OrganizationIDs, info.Nickname); err != nil {
+	job, err := factory.NewJobFactory().NewJob(enum.JobAddChannel, net.ID, 0)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("add channel %s", info.Nickname)); err != nil {
+			return err
+		}
+		ch, err := service.NewNetworkService(net).AddChannel(info.OrganizationIDs, info.Nickname)
+		jSvc.SetTargetID(ch.ID)
+		if err != nil {
 			dao.UpdateChannelStatusByID(ch.ID, enum.StatusError)
-			global.Logger.Error("fail to add channel", zap.Error(err))
-			return
+			return errors.WithMessage(err, "fail to add channel")
 		}
 		dao.UpdateChannelStatusByID(ch.ID, enum.StatusRunning)
 		global.Logger.Info("channel has been created successfully", zap.String("channelName", ch.GetName()))
-	}()
+		return nil
+	})
 
 	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
 		Result(c.JSON)
 }
 
@@ -115,4 +132,335 @@ func GetChannelByID(c *gin.Context) {
 		SetPayload(respFactory.NewChannelWithHeight(ch, bcInfoResp.BCI.Height)).
 		Result(c.JSON)
 
-}
\ No newline at end of file
+}
+// GET /api/channel/:id/policy
+// the policy tree of the latest channel config
+func GetChannelPolicies(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	tree, sequence, err := service.NewChannelService(ch).GetPolicies()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewChannelPolicies(ch, sequence, tree)).
+		Result(c.JSON)
+}
+
+// PUT /api/channel/:id/policy
+// param: UpdatePolicyReq
+func UpdateChannelPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	var info request.UpdatePolicyReq
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	if info.Type != configtx.SignaturePolicyType && info.Type != configtx.ImplicitMetaPolicyType {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage("The policy type only supports Signature and ImplicitMeta").
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	policy := configtx.Policy{Type: info.Type, Rule: info.Rule}
+	if info.Pending {
+		cu, err := service.NewChannelService(ch).ProposePolicyUpdate(info.Path, info.Name, policy)
+		if err != nil {
+			response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		response.Ok().
+			SetPayload(respFactory.NewConfigUpdate(cu)).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobUpdatePolicy, ch.NetworkID, ch.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("update policy %s of %v", info.Name, info.Path)); err != nil {
+			return err
+		}
+		if err := service.NewChannelService(ch).UpdatePolicy(info.Path, info.Name, policy); err != nil {
+			return errors.WithMessage(err, "fail to update policy")
+		}
+		return nil
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// DELETE /api/channel/:id/organization
+// param: organizationID
+// the organization's group is removed from the channel config and its peers unjoin the channel
+func RemoveOrgFromChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	info := struct {
+		OrganizationID int `form:"organizationID" json:"organizationID" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobRemoveOrgFromChannel, ch.NetworkID, info.OrganizationID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("remove org%d from %s", info.OrganizationID, ch.GetName())); err != nil {
+			return err
+		}
+		return service.NewChannelService(ch).RemoveOrg(info.OrganizationID)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET /api/channel/:id/parameters
+// capability levels and batch parameters, read from the latest config block
+func GetChannelParameters(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	params, sequence, err := service.NewChannelService(ch).GetParameters()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewChannelParameters(ch, sequence, params)).
+		Result(c.JSON)
+}
+
+// PUT /api/channel/:id/parameters
+// param: UpdateChannelParametersReq
+func UpdateChannelParameters(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	var info request.UpdateChannelParametersReq
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	params := configtx.ChannelParameters{
+		ChannelCapabilities: 		info.ChannelCapabilities,
+		OrdererCapabilities: 		info.OrdererCapabilities,
+		ApplicationCapabilities: 	info.ApplicationCapabilities,
+		Batch: configtx.BatchParameters{
+			BatchTimeout: 		info.BatchTimeout,
+			MaxMessageCount: 	info.MaxMessageCount,
+			AbsoluteMaxBytes: 	info.AbsoluteMaxBytes,
+			PreferredMaxBytes: 	info.PreferredMaxBytes,
+		},
+	}
+	if info.Pending {
+		cu, err := service.NewChannelService(ch).ProposeParametersUpdate(params)
+		if err != nil {
+			response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		response.Ok().
+			SetPayload(respFactory.NewConfigUpdate(cu)).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobUpdateChannelParameters, ch.NetworkID, ch.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("update parameters of %s", ch.GetName())); err != nil {
+			return err
+		}
+		return service.NewChannelService(ch).UpdateParameters(params)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET /api/channel/:id/anchors
+// anchor peers of every organization, decoded from the latest config block
+func GetChannelAnchors(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	anchors, err := service.NewChannelService(ch).GetAnchors()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewOrganizationAnchors(anchors)).
+		Result(c.JSON)
+}
+
+// PUT /api/channel/:id/anchors
+// param: SetAnchorsReq
+func SetChannelAnchors(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	var info request.SetAnchorsReq
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobSetAnchors, ch.NetworkID, info.OrganizationID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("set anchors of org%d in %s", info.OrganizationID, ch.GetName())); err != nil {
+			return err
+		}
+		return service.NewChannelService(ch).SetAnchors(info.OrganizationID, info.PeerIDs)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
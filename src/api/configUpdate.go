@@ -0,0 +1,283 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/model"
+	"mictract/model/response"
+	"mictract/service"
+	"mictract/service/factory"
+	respFactory "mictract/service/factory/response"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// findConfigUpdate responds with an error if the config update in the path does not exist
+func findConfigUpdate(c *gin.Context) (*model.ConfigUpdate, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return nil, false
+	}
+
+	cu, err := dao.FindConfigUpdateByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return nil, false
+	}
+	return cu, true
+}
+
+// respondConfigUpdate evaluates the signatures collected so far,
+// and with submit, starts submitting the update once they are enough.
+func respondConfigUpdate(c *gin.Context, cu *model.ConfigUpdate, submit bool) {
+	cuSvc := service.NewConfigUpdateService(cu)
+	unsatisfied, stale, err := cuSvc.GetUnsatisfiedPolicies()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	if submit && !stale && len(unsatisfied) == 0 && len(cu.Signatures) > 0 {
+		if _, err := submitConfigUpdate(cu); err != nil {
+			response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewConfigUpdateWithStatus(cu, unsatisfied, stale)).
+		Result(c.JSON)
+}
+
+// submitConfigUpdate claims the update and starts a job submitting it,
+// nil job if the update has been claimed by another request or isn't pending
+func submitConfigUpdate(cu *model.ConfigUpdate) (*model.Job, error) {
+	cuSvc := service.NewConfigUpdateService(cu)
+	if ok, err := cuSvc.Claim(); err != nil || !ok {
+		return nil, err
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobSubmitConfigUpdate, cu.NetworkID, cu.ID)
+	if err != nil {
+		_ = dao.UpdateConfigUpdateStatusByID(cu.ID, enum.StatusError, err.Error())
+		return nil, err
+	}
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("submit %s", cu.GetName())); err != nil {
+			return err
+		}
+		return cuSvc.Submit()
+	})
+	return job, nil
+}
+
+// GET /api/config-update
+// param: channelID or networkID
+func ListConfigUpdates(c *gin.Context) {
+	info := struct {
+		NetworkID int `form:"networkID"`
+		ChannelID int `form:"channelID"`
+	}{}
+	if err := c.ShouldBindQuery(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	var cus []model.ConfigUpdate
+	var err error
+	if info.ChannelID != 0 {
+		cus, err = dao.FindAllConfigUpdatesInChannel(info.ChannelID)
+	} else {
+		cus, err = dao.FindAllConfigUpdatesInNetwork(info.NetworkID)
+	}
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewConfigUpdates(cus)).
+		Result(c.JSON)
+}
+
+// GET /api/config-update/:id
+// with the policies which still need signatures
+func GetConfigUpdateByID(c *gin.Context) {
+	cu, ok := findConfigUpdate(c)
+	if !ok {
+		return
+	}
+	respondConfigUpdate(c, cu, false)
+}
+
+// POST /api/config-update/:id/submit
+// submits the update once its signatures are enough, also a failed one again
+func SubmitConfigUpdate(c *gin.Context) {
+	cu, ok := findConfigUpdate(c)
+	if !ok {
+		return
+	}
+
+	unsatisfied, stale, err := service.NewConfigUpdateService(cu).GetUnsatisfiedPolicies()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	if stale {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage("the channel config has changed since the update was proposed, please propose it again").
+			Result(c.JSON)
+		return
+	}
+	if len(unsatisfied) > 0 {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage("policies are not satisfied yet: " + strings.Join(unsatisfied, ", ")).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := submitConfigUpdate(cu)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	if job == nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(fmt.Sprintf("%s is %s", cu.GetName(), cu.Status)).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET /api/config-update/:id/envelope
+// the unsigned envelope, sign it with `peer channel signconfigtx -f <file>` and upload it back
+func DownloadConfigUpdate(c *gin.Context) {
+	cu, ok := findConfigUpdate(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pb", cu.GetName()))
+	c.Data(http.StatusOK, "application/octet-stream", service.NewConfigUpdateService(cu).GetEnvelope())
+}
+
+// POST /api/config-update/:id/signature
+// param: file, the envelope signed by `peer channel signconfigtx`
+func UploadConfigSignature(c *gin.Context) {
+	cu, ok := findConfigUpdate(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	reader, err := file.Open()
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	defer reader.Close()
+	signed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	if _, err := service.NewConfigUpdateService(cu).AddSignedEnvelope(signed); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	respondConfigUpdate(c, cu, true)
+}
+
+// POST /api/config-update/:id/sign
+// the admin of the organization held by mictract signs the update
+func SignConfigUpdate(c *gin.Context) {
+	cu, ok := findConfigUpdate(c)
+	if !ok {
+		return
+	}
+	info := struct {
+		OrganizationID int `form:"organizationID" json:"organizationID" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	org, err := dao.FindOrganizationByID(info.OrganizationID)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	if err := service.NewConfigUpdateService(cu).Sign(org); err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	respondConfigUpdate(c, cu, true)
+}
+
+// DELETE /api/config-update/:id
+// cancel a pending update
+func CancelConfigUpdate(c *gin.Context) {
+	cu, ok := findConfigUpdate(c)
+	if !ok {
+		return
+	}
+
+	if err := service.NewConfigUpdateService(cu).Cancel(); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewConfigUpdate(cu)).
+		Result(c.JSON)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/model"
+	"mictract/model/response"
+	"mictract/service"
+	respFactory "mictract/service/factory/response"
+	"net/http"
+	"strconv"
+)
+
+// GET /api/job
+func ListJobs(c *gin.Context) {
+	info := struct {
+		NetworkID int `form:"networkID"`
+	}{}
+
+	if err := c.ShouldBindQuery(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	var jobs []model.Job
+	var err error
+	if info.NetworkID == 0 {
+		jobs, err = dao.FindAllJobs()
+	} else {
+		jobs, err = dao.FindAllJobsInNetwork(info.NetworkID)
+	}
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewJobs(jobs)).
+		Result(c.JSON)
+}
+
+// GET /api/job/:id
+func GetJobByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := dao.FindJobByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// POST /api/job/:id/cancel
+// The job stops before its next step, work already done is not undone.
+func CancelJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := dao.FindJobByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	if err := service.NewJobService(job).Cancel(); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		Result(c.JSON)
+}
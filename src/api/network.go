@@ -1,9 +1,12 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 	"mictract/dao"
 	"mictract/enum"
 	"mictract/global"
@@ -13,8 +16,10 @@ import (
 	respFactory "mictract/service/factory/response"
 	"mictract/service"
 	"mictract/service/factory"
+	"mictract/service/factory/sdk"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // Create a new network configuration.
@@ -42,9 +47,30 @@ func CreateNetwork(c *gin.Context) {
 		return
 	}
 
-	if info.Consensus != "solo" && info.Consensus != "etcdraft" {
+	if info.Consensus != "solo" && info.Consensus != "etcdraft" && info.Consensus != enum.ConsensusBFT {
 		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
-			SetMessage("The consensus protocol only supports solo and etcdraft").
+			SetMessage("The consensus protocol only supports solo, etcdraft and BFT").
+			Result(c.JSON)
+		return
+	}
+
+	if info.ChannelMode == enum.ChannelModeParticipation && info.Consensus == "solo" {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage("The channel participation only supports etcdraft and BFT").
+			Result(c.JSON)
+		return
+	}
+
+	if info.ChannelMode == enum.ChannelModeSystemChannel && info.Consensus == enum.ConsensusBFT {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage("The BFT consensus only supports channel participation").
+			Result(c.JSON)
+		return
+	}
+
+	if info.Consensus == enum.ConsensusBFT && info.OrdererCount < enum.BFTMinOrderers {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(fmt.Sprintf("The BFT consensus needs at least %d orderers", enum.BFTMinOrderers)).
 			Result(c.JSON)
 		return
 	}
@@ -73,55 +99,80 @@ func CreateNetwork(c *gin.Context) {
 
 	// TODO
 	// check if the network name has existed.
-	// check if the new network configuration could be saved.
-	go func() {
-		net, err	:= factory.NewNetworkFactory().NewNetwork(info.Nickname, info.Consensus)
-		if err != nil {
-			global.Logger.Error("fail to init net", zap.Error(err))
-			return
-		}
-		netSvc		:= service.NewNetworkService(net)
 
-		if err = netSvc.Deploy(); err != nil {
+	net, err := factory.NewNetworkFactory().NewNetwork(info.Nickname, info.Consensus, info.ChannelMode)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	job, err := factory.NewJobFactory().NewJob(enum.JobCreateNetwork, net.ID, net.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := createNetwork(jSvc, net, info); err != nil {
 			dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
-			global.Logger.Error("fail to deploy basic network ", zap.Error(err))
-			return
+			return err
 		}
+		dao.UpdateNetworkStatusByID(net.ID, enum.StatusRunning)
+		global.Logger.Info("network has been created successfully", zap.String("netName", net.GetName()))
+		return nil
+	})
 
-		// add rest org
-		for i := 0; i < len(info.PeerCounts); i++ {
-			var newOrg *model.Organization
-			if newOrg, err = netSvc.AddOrg(info.OrgNicknames[i]); err != nil {
-				dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
-				global.Logger.Error("fail to add rest org", zap.Error(err))
-				return
-			}
-			// add rest peer
-			for j := 0; j < info.PeerCounts[i] - 1; j++ {
-				if _, err := service.NewOrganizationService(newOrg).AddPeer(); err != nil {
-					dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
-					global.Logger.Error("fail to add rest peer", zap.Error(err))
-					return
-				}
-			}
-			dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusRunning)
-		}
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// createNetwork runs under a job, see CreateNetwork
+func createNetwork(jSvc *service.JobService, net *model.Network, info request.AddNetworkReq) error {
+	netSvc := service.NewNetworkService(net)
 
+	if err := jSvc.Step("deploy basic network"); err != nil {
+		return err
+	}
+	if err := netSvc.Deploy(); err != nil {
+		return errors.WithMessage(err, "fail to deploy basic network")
+	}
 
-		// add rest orderer
-		for i := 1; i < info.OrdererCount; i++ {
-			if err := netSvc.AddOrderersToSystemChannel(); err != nil {
-				dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
-				global.Logger.Error("fail to add rest orderer", zap.Error(err))
-				return
+	// add rest org
+	for i := 0; i < len(info.PeerCounts); i++ {
+		if err := jSvc.Step(fmt.Sprintf("add organization %s", info.OrgNicknames[i])); err != nil {
+			return err
+		}
+		newOrg, err := netSvc.AddOrg(info.OrgNicknames[i])
+		if err != nil {
+			return errors.WithMessage(err, "fail to add rest org")
+		}
+		// add rest peer
+		for j := 0; j < info.PeerCounts[i] - 1; j++ {
+			if err := jSvc.Step(fmt.Sprintf("add peer to %s", newOrg.GetName())); err != nil {
+				return err
+			}
+			if _, err := service.NewOrganizationService(newOrg).AddPeer(); err != nil {
+				dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusError)
+				return errors.WithMessage(err, "fail to add rest peer")
 			}
 		}
-		dao.UpdateNetworkStatusByID(net.ID, enum.StatusRunning)
-		global.Logger.Info("network has been created successfully", zap.String("netName", net.GetName()))
-	}()
+		dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusRunning)
+	}
 
-	response.Ok().
-		Result(c.JSON)
+	// add rest orderer
+	for i := 1; i < info.OrdererCount; i++ {
+		if err := jSvc.Step("add orderer"); err != nil {
+			return err
+		}
+		if _, err := netSvc.AddOrderer(); err != nil {
+			return errors.WithMessage(err, "fail to add rest orderer")
+		}
+	}
+	return nil
 }
 
 // GET	/network
@@ -192,3 +243,162 @@ func DeleteNetwork(c *gin.Context) {
 			Result(c.JSON)
 	}
 }
+
+// Import a network which is not deployed by mictract,
+// its nodes keep running where they are and are never created or deleted by mictract.
+//
+// POST	/network/import
+// param: ImportNetworkReq
+func ImportNetwork(c *gin.Context) {
+	var info request.ImportNetworkReq
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	profile, err := service.ParseConnectionProfile([]byte(info.Profile))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	if err := service.CheckImport(profile, info.Admins); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	if info.Consensus == "" {
+		info.Consensus = "etcdraft"
+	}
+
+	net, err := factory.NewNetworkFactory().NewExternalNetwork(info.Nickname, info.Consensus)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	job, err := factory.NewJobFactory().NewJob(enum.JobImportNetwork, net.ID, net.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step("import network"); err != nil {
+			dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
+			return err
+		}
+		if err := service.NewNetworkService(net).Import(profile, info.OrdererMSPID, info.Admins); err != nil {
+			dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
+			return errors.WithMessage(err, "fail to import network")
+		}
+		dao.UpdateNetworkStatusByID(net.ID, enum.StatusRunning)
+		global.Logger.Info("network has been imported successfully", zap.String("netName", net.GetName()))
+		return nil
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET	/network/:id/export
+// param:
+//   format: 		json(default), yaml or zip
+//   clientOrgID: 	client.organization of the profile, the first peer organization by default
+//   userID: 		required by zip, whose msp and tls directories are packed
+//   address: 		repeatable, maps an in-cluster host to an external one, e.g. peer1-org1-net1=1.2.3.4:30051
+func ExportNetwork(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	info := struct {
+		Format 		string 		`form:"format"`
+		ClientOrgID int 		`form:"clientOrgID"`
+		UserID 		int 		`form:"userID"`
+		Addresses 	[]string 	`form:"address"`
+	}{}
+	if err := c.ShouldBindQuery(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	addresses := map[string]string{}
+	for _, addr := range info.Addresses {
+		kv := strings.SplitN(addr, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+				SetMessage(fmt.Sprintf("address should be like peer1-org1-net1=1.2.3.4:30051, got %s", addr)).
+				Result(c.JSON)
+			return
+		}
+		addresses[kv[0]] = kv[1]
+	}
+
+	net, err := dao.FindNetworkByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	profile, err := sdk.NewSDKFactory().NewConnectionProfile(net.ID, info.ClientOrgID, addresses)
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	switch info.Format {
+	case "", "json":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", net.GetName()))
+		c.IndentedJSON(http.StatusOK, profile)
+	case "yaml":
+		bt, err := yaml.Marshal(profile)
+		if err != nil {
+			response.Err(http.StatusInternalServerError, enum.CodeErrBadArgument).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.yaml", net.GetName()))
+		c.Data(http.StatusOK, "application/x-yaml", bt)
+	case "zip":
+		user, err := dao.FindCaUserByID(info.UserID)
+		if err != nil {
+			response.Err(http.StatusBadRequest, enum.CodeErrNotFound).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		buf := &bytes.Buffer{}
+		if err := service.NewNetworkService(net).ExportBundle(buf, profile, user); err != nil {
+			response.Err(http.StatusInternalServerError, enum.CodeErrBadArgument).
+				SetMessage(err.Error()).
+				Result(c.JSON)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", net.GetName()))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	default:
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage("format only supports json, yaml and zip").
+			Result(c.JSON)
+	}
+}
@@ -2,16 +2,21 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"mictract/dao"
 	"mictract/enum"
+	"mictract/model"
 	"mictract/model/request"
 	"mictract/model/response"
+	respFactory "mictract/service/factory/response"
 	"mictract/service"
+	"mictract/service/factory"
 	"net/http"
 	"strconv"
 )
 
 // POST /orderer/
+// every new orderer joins the consenters of system-channel and all application channels
 func AddOrderer(c *gin.Context) {
 	var info request.AddOrdererReq
 	if err := c.ShouldBindJSON(&info); err != nil {
@@ -28,18 +33,131 @@ func AddOrderer(c *gin.Context) {
 			Result(c.JSON)
 		return
 	}
-	netSvc := service.NewNetworkService(net)
 
-	for i := 0; i < info.OrdererCount; i++ {
-		if err := netSvc.AddOrderersToSystemChannel(); err != nil {
-			response.Err(http.StatusInternalServerError, enum.CodeErrNotFound).
+	job, err := factory.NewJobFactory().NewJob(enum.JobAddOrderer, net.ID, 0)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		netSvc := service.NewNetworkService(net)
+		for i := 0; i < info.OrdererCount; i++ {
+			if err := jSvc.Step("add orderer"); err != nil {
+				return err
+			}
+			orderer, err := netSvc.AddOrderer()
+			if err != nil {
+				return errors.WithMessage(err, "fail to add orderer")
+			}
+			jSvc.SetTargetID(orderer.ID)
+		}
+		return nil
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// DELETE /orderer/:id
+// the orderer leaves the consenters of all channels, then its entity is deleted and its certificates are revoked
+func RemoveOrderer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	orderer, err := dao.FindCaUserByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	net, err := dao.FindNetworkByID(orderer.NetworkID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobRemoveOrderer, net.ID, orderer.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step("remove orderer"); err != nil {
+			return err
+		}
+		return service.NewNetworkService(net).RemoveOrderer(orderer.ID)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET /network/:id/consenters
+// the raft consenters of system-channel and every application channel, read from their config blocks
+func ListConsenters(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	net, err := dao.FindNetworkByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	orderers, err := dao.FindAllOrderersInNetwork(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	chs, err := dao.FindAllChannelsInNetwork(id)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	if !net.UsesChannelParticipation() {
+		chs = append([]model.Channel{*factory.NewChannelFactory().NewSystemChannel(id)}, chs...)
+	}
+
+	ret := []response.ChannelConsenters{}
+	for i := range chs {
+		consenters, err := service.NewChannelService(&chs[i]).GetConsenters()
+		if err != nil {
+			response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
 				SetMessage(err.Error()).
 				Result(c.JSON)
 			return
 		}
+		ret = append(ret, *respFactory.NewChannelConsenters(&chs[i], consenters, orderers))
 	}
 
 	response.Ok().
+		SetPayload(ret).
 		Result(c.JSON)
 }
 
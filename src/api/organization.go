@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"mictract/dao"
 	"mictract/enum"
@@ -11,6 +13,7 @@ import (
 	"mictract/model/response"
 	respFactory "mictract/service/factory/response"
 	"mictract/service"
+	"mictract/service/factory"
 	"net/http"
 	"strconv"
 )
@@ -41,29 +44,44 @@ func AddOrg(c *gin.Context) {
 	}
 	netSvc := service.NewNetworkService(net)
 
-	go func(){
-		var newOrg *model.Organization
-		if newOrg, err = netSvc.AddOrg(info.Nickname); err != nil {
-			global.Logger.Error("fail to add org", zap.Error(err))
-			dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusError)
-			return
+	job, err := factory.NewJobFactory().NewJob(enum.JobAddOrg, net.ID, 0)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("add organization %s", info.Nickname)); err != nil {
+			return err
+		}
+		// a failed AddOrg has removed everything it created
+		newOrg, err := netSvc.AddOrg(info.Nickname)
+		if err != nil {
+			return errors.WithMessage(err, "fail to add org")
 		}
+		jSvc.SetTargetID(newOrg.ID)
 		orgSvc := service.NewOrganizationService(newOrg)
 
 		// add rest peer
 		for i := 1; i < info.PeerCount; i++ {
+			if err := jSvc.Step(fmt.Sprintf("add peer to %s", newOrg.GetName())); err != nil {
+				dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusError)
+				return err
+			}
 			if _, err := orgSvc.AddPeer(); err != nil {
-				global.Logger.Error("fail to add rest peer", zap.Error(err))
 				dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusError)
-				return
+				return errors.WithMessage(err, "fail to add rest peer")
 			}
 		}
 		dao.UpdateOrganizationStatusByID(newOrg.ID, enum.StatusRunning)
 		global.Logger.Info("org has been created successfully!", zap.String("orgName", newOrg.GetName()))
-		return
-	}()
+		return nil
+	})
 
 	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
 		Result(c.JSON)
 }
 
@@ -120,4 +138,88 @@ func GetOrganizationByID(c *gin.Context)  {
 	response.Ok().
 		SetPayload(respFactory.NewOrg(org)).
 		Result(c.JSON)
-}
\ No newline at end of file
+}
+// DELETE /api/organization/:id/consortium
+// the organization must have been removed from all channels
+func RemoveOrgFromConsortium(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	org, err := dao.FindOrganizationByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	net, err := dao.FindNetworkByID(org.NetworkID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobRemoveOrgFromConsortium, net.ID, org.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("remove %s from consortium", org.GetName())); err != nil {
+			return err
+		}
+		return service.NewNetworkService(net).RemoveOrgFromConsortium(org.ID)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// PUT /api/organization/:id/crl
+// pushes the CRL of the organization into its channels again, eg: after a revocation failed halfway
+func DistributeOrgCRL(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	org, err := dao.FindOrganizationByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobDistributeCRL, org.NetworkID, org.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("distribute CRL of %s", org.GetName())); err != nil {
+			return err
+		}
+		return service.NewOrganizationService(org).DistributeCRL()
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"mictract/dao"
 	"mictract/enum"
+	"mictract/model"
 	"mictract/model/request"
 	"mictract/model/response"
 	"mictract/service"
 	"mictract/service/factory"
+	respFactory "mictract/service/factory/response"
 	"net/http"
 	"strconv"
 )
@@ -92,22 +95,23 @@ func GetPeerByID(c *gin.Context)  {
 		Result(c.JSON)
 }
 
-// =========================================================================================================
-
-// POST /api/peer/channel
+// POST /api/channel/:id/peer
 /* join channel
 {
-	"channelID": 10,
-	"peerNames": ["peer10.org5.net11.com"]
+	"peerIDs": [3, 4]
 }
 */
-
 func JoinPeerToChannel(c *gin.Context)  {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 	info := struct{
-		ChannelID  int 		`form:"channelID" json:"channelID" binding:"required"`
-		PeerNames  []string	`form:"peerNames" json:"peerNames" binding:"required"`
+		PeerIDs    []int	`form:"peerIDs" json:"peerIDs" binding:"required"`
 	}{}
-
 	if err := c.ShouldBindJSON(&info); err != nil {
 		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
 			SetMessage(err.Error()).
@@ -115,66 +119,198 @@ func JoinPeerToChannel(c *gin.Context)  {
 		return
 	}
 
-	ch, err := dao.FindChannelByID(info.ChannelID)
+	ch, err := dao.FindChannelByID(id)
 	if err != nil {
-		response.Err(http.StatusBadRequest, enum.CodeErrDB).
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	if err := service.NewChannelService(ch).JoinPeers(info.PeerIDs); err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
-	orderers, err := dao.FindAllOrderersInNetwork(ch.NetworkID)
+	response.Ok().Result(c.JSON)
+}
+
+// GET /api/peer/:id/channel
+// channels the peer has joined
+func ListChannelsInPeer(c *gin.Context) {
+	peer, ok := findPeer(c)
+	if !ok {
+		return
+	}
+
+	names, err := service.NewCaUserService(peer).GetJoinedChannel()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	chs, err := dao.FindAllChannelsInNetwork(peer.NetworkID)
 	if err != nil {
-		response.Err(http.StatusBadRequest, enum.CodeErrDB).
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
-	for _, peerName := range info.PeerNames {
-		peer := factory.NewCaUserFactory().NewCaUserFromDomainName(peerName)
-		if err := service.NewCaUserService(peer).JoinChannel(
-			ch.ID,
-			orderers[0].GetName()); err != nil {
-			response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
-				SetMessage(err.Error()).
-				Result(c.JSON)
-			return
+	joined := []model.Channel{}
+	for _, ch := range chs {
+		for _, name := range names {
+			if ch.GetName() == name {
+				joined = append(joined, ch)
+				break
+			}
 		}
 	}
 
-	response.Ok().Result(c.JSON)
-	return
+	response.Ok().
+		SetPayload(respFactory.NewChannels(joined)).
+		Result(c.JSON)
 }
 
-// GET /api/peer/channel
-/* list channel
-{
-	"peerName": "peer10.org5.net11.com"
+// DELETE /api/peer/:id/channel
+// param: channelID
+// the peer is stopped, unjoins the channel with `peer node unjoin`, then started again
+func UnjoinPeerFromChannel(c *gin.Context) {
+	peer, ok := findPeer(c)
+	if !ok {
+		return
+	}
+	info := struct{
+		ChannelID  int 		`form:"channelID" json:"channelID" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	ch, err := dao.FindChannelByID(info.ChannelID)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobUnjoinPeer, peer.NetworkID, peer.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("%s unjoin %s", peer.GetName(), ch.GetName())); err != nil {
+			return err
+		}
+		return service.NewCaUserService(peer).UnjoinChannel(ch.GetName())
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
 }
-payload
-{
-	channelID: nickname,
-	channelID: nickname,
-	...
+
+// POST /api/peer/:id/reset
+// the ledgers of all channels of the peer are reset to their genesis blocks with `peer node reset`
+func ResetPeerLedger(c *gin.Context) {
+	peer, ok := findPeer(c)
+	if !ok {
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobResetPeer, peer.NetworkID, peer.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("reset ledger of %s", peer.GetName())); err != nil {
+			return err
+		}
+		return service.NewCaUserService(peer).ResetLedger()
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
 }
-*/
 
-func ListChannelsInPeer(c *gin.Context) {
-	info := struct {
-		PeerName  string  `form:"peerName" json:"peerName" binding:"required"`
-	}{}
+// DELETE /api/peer/:id
+// the peer is stopped, its certificates are revoked and the new CRL is pushed into the channels
+func RemovePeer(c *gin.Context) {
+	peer, ok := findPeer(c)
+	if !ok {
+		return
+	}
+	org, err := dao.FindOrganizationByID(peer.OrganizationID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
 
-	if err := c.ShouldBindQuery(&info); err != nil {
+	job, err := factory.NewJobFactory().NewJob(enum.JobRemovePeer, peer.NetworkID, peer.ID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("remove %s", peer.GetName())); err != nil {
+			return err
+		}
+		return service.NewOrganizationService(org).RemovePeer(peer.ID)
+	})
+
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
+}
+
+// GET /api/channel/:id/peer
+// peers of the organizations in the channel, with their ledger height
+func ListPeersInChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
 		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
-	list, err := service.
-		NewCaUserService(factory.NewCaUserFactory().NewCaUserFromDomainName(info.PeerName)).
-		GetJoinedChannel()
+	ch, err := dao.FindChannelByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	peers, err := dao.FindAllPeersInChannel(ch)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+	heights, err := service.NewChannelService(ch).GetPeerHeights()
 	if err != nil {
 		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
 			SetMessage(err.Error()).
@@ -182,5 +318,33 @@ func ListChannelsInPeer(c *gin.Context) {
 		return
 	}
 
-	response.Ok().SetPayload(list).Result(c.JSON)
-}
\ No newline at end of file
+	response.Ok().
+		SetPayload(response.NewChannelPeers(peers, heights)).
+		Result(c.JSON)
+}
+
+// findPeer responds with an error if the peer in the path does not exist
+func findPeer(c *gin.Context) (*model.CaUser, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return nil, false
+	}
+
+	peer, err := dao.FindCaUserByID(id)
+	if err != nil {
+		response.Err(http.StatusNotFound, enum.CodeErrNotFound).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return nil, false
+	}
+	if peer.Type != "peer" {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(peer.GetName() + " is not a peer").
+			Result(c.JSON)
+		return nil, false
+	}
+	return peer, true
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"mictract/enum"
+	"mictract/model/response"
+	"mictract/service"
+	"net/http"
+)
+
+// GET /api/reconcile
+// lists k8s resources whose network has been deleted
+func ListOrphanObjects(c *gin.Context) {
+	objs, err := service.FindOrphanObjects()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(objs).
+		Result(c.JSON)
+}
+
+// DELETE /api/reconcile
+// deletes k8s resources whose network has been deleted
+func RemoveOrphanObjects(c *gin.Context) {
+	objs, err := service.RemoveOrphanObjects()
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrBlockchainNetworkError).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(objs).
+		Result(c.JSON)
+}
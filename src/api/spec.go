@@ -0,0 +1,216 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"mictract/model/request"
+	"mictract/model/response"
+	"mictract/service"
+	"mictract/service/factory"
+	respFactory "mictract/service/factory/response"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// bindSpec accepts both json and yaml (Content-Type: application/x-yaml)
+func bindSpec(c *gin.Context) (request.NetworkSpec, error) {
+	var spec request.NetworkSpec
+	if strings.Contains(c.ContentType(), "yaml") {
+		err := yaml.NewDecoder(c.Request.Body).Decode(&spec)
+		return spec, err
+	}
+	err := c.ShouldBindJSON(&spec)
+	return spec, err
+}
+
+// POST /api/spec/plan
+// param: NetworkSpec
+// reports what apply will do without changing anything
+func PlanSpec(c *gin.Context) {
+	spec, err := bindSpec(c)
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	plan, err := service.NewSpecService(&spec).Plan()
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	response.Ok().
+		SetPayload(plan).
+		Result(c.JSON)
+}
+
+// POST /api/spec/apply
+// param: NetworkSpec
+// plans and executes the plan under a job
+func ApplySpec(c *gin.Context) {
+	spec, err := bindSpec(c)
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrMissingArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	sSvc := service.NewSpecService(&spec)
+	plan, err := sSvc.Plan()
+	if err != nil {
+		response.Err(http.StatusBadRequest, enum.CodeErrBadArgument).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	job, err := factory.NewJobFactory().NewJob(enum.JobApplySpec, plan.NetworkID, plan.NetworkID)
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		return applyPlan(jSvc, sSvc, spec, plan)
+	})
+
+	response.Ok().
+		SetPayload(response.ApplySpec{
+			Plan: plan,
+			Job:  respFactory.NewJob(job),
+		}).
+		Result(c.JSON)
+}
+
+// applyPlan runs under a job, see ApplySpec
+func applyPlan(jSvc *service.JobService, sSvc *service.SpecService, spec request.NetworkSpec, plan *model.Plan) error {
+	var net *model.Network
+	var err error
+	if plan.NetworkID != 0 {
+		if net, err = dao.FindNetworkByID(plan.NetworkID); err != nil {
+			return err
+		}
+	}
+
+	for _, action := range plan.Actions {
+		if err := jSvc.Step(action.Description); err != nil {
+			return err
+		}
+
+		switch action.Type {
+		case enum.ActionCreateNetwork:
+			net, err = factory.NewNetworkFactory().NewNetwork(spec.Nickname, spec.Consensus, spec.ChannelMode)
+			if err != nil {
+				return err
+			}
+			jSvc.SetTargetID(net.ID)
+			if err := service.NewNetworkService(net).Deploy(); err != nil {
+				dao.UpdateNetworkStatusByID(net.ID, enum.StatusError)
+				return errors.WithMessage(err, "fail to deploy basic network")
+			}
+			dao.UpdateNetworkStatusByID(net.ID, enum.StatusRunning)
+
+		case enum.ActionAddOrderer:
+			if _, err := service.NewNetworkService(net).AddOrderer(); err != nil {
+				return errors.WithMessage(err, "fail to add orderer")
+			}
+
+		case enum.ActionAddOrg:
+			org, err := service.NewNetworkService(net).AddOrg(action.Organization)
+			if err != nil {
+				return errors.WithMessage(err, "fail to add org")
+			}
+			dao.UpdateOrganizationStatusByID(org.ID, enum.StatusRunning)
+
+		case enum.ActionAddPeer:
+			org, err := dao.FindOrganizationInNetworkByNickname(net.ID, action.Organization)
+			if err != nil {
+				return err
+			}
+			if _, err := service.NewOrganizationService(org).AddPeer(); err != nil {
+				return errors.WithMessage(err, "fail to add peer")
+			}
+
+		case enum.ActionAddChannel:
+			orgIDs := []int{}
+			for _, nickname := range action.Organizations {
+				org, err := dao.FindOrganizationInNetworkByNickname(net.ID, nickname)
+				if err != nil {
+					return err
+				}
+				orgIDs = append(orgIDs, org.ID)
+			}
+			ch, err := service.NewNetworkService(net).AddChannel(orgIDs, action.Channel)
+			if err != nil {
+				dao.UpdateChannelStatusByID(ch.ID, enum.StatusError)
+				return errors.WithMessage(err, "fail to add channel")
+			}
+			dao.UpdateChannelStatusByID(ch.ID, enum.StatusRunning)
+
+		case enum.ActionAddOrgToChannel:
+			ch, err := dao.FindChannelInNetworkByNickname(net.ID, action.Channel)
+			if err != nil {
+				return err
+			}
+			org, err := dao.FindOrganizationInNetworkByNickname(net.ID, action.Organization)
+			if err != nil {
+				return err
+			}
+			if err := service.NewChannelService(ch).AddOrgAndJoinPeers(org.ID); err != nil {
+				return errors.WithMessage(err, "fail to add org to channel")
+			}
+
+		case enum.ActionCreateChaincode:
+			if err := applyChaincode(jSvc, sSvc, net, action); err != nil {
+				return err
+			}
+		}
+	}
+
+	global.Logger.Info("spec has been applied successfully", zap.String("netName", net.GetName()))
+	return nil
+}
+
+func applyChaincode(jSvc *service.JobService, sSvc *service.SpecService, net *model.Network, action model.PlanAction) error {
+	ccSpec, err := sSvc.GetChaincodeSpec(action.Channel, action.Chaincode)
+	if err != nil {
+		return err
+	}
+	ch, err := dao.FindChannelInNetworkByNickname(net.ID, action.Channel)
+	if err != nil {
+		return err
+	}
+
+	src, err := ioutil.ReadFile(ccSpec.Path)
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("fail to read %s", ccSpec.Path))
+	}
+
+	cc, err := factory.NewChaincodeFactory().NewChaincode(ccSpec.Nickname, ch.ID, net.ID,
+		ccSpec.Label, ccSpec.Policy, ccSpec.Version, ccSpec.Sequence, ccSpec.InitRequired, false)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(cc.GetCCPath(), "src.tar.gz"), src, 0644); err != nil {
+		dao.UpdateChaincodeStatusByID(cc.ID, enum.StatusError)
+		return err
+	}
+
+	return service.NewChaincodePipeline(cc).Start(jSvc)
+}
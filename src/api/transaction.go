@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"mictract/dao"
 	"mictract/enum"
 	"mictract/global"
-	"mictract/model"
 	"mictract/model/request"
 	"mictract/model/response"
 	"mictract/service"
@@ -50,8 +50,16 @@ func InvokeChaincode(c *gin.Context)  {
 		return
 	}
 
-	go func(info request.InvokeCCReq, tx model.Transaction) {
-		txSvc := service.NewTransactionService(&tx)
+	job, err := factory.NewJobFactory().NewJob(enum.JobInvokeChaincode, 0, int(tx.ID))
+	if err != nil {
+		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
+			SetMessage(err.Error()).
+			Result(c.JSON)
+		return
+	}
+
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		txSvc := service.NewTransactionService(tx)
 
 		cc, err := dao.FindChaincodeByID(info.ChaincodeID)
 		if err != nil {
@@ -60,7 +68,7 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				"fail to get cc",
 			)
-			return
+			return errors.WithMessage(err, "fail to get cc")
 		}
 
 		if cc.Status != enum.StatusRunning {
@@ -69,7 +77,7 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				fmt.Sprintf("the chaincode%d's status is %s", cc.ID, cc.Status),
 			)
-			return
+			return errors.New(fmt.Sprintf("the chaincode%d's status is %s", cc.ID, cc.Status))
 		}
 
 		ch, err := dao.FindChannelByID(cc.ChannelID)
@@ -79,7 +87,7 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				"fail to get ch",
 			)
-			return
+			return errors.WithMessage(err, "fail to get ch")
 		}
 
 		global.Logger.Info("Obtaining channel client...")
@@ -90,7 +98,7 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				"fail to get user",
 			)
-			return
+			return errors.WithMessage(err, "fail to get user")
 		}
 		chClient, err := sdk.NewSDKClientFactory().NewChannelClientIncludeNetwork(user, ch)
 		if err != nil {
@@ -99,7 +107,7 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				"fail to get chClient",
 			)
-			return
+			return errors.WithMessage(err, "fail to get chClient")
 		}
 
 		var resp channel.Response
@@ -118,7 +126,7 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				base64.StdEncoding.EncodeToString([]byte(err.Error())),
 			)
-			return
+			return err
 		}
 		global.Logger.Info(fmt.Sprintf("txID = %s", resp.TransactionID))
 		if err := dao.UpdateTxIDByID(tx.ID, string(resp.TransactionID)); err != nil {
@@ -127,13 +135,15 @@ func InvokeChaincode(c *gin.Context)  {
 				enum.StatusError,
 				fmt.Sprintf("fail to update txID(txID = %s)", resp.TransactionID),
 			)
-			return
+			return errors.WithMessage(err, "fail to update txID")
 		}
 		dao.UpdateTransactionStatusAndMessageByID(tx.ID, enum.StatusSuccess, "well done")
+		return nil
+	})
 
-	}(info, *tx)
-
-	response.Ok().Result(c.JSON)
+	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
+		Result(c.JSON)
 }
 
 // GET /api/transaction
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	mspclient "github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
 	"mictract/dao"
@@ -10,6 +11,7 @@ import (
 	"mictract/model/response"
 	"mictract/service"
 	"mictract/service/factory"
+	respFactory "mictract/service/factory/response"
 	"mictract/service/factory/sdk"
 	"net/http"
 	"strconv"
@@ -152,11 +154,11 @@ func GetUserByID(c *gin.Context)  {
 }
 
 // DELETE /api/user
+// the certificates of the user are revoked and the new CRL is pushed into the channels of the organization
 func DeleteUser(c *gin.Context) {
 	var info request.DeleteUserReq
 	var user *model.CaUser
 	var org *model.Organization
-	var mspClient *mspclient.Client
 	var err error
 
 	if err := c.ShouldBindJSON(&info); err != nil {
@@ -192,31 +194,22 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// revoke
-	mspClient, err = sdk.NewSDKClientFactory().NewMSPClient(org)
+	job, err := factory.NewJobFactory().NewJob(enum.JobRevokeUser, org.NetworkID, user.ID)
 	if err != nil {
-		response.Err(http.StatusInternalServerError, enum.CodeErrNotFound).
-			SetMessage(err.Error()).
-			Result(c.JSON)
-		return
-	}
-
-	userSvc := service.NewCaUserService(user)
-
-	if err := userSvc.Revoke(mspClient); err != nil {
-		response.Err(http.StatusInternalServerError, enum.CodeErrCA).
-			SetMessage(err.Error()).
-			Result(c.JSON)
-		return
-	}
-
-	if err := dao.DeleteCaUserByID(user.ID); err != nil {
 		response.Err(http.StatusInternalServerError, enum.CodeErrDB).
 			SetMessage(err.Error()).
 			Result(c.JSON)
 		return
 	}
 
+	service.NewJobService(job).Run(func(jSvc *service.JobService) error {
+		if err := jSvc.Step(fmt.Sprintf("revoke %s", user.GetName())); err != nil {
+			return err
+		}
+		return service.NewOrganizationService(org).RevokeUser(user.ID)
+	})
+
 	response.Ok().
+		SetPayload(respFactory.NewJob(job)).
 		Result(c.JSON)
-}
\ No newline at end of file
+}
@@ -31,4 +31,13 @@ var (
 	NFS_SERVER_URL		= os.Getenv("NFS_SERVER_URL")
 	DB_SERVER_URL		= os.Getenv("DB_SERVER_URL")
 	DB_PW         		= os.Getenv("DB_PW")
+
+	// CC_IMAGE_REGISTRY is where chaincode pods pull their images from, eg: localhost:30501 of k8s/registry.
+	// Chaincode is built into an image if it is set, otherwise the pod runs the artefact on the NFS.
+	CC_IMAGE_REGISTRY	= os.Getenv("CC_IMAGE_REGISTRY")
+	// CC_IMAGE_PUSH_REGISTRY is the same registry seen from the builder pod, eg: registry.default.svc:5000,
+	// CC_IMAGE_REGISTRY is used if it is empty.
+	CC_IMAGE_PUSH_REGISTRY	= os.Getenv("CC_IMAGE_PUSH_REGISTRY")
+	// CC_IMAGE_REGISTRY_INSECURE pushes over plain http, for a registry in the cluster
+	CC_IMAGE_REGISTRY_INSECURE	= os.Getenv("CC_IMAGE_REGISTRY_INSECURE") == "true"
 )
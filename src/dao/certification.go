@@ -17,7 +17,7 @@ func FindCACertByOrganizationID(orgID int) (*model.Certification, error) {
 	}
 	certs := []model.Certification{}
 	if err := global.DB.
-		Where("user_type = ?", org.GetCAID()).
+		Where("user_type = ? and is_tls = ?", org.GetCAID(), false).
 		Find(&certs).Error; err != nil {
 		return &model.Certification{}, err
 	}
@@ -27,6 +27,25 @@ func FindCACertByOrganizationID(orgID int) (*model.Certification, error) {
 	return &certs[0], nil
 }
 
+// FindTLSCACertByOrganizationID returns the tls ca cert of the organization's nodes.
+// Only external organizations have a separate one, for the others it is the ca cert.
+func FindTLSCACertByOrganizationID(orgID int) (*model.Certification, error) {
+	org, err := FindOrganizationByID(orgID)
+	if err != nil {
+		return &model.Certification{}, err
+	}
+	certs := []model.Certification{}
+	if err := global.DB.
+		Where("user_type = ? and is_tls = ?", org.GetCAID(), true).
+		Find(&certs).Error; err != nil {
+		return &model.Certification{}, err
+	}
+	if len(certs) < 1 {
+		return FindCACertByOrganizationID(orgID)
+	}
+	return &certs[0], nil
+}
+
 func FindCertsByUserID(userID int, isTLS bool) ([]model.Certification, error) {
 	certs := []model.Certification{}
 	if err := global.DB.
@@ -81,6 +100,16 @@ func DeleteCertByID(certID int) error {
 	return  global.DB.Where("id = ?", certID).Delete(&model.Certification{}).Error
 }
 
+func DeleteCertsByUserID(userID int) error {
+	return global.DB.Where("user_id = ?", userID).Delete(&model.Certification{}).Error
+}
+
+func DeleteCACertByOrganization(org *model.Organization) error {
+	return global.DB.
+		Where("network_id = ? and user_type = ?", org.NetworkID, org.GetCAID()).
+		Delete(&model.Certification{}).Error
+}
+
 func DeleteAllCertificationsInNetwork(netID int) error {
 	return global.DB.Where("network_id = ?", netID).Delete(&model.Certification{}).Error
 }
@@ -29,6 +29,9 @@ func DeleteChaincodeByID(ccID int) error {
 	if err := global.DB.Where("id = ?", ccID).Delete(&model.Chaincode{}).Error; err != nil {
 		return err
 	}
+	if err := global.DB.Where("chaincode_id = ?", ccID).Delete(&model.ChaincodeVersion{}).Error; err != nil {
+		return err
+	}
 	if err := os.RemoveAll(filepath.Join(config.LOCAL_CC_PATH, fmt.Sprintf("chaincode%d", ccID))); err != nil {
 		return err
 	}
@@ -43,6 +46,14 @@ func FindAllChaincodes() ([]model.Chaincode, error) {
 	return ccs, nil
 }
 
+func FindAllChaincodesInChannel(chID int) ([]model.Chaincode, error) {
+	ccs := []model.Chaincode{}
+	if err := global.DB.Where("channel_id = ?", chID).Find(&ccs).Error; err != nil {
+		return []model.Chaincode{}, err
+	}
+	return ccs, nil
+}
+
 func UpdateChaincodeNickname(ccID int, newNickname string) error {
 	if err := global.DB.Model(&model.Chaincode{}).
 		Where("id = ?", ccID).
@@ -56,10 +67,68 @@ func UpdateChaincodeStatusByID(ccID int, status string) error {
 	return global.DB.Model(&model.Chaincode{}).Where("id = ?", ccID).Update("status", status).Error
 }
 
+// UpdateChaincodeStageByID records the pipeline stage, which is the status as well while it runs
+func UpdateChaincodeStageByID(ccID int, stage string) error {
+	return global.DB.Model(&model.Chaincode{}).Where("id = ?", ccID).Updates(map[string]interface{}{
+		"stage":  stage,
+		"status": stage,
+	}).Error
+}
+
+// SwapChaincodeStatusByID sets the status only if it is one of from, and reports whether it did,
+// so that of concurrent requests only one moves the chaincode on.
+func SwapChaincodeStatusByID(ccID int, from []string, to string) (bool, error) {
+	result := global.DB.Model(&model.Chaincode{}).
+		Where("id = ? AND status in ?", ccID, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+func UpdateChaincodeOrgResults(cc *model.Chaincode) error {
+	return global.DB.Model(&model.Chaincode{}).Where("id = ?", cc.ID).Update("org_results", cc.OrgResults).Error
+}
+
 func UpdateChaincodePackageIDByID(ccID int, packageID string) error  {
 	return global.DB.Model(&model.Chaincode{}).Where("id = ?", ccID).Update("package_id", packageID).Error
 }
 
+// UpdateChaincodeDefinition saves the definition committed by an upgrade
+func UpdateChaincodeDefinition(cc *model.Chaincode) error {
+	return global.DB.Model(&model.Chaincode{}).Where("id = ?", cc.ID).Updates(map[string]interface{}{
+		"label":      cc.Label,
+		"policy_str": cc.PolicyStr,
+		"version":    cc.Version,
+		"sequence":   cc.Sequence,
+		"package_id": cc.PackageID,
+		"language":   cc.Language,
+		"image":      cc.Image,
+	}).Error
+}
+
+// UpdateChaincodeBuild saves what the build has found and produced
+func UpdateChaincodeBuild(cc *model.Chaincode) error {
+	return global.DB.Model(&model.Chaincode{}).Where("id = ?", cc.ID).Updates(map[string]interface{}{
+		"language": cc.Language,
+		"image":    cc.Image,
+	}).Error
+}
+
 func DeleteAllChaincodesInNetwork(netID int) error {
+	if err := global.DB.Where("network_id = ?", netID).Delete(&model.ChaincodeVersion{}).Error; err != nil {
+		return err
+	}
 	return global.DB.Where("network_id = ?", netID).Delete(&model.Chaincode{}).Error
 }
+
+func InsertChaincodeVersion(ccv *model.ChaincodeVersion) error {
+	return global.DB.Create(ccv).Error
+}
+
+// FindAllVersionsOfChaincode returns the definitions of the chaincode, the oldest first
+func FindAllVersionsOfChaincode(ccID int) ([]model.ChaincodeVersion, error) {
+	ccvs := []model.ChaincodeVersion{}
+	if err := global.DB.Where("chaincode_id = ?", ccID).Order("sequence").Find(&ccvs).Error; err != nil {
+		return []model.ChaincodeVersion{}, err
+	}
+	return ccvs, nil
+}
@@ -21,6 +21,17 @@ func FindChannelByID(chID int) (*model.Channel, error) {
 	return &chs[0], nil
 }
 
+func FindChannelInNetworkByNickname(netID int, nickname string) (*model.Channel, error) {
+	var chs []model.Channel
+	if err := global.DB.Where("network_id = ? and nickname = ?", netID, nickname).Find(&chs).Error; err != nil {
+		return &model.Channel{}, err
+	}
+	if len(chs) == 0 {
+		return &model.Channel{}, errors.New("channel not found")
+	}
+	return &chs[0], nil
+}
+
 func UpdateOrgIDs(chID, orgID int) error {
 	// 加个互斥锁
 	global.ChannelLock.Lock()
@@ -36,6 +47,40 @@ func UpdateOrgIDs(chID, orgID int) error {
 	return global.DB.Model(ch).Update("organization_ids", ch.OrganizationIDs).Error
 }
 
+func RemoveOrgID(chID, orgID int) error {
+	global.ChannelLock.Lock()
+	defer global.ChannelLock.Unlock()
+
+	ch, err := FindChannelByID(chID)
+	if err != nil {
+		return err
+	}
+
+	orgIDs := []int{}
+	for _, id := range ch.OrganizationIDs {
+		if id != orgID {
+			orgIDs = append(orgIDs, id)
+		}
+	}
+	ch.OrganizationIDs = orgIDs
+
+	return global.DB.Model(ch).Update("organization_ids", ch.OrganizationIDs).Error
+}
+
+func UpdateOrdererIDs(chID int, ordererIDs []int) error {
+	global.ChannelLock.Lock()
+	defer global.ChannelLock.Unlock()
+
+	ch, err := FindChannelByID(chID)
+	if err != nil {
+		return err
+	}
+
+	ch.OrdererIDs = ordererIDs
+
+	return global.DB.Model(ch).Update("orderer_ids", ch.OrdererIDs).Error
+}
+
 func UpdateChannelStatusByID(chID int, status string) error {
 	return global.DB.Model(&model.Channel{}).Where("id = ?", chID).Update("status", status).Error
 }
@@ -67,6 +112,23 @@ func FindAllPeersInChannel(c *model.Channel) ([]model.CaUser, error) {
 	return peers, nil
 }
 
+func FindAllChannelsOfOrganization(org *model.Organization) ([]model.Channel, error) {
+	chs, err := FindAllChannelsInNetwork(org.NetworkID)
+	if err != nil {
+		return []model.Channel{}, err
+	}
+	ret := []model.Channel{}
+	for _, ch := range chs {
+		for _, id := range ch.OrganizationIDs {
+			if id == org.ID {
+				ret = append(ret, ch)
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
 func DeleteAllChannelsInNetwork(netID int) error {
 	return global.DB.Where("network_id = ?", netID).Delete(&model.Channel{}).Error
 }
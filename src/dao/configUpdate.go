@@ -0,0 +1,111 @@
+package dao
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"mictract/global"
+	"mictract/model"
+)
+
+func InsertConfigUpdate(cu *model.ConfigUpdate) error {
+	return global.DB.Create(cu).Error
+}
+
+func FindConfigUpdateByID(id int) (*model.ConfigUpdate, error) {
+	var cus []model.ConfigUpdate
+	if err := global.DB.Where("id = ?", id).Find(&cus).Error; err != nil {
+		return &model.ConfigUpdate{}, err
+	}
+	if len(cus) < 1 {
+		return &model.ConfigUpdate{}, errors.New(fmt.Sprintf("no such config update(id = %d)", id))
+	}
+	return &cus[0], nil
+}
+
+func FindAllConfigUpdatesInChannel(chID int) ([]model.ConfigUpdate, error) {
+	cus := []model.ConfigUpdate{}
+	if err := global.DB.Where("channel_id = ?", chID).Order("id desc").Find(&cus).Error; err != nil {
+		return []model.ConfigUpdate{}, err
+	}
+	return cus, nil
+}
+
+func FindAllConfigUpdatesInNetwork(netID int) ([]model.ConfigUpdate, error) {
+	cus := []model.ConfigUpdate{}
+	if err := global.DB.Where("network_id = ?", netID).Order("id desc").Find(&cus).Error; err != nil {
+		return []model.ConfigUpdate{}, err
+	}
+	return cus, nil
+}
+
+func UpdateConfigUpdateSignaturesByID(id int, signatures []model.ConfigSignature) error {
+	cu := model.ConfigUpdate{Signatures: signatures}
+	return global.DB.Model(&model.ConfigUpdate{}).
+		Where("id = ?", id).
+		Select("signatures").
+		Updates(&cu).
+		Error
+}
+
+// AddConfigUpdateSignaturesByID saves the signatures merge returns from the saved ones,
+// in a transaction holding the row, so that concurrent signers don't overwrite each other.
+// It fails unless the status is one of statuses, eg: once the update is claimed for submission.
+func AddConfigUpdateSignaturesByID(id int, statuses []string,
+	merge func(saved []model.ConfigSignature) ([]model.ConfigSignature, error)) ([]model.ConfigSignature, error) {
+	var signatures []model.ConfigSignature
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		var cus []model.ConfigUpdate
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			Find(&cus).Error; err != nil {
+			return err
+		}
+		if len(cus) < 1 {
+			return errors.New(fmt.Sprintf("no such config update(id = %d)", id))
+		}
+		cu := cus[0]
+		valid := false
+		for _, status := range statuses {
+			valid = valid || cu.Status == status
+		}
+		if !valid {
+			return errors.New(fmt.Sprintf("%s is %s", cu.GetName(), cu.Status))
+		}
+
+		var err error
+		if signatures, err = merge(cu.Signatures); err != nil {
+			return err
+		}
+		return tx.Model(&model.ConfigUpdate{}).
+			Where("id = ?", id).
+			Select("signatures").
+			Updates(&model.ConfigUpdate{Signatures: signatures}).
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+func UpdateConfigUpdateStatusByID(id int, status string, message string) error {
+	return global.DB.Model(&model.ConfigUpdate{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "error": message}).
+		Error
+}
+
+// SwapConfigUpdateStatusByID sets the status only if it is one of from, and reports whether it did,
+// so that of concurrent requests only one moves the update on.
+func SwapConfigUpdateStatusByID(id int, from []string, to string) (bool, error) {
+	result := global.DB.Model(&model.ConfigUpdate{}).
+		Where("id = ? AND status in ?", id, from).
+		Updates(map[string]interface{}{"status": to, "error": ""})
+	return result.RowsAffected == 1, result.Error
+}
+
+func DeleteAllConfigUpdatesInNetwork(netID int) error {
+	return global.DB.Where("network_id = ?", netID).Delete(&model.ConfigUpdate{}).Error
+}
@@ -0,0 +1,95 @@
+package dao
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"time"
+)
+
+func InsertJob(job *model.Job) error {
+	return global.DB.Create(job).Error
+}
+
+func FindJobByID(id int) (*model.Job, error) {
+	var jobs []model.Job
+	if err := global.DB.Where("id = ?", id).Find(&jobs).Error; err != nil {
+		return &model.Job{}, err
+	}
+	if len(jobs) < 1 {
+		return &model.Job{}, errors.New(fmt.Sprintf("no such job(id = %d)", id))
+	}
+	return &jobs[0], nil
+}
+
+func FindAllJobs() ([]model.Job, error) {
+	jobs := []model.Job{}
+	if err := global.DB.Order("id desc").Find(&jobs).Error; err != nil {
+		return []model.Job{}, err
+	}
+	return jobs, nil
+}
+
+func FindAllJobsInNetwork(netID int) ([]model.Job, error) {
+	jobs := []model.Job{}
+	if err := global.DB.Where("network_id = ?", netID).Order("id desc").Find(&jobs).Error; err != nil {
+		return []model.Job{}, err
+	}
+	return jobs, nil
+}
+
+// FindUnfinishedJobs returns pending and running jobs
+func FindUnfinishedJobs() ([]model.Job, error) {
+	jobs := []model.Job{}
+	if err := global.DB.
+		Where("status in ?", []string{enum.StatusPending, enum.StatusRunning}).
+		Find(&jobs).Error; err != nil {
+		return []model.Job{}, err
+	}
+	return jobs, nil
+}
+
+func UpdateJobStatusByID(id int, status string) error {
+	return global.DB.Model(&model.Job{}).
+		Where("id = ?", id).
+		Update("status", status).
+		Error
+}
+
+func UpdateJobTargetIDByID(id int, targetID int) error {
+	return global.DB.Model(&model.Job{}).
+		Where("id = ?", id).
+		Update("target_id", targetID).
+		Error
+}
+
+func UpdateJobStepsByID(id int, steps []string) error {
+	job := model.Job{Steps: steps}
+	return global.DB.Model(&model.Job{}).
+		Where("id = ?", id).
+		Select("steps").
+		Updates(&job).
+		Error
+}
+
+func FinishJobByID(id int, status string, message string) error {
+	return global.DB.Model(&model.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "error": message, "finished_at": time.Now()}).
+		Error
+}
+
+// FinishUnfinishedJobByID finishes the job only if it is pending or running, and reports whether it did,
+// so that a job which has just finished is not overwritten.
+func FinishUnfinishedJobByID(id int, status string, message string) (bool, error) {
+	result := global.DB.Model(&model.Job{}).
+		Where("id = ? AND status in ?", id, []string{enum.StatusPending, enum.StatusRunning}).
+		Updates(map[string]interface{}{"status": status, "error": message, "finished_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
+
+func DeleteAllJobsInNetwork(netID int) error {
+	return global.DB.Where("network_id = ?", netID).Delete(&model.Job{}).Error
+}
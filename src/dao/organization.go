@@ -29,6 +29,20 @@ func FindOrganizationByID(orgID int) (*model.Organization, error) {
 	return &orgs[0], nil
 }
 
+// FindOrganizationInNetworkByNickname returns the first peer organization with the nickname
+func FindOrganizationInNetworkByNickname(netID int, nickname string) (*model.Organization, error) {
+	var orgs []model.Organization
+	if err := global.DB.
+		Where("network_id = ? and nickname = ? and is_orderer_org = ?", netID, nickname, false).
+		Find(&orgs).Error; err != nil {
+		return &model.Organization{}, err
+	}
+	if len(orgs) < 1 {
+		return &model.Organization{}, errors.New("no such org")
+	}
+	return &orgs[0], nil
+}
+
 func FindSystemUserInOrganization(orgID int) (*model.CaUser, error) {
 	var sysUsers []model.CaUser
 	if err := global.DB.
@@ -71,4 +85,8 @@ func UpdateOrganizationStatusByID(orgID int, status string) error {
 
 func DeleteAllOrganizationsInNetwork(netID int) error {
 	return global.DB.Where("network_id = ?", netID).Delete(&model.Organization{}).Error
-}
\ No newline at end of file
+}
+
+func DeleteOrganizationByID(orgID int) error {
+	return global.DB.Where("id = ?", orgID).Delete(&model.Organization{}).Error
+}
@@ -9,8 +9,84 @@ const (
 	// chaincode
 	StatusUnpacking = "unpacking"
 	StatusBuilding  = "building"
+	StatusInstalling	= "installing"
+	StatusApproving		= "approving"
+	StatusCommitting	= "committing"
+	StatusUpgrading = "upgrading"
 
 	// transaction
 	StatusExecute	= "execute"
 	StatusSuccess	= "success"
+
+	// job
+	StatusPending	= "pending"
+	StatusCanceled	= "canceled"
+
+	// config update
+	StatusSubmitting	= "submitting"
+	StatusSubmitted		= "submitted"
+)
+
+// job type
+const (
+	JobCreateNetwork	= "create_network"
+	JobAddOrg			= "add_org"
+	JobAddChannel		= "add_channel"
+	JobCreateChaincode	= "create_chaincode"
+	JobInvokeChaincode	= "invoke_chaincode"
+	JobApplySpec		= "apply_spec"
+	JobImportNetwork	= "import_network"
+	JobAddOrderer		= "add_orderer"
+	JobRemoveOrderer	= "remove_orderer"
+	JobUpdatePolicy		= "update_policy"
+	JobSubmitConfigUpdate	= "submit_config_update"
+	JobRemoveOrgFromChannel		= "remove_org_from_channel"
+	JobRemoveOrgFromConsortium	= "remove_org_from_consortium"
+	JobUpdateChannelParameters	= "update_channel_parameters"
+	JobSetAnchors				= "set_anchors"
+	JobUnjoinPeer				= "unjoin_peer"
+	JobResetPeer				= "reset_peer"
+	JobRemovePeer				= "remove_peer"
+	JobRevokeUser				= "revoke_user"
+	JobDistributeCRL			= "distribute_crl"
+	JobUpgradeChaincode			= "upgrade_chaincode"
+)
+
+// plan action type
+const (
+	ActionCreateNetwork		= "create_network"
+	ActionAddOrderer		= "add_orderer"
+	ActionAddOrg			= "add_org"
+	ActionAddPeer			= "add_peer"
+	ActionAddChannel		= "add_channel"
+	ActionAddOrgToChannel	= "add_org_to_channel"
+	ActionCreateChaincode	= "create_chaincode"
+)
+
+// network channel mode
+const (
+	// orderers are bootstrapped with the genesis block of system-channel,
+	// organizations join the consortium before joining channels
+	ChannelModeSystemChannel	= "system-channel"
+	// orderers start without a system channel (Fabric 2.3+),
+	// channels are created by joining every orderer to the channel's genesis block
+	ChannelModeParticipation	= "participation"
+)
+
+// network consensus
+const (
+	ConsensusSolo		= "solo"
+	ConsensusEtcdraft	= "etcdraft"
+	// SmartBFT (Fabric 3.0+), there is no system channel, so BFT networks always use channel participation
+	ConsensusBFT		= "BFT"
+
+	// BFT tolerates f faulty orderers out of 3f+1
+	BFTMinOrderers		= 4
+)
+
+// chaincode language, detected from the source when building
+const (
+	ChaincodeLanguageGolang	= "golang"
+	ChaincodeLanguageNode	= "node"
+	ChaincodeLanguageJava	= "java"
 )
@@ -24,7 +24,10 @@ var (
 	K8sInformer			cache.SharedIndexInformer
 	K8sLister			v1.PodNamespaceLister
 
+	// ChannelLock guards read-modify-write of channel rows in db
 	ChannelLock			sync.Mutex
+	// ChannelLocks serializes config transactions per channel
+	ChannelLocks		= NewKeyedMutex()
 )
 
 var (
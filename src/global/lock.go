@@ -0,0 +1,32 @@
+package global
+
+import "sync"
+
+// KeyedMutex hands out one mutex per key, eg: one per channel.
+// Mutexes are never released, there are only a few keys per network.
+type KeyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func NewKeyedMutex() *KeyedMutex {
+	return &KeyedMutex{
+		locks: map[string]*sync.Mutex{},
+	}
+}
+
+// Lock blocks until the key is free and returns the function to unlock it.
+//
+// defer global.ChannelLocks.Lock("net1-channel1")()
+func (km *KeyedMutex) Lock(key string) func() {
+	km.mu.Lock()
+	l, ok := km.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		km.locks[key] = l
+	}
+	km.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
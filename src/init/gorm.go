@@ -41,8 +41,11 @@ func createTables() {
 		model.Organization{},
 		model.CaUser{},
 		model.Chaincode{},
+		model.ChaincodeVersion{},
 		model.Certification{},
 		model.Transaction{},
+		model.Job{},
+		model.ConfigUpdate{},
 	)
 
 	if err != nil {
@@ -3,12 +3,14 @@ package init
 import (
 	"mictract/global"
 	"mictract/model/kubernetes"
+	"mictract/service"
 )
 
 func init() {
 	_ = (&kubernetes.Tools{}).AwaitableCreate()
 	initDB()
 	createTables()
+	service.FailInterruptedJobs()
 }
 
 func Close() {
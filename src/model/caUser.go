@@ -18,6 +18,13 @@ type CaUser struct {
 	Type           	string
 	Password       	string
 	IsInOrdererOrganization  bool
+
+	// only for users and nodes of an external network.
+	// ExternalName replaces the generated name, ExternalURL is the full grpcs:// address of a node
+	// and ExternalHostOverride is the ssl-target-name-override from the connection profile
+	ExternalName 			string
+	ExternalURL 			string
+	ExternalHostOverride 	string
 }
 
 func (cu *CaUser) IsInOrdererOrg() bool {
@@ -39,6 +46,9 @@ func (cu *CaUser) GetURL() string {
 }
 
 func (cu *CaUser) GetName() (username string) {
+	if cu.ExternalName != "" {
+		return cu.ExternalName
+	}
 	switch cu.Type {
 	case "user":
 		if cu.IsInOrdererOrg() {
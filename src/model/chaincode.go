@@ -1,9 +1,12 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"mictract/config"
 	"path/filepath"
+	"time"
 )
 
 // Local chaincode
@@ -22,6 +25,56 @@ type Chaincode struct {
 	InitRequired 	bool 						`json:"init_required"`
 
 	PackageID	 	string						`json:"package_id"`
+	// Language is detected from the source when building, enum.ChaincodeLanguage*
+	Language		string						`json:"language"`
+	// Image is the image built from the artefact, empty if the pod runs the artefact on the NFS
+	Image			string						`json:"image"`
+	// ManualApproval leaves approving to each organization,
+	// the pipeline waits at approving until all of them have approved
+	ManualApproval	bool						`json:"manual_approval"`
+
+	// Stage is the pipeline stage running, or the one which failed if Status is error
+	Stage			string						`json:"stage"`
+	OrgResults		chaincodeOrgResults			`json:"org_results" gorm:"type:text"`
+}
+
+// ChaincodeOrgResult is the outcome of a per-organization stage (installing, approving) for one organization
+type ChaincodeOrgResult struct {
+	OrganizationID	int		`json:"organizationID"`
+	Stage			string	`json:"stage"`
+	// success error
+	Status			string	`json:"status"`
+	Error			string	`json:"error"`
+}
+
+// gorm need
+type chaincodeOrgResults []ChaincodeOrgResult
+func (arr chaincodeOrgResults) Value() (driver.Value, error) {
+	return json.Marshal(arr)
+}
+func (arr *chaincodeOrgResults) Scan(data interface{}) error {
+	// chaincodes created before results were kept
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(data.([]byte), &arr)
+}
+
+// ChaincodeVersion is a definition committed for a chaincode,
+// every upgrade adds one, the latest is the same as the Chaincode.
+type ChaincodeVersion struct {
+	ID  			int		                    `json:"id" gorm:"primarykey"`
+	ChaincodeID		int							`json:"chaincode_id"`
+	NetworkID	 	int							`json:"network_id"`
+
+	Label	 	 	string						`json:"label"`
+	PolicyStr    	string						`json:"policy"`
+	Version  	 	string 						`json:"version"`
+	Sequence 	 	int64  						`json:"sequence"`
+	InitRequired 	bool 						`json:"init_required"`
+	PackageID	 	string						`json:"package_id"`
+
+	CreatedAt		time.Time					`json:"created_at"`
 }
 
 func GetChaincodeNameByID(ccID int) string {
@@ -31,6 +84,27 @@ func (c *Chaincode) GetName() string {
 	return GetChaincodeNameByID(c.ID)
 }
 
+// Succeeded tells whether the per-organization stage has succeeded for the organization
+func (c *Chaincode) Succeeded(stage string, orgID int) bool {
+	for _, r := range c.OrgResults {
+		if r.Stage == stage && r.OrganizationID == orgID {
+			return r.Status == "success"
+		}
+	}
+	return false
+}
+
+// SetOrgResult replaces the result of the organization in the stage
+func (c *Chaincode) SetOrgResult(result ChaincodeOrgResult) {
+	for i, r := range c.OrgResults {
+		if r.Stage == result.Stage && r.OrganizationID == result.OrganizationID {
+			c.OrgResults[i] = result
+			return
+		}
+	}
+	c.OrgResults = append(c.OrgResults, result)
+}
+
 func (c *Chaincode) GetAddress() string {
 	return fmt.Sprintf(
 		"cc%d-chan%d-net%d:9999",
@@ -43,4 +117,9 @@ func (c *Chaincode)GetCCPath() string {
 	return filepath.Join(
 		config.LOCAL_CC_PATH,
 		fmt.Sprintf("chaincode%d", c.ID))
-}
\ No newline at end of file
+}
+
+// GetSrcArchivePath is where the source package of a replaced definition is kept
+func (c *Chaincode) GetSrcArchivePath(sequence int64) string {
+	return filepath.Join(c.GetCCPath(), "history", fmt.Sprintf("src-%d.tar.gz", sequence))
+}
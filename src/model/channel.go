@@ -14,6 +14,9 @@ type Channel struct {
 
 	OrganizationIDs		ints 				`json:"organization_ids"`
 	OrdererIDs          ints				`json:"orderer_ids"`
+
+	// the real name of a channel imported from an external network
+	ExternalName 		string 				`json:"externalName"`
 }
 
 // gorm need
@@ -32,5 +35,8 @@ func GetChannelNameByID(chID int) string {
 	return fmt.Sprintf("channel%d", chID)
 }
 func (c *Channel) GetName() string {
+	if c.ExternalName != "" {
+		return c.ExternalName
+	}
 	return GetChannelNameByID(c.ID)
 }
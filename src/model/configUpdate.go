@@ -0,0 +1,63 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ConfigUpdate is a channel config update waiting for the signatures of organizations
+// whose admins are not (all) held by mictract. It is submitted once the signatures satisfy
+// the mod policies of everything it changes.
+type ConfigUpdate struct {
+	ID			int					`json:"id" gorm:"primarykey"`
+	NetworkID	int					`json:"networkID"`
+	ChannelID	int					`json:"channelID"`
+	Description	string				`json:"description"`
+
+	// Envelope is the unsigned CONFIG_UPDATE envelope, which is what orgs download and sign,
+	// signatures are made over the ConfigUpdate bytes inside it.
+	Envelope	[]byte				`json:"-"`
+	// Sequence is the config sequence the update is computed from,
+	// the update is stale once the channel config moves on.
+	Sequence	uint64				`json:"sequence"`
+	Signatures	configSignatures	`json:"signatures" gorm:"type:text"`
+
+	// pending submitting submitted canceled error, a failed update can be submitted again
+	Status		string				`json:"status"`
+	Error		string				`json:"error" gorm:"type:text"`
+
+	CreatedAt	time.Time			`json:"createdAt"`
+	UpdatedAt	time.Time			`json:"updatedAt"`
+}
+
+// ConfigSignature is a detached signature of the ConfigUpdate in ConfigUpdate.Envelope,
+// the same as common.ConfigSignature plus the signer's MSP for display.
+type ConfigSignature struct {
+	MSPID			string	`json:"mspID"`
+	SignatureHeader	[]byte	`json:"signatureHeader"`
+	Signature		[]byte	`json:"signature"`
+}
+
+// gorm need
+type configSignatures []ConfigSignature
+func (arr configSignatures) Value() (driver.Value, error) {
+	return json.Marshal(arr)
+}
+func (arr *configSignatures) Scan(data interface{}) error {
+	return json.Unmarshal(data.([]byte), &arr)
+}
+
+func (cu *ConfigUpdate) GetName() string {
+	return fmt.Sprintf("config-update%d", cu.ID)
+}
+
+// SignedBy returns the MSP IDs which have signed the update
+func (cu *ConfigUpdate) SignedBy() []string {
+	ret := []string{}
+	for _, sig := range cu.Signatures {
+		ret = append(ret, sig.MSPID)
+	}
+	return ret
+}
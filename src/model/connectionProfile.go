@@ -0,0 +1,57 @@
+package model
+
+// ConnectionProfile is a standard Fabric connection profile,
+// which fabric-sdk-go, the Node and Java SDKs all understand.
+// Unlike SDKConfig it carries no private keys, and node addresses may be external ones.
+type ConnectionProfile struct {
+	Name 					string 									`json:"name" yaml:"name"`
+	Version 				string 									`json:"version" yaml:"version"`
+	Client 					ConnectionProfileClient 				`json:"client" yaml:"client"`
+	Organizations 			map[string]*ConnectionProfileOrganization 	`json:"organizations" yaml:"organizations"`
+	Orderers 				map[string]*ConnectionProfileNode 		`json:"orderers" yaml:"orderers"`
+	Peers 					map[string]*ConnectionProfileNode 		`json:"peers" yaml:"peers"`
+	Channels 				map[string]*ConnectionProfileChannel 	`json:"channels" yaml:"channels"`
+	CertificateAuthorities 	map[string]*ConnectionProfileCA 		`json:"certificateAuthorities" yaml:"certificateAuthorities"`
+}
+
+type ConnectionProfileClient struct {
+	Organization 	string 	`json:"organization" yaml:"organization"`
+}
+
+type ConnectionProfileOrganization struct {
+	Mspid 					string 		`json:"mspid" yaml:"mspid"`
+	Peers 					[]string 	`json:"peers" yaml:"peers"`
+	CertificateAuthorities 	[]string 	`json:"certificateAuthorities" yaml:"certificateAuthorities"`
+}
+
+type ConnectionProfileNode struct {
+	URL 		string 				`json:"url" yaml:"url"`
+	TLSCACerts 	SDKConfigPemJSON 	`json:"tlsCACerts" yaml:"tlsCACerts"`
+	// the tls certificate of the node is issued for its in-cluster host name
+	GRPCOptions map[string]interface{} `json:"grpcOptions" yaml:"grpcOptions"`
+}
+
+type ConnectionProfileChannel struct {
+	Orderers 	[]string 							`json:"orderers" yaml:"orderers"`
+	Peers 		map[string]ConnectionProfilePeerRole 	`json:"peers" yaml:"peers"`
+}
+
+type ConnectionProfilePeerRole struct {
+	EndorsingPeer  bool `json:"endorsingPeer" yaml:"endorsingPeer"`
+	ChaincodeQuery bool `json:"chaincodeQuery" yaml:"chaincodeQuery"`
+	LedgerQuery    bool `json:"ledgerQuery" yaml:"ledgerQuery"`
+	EventSource    bool `json:"eventSource" yaml:"eventSource"`
+}
+
+type ConnectionProfileCA struct {
+	URL 		string 				`json:"url" yaml:"url"`
+	CAName 		string 				`json:"caName" yaml:"caName"`
+	TLSCACerts 	struct {
+		Pem []string `json:"pem" yaml:"pem"`
+	} `json:"tlsCACerts" yaml:"tlsCACerts"`
+	HTTPOptions map[string]interface{} `json:"httpOptions" yaml:"httpOptions"`
+}
+
+type SDKConfigPemJSON struct {
+	Pem string `json:"pem" yaml:"pem"`
+}
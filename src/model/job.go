@@ -0,0 +1,35 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Job records a long-running operation (deploying a network, adding an org, ...)
+// which runs in background, so its progress survives a server restart.
+type Job struct {
+	ID			int			`json:"id" gorm:"primarykey"`
+	// create_network add_org add_channel create_chaincode invoke_chaincode
+	Type		string		`json:"type"`
+	// pending running success error canceled
+	Status		string		`json:"status"`
+
+	NetworkID	int			`json:"networkID"`
+	// TargetID is the id of the object the job creates or works on, depends on Type
+	TargetID	int			`json:"targetID"`
+
+	Steps		mystring	`json:"steps" gorm:"type:text"`
+	Error		string		`json:"error" gorm:"type:text"`
+
+	CreatedAt	time.Time	`json:"createdAt"`
+	UpdatedAt	time.Time	`json:"updatedAt"`
+	FinishedAt	*time.Time	`json:"finishedAt"`
+}
+
+func (j *Job) GetName() string {
+	return fmt.Sprintf("job%d(%s)", j.ID, j.Type)
+}
+
+func (j *Job) IsFinished() bool {
+	return j.FinishedAt != nil
+}
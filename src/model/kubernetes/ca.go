@@ -103,6 +103,7 @@ func (ca *CA) CreateConfigMap() {
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name + "-env",
+			Labels: ca.GetSelector(),
 		},
 		Data:	map[string]string{
 			"FABRIC_CA_HOME": "/etc/hyperledger/fabric-ca-server",
@@ -146,6 +147,7 @@ func (ca *CA) CreateDeployment() {
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: ca.GetSelector(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
@@ -235,6 +237,7 @@ func (ca *CA) CreateService() {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: ca.GetSelector(),
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     corev1.ServiceTypeClusterIP,
@@ -270,6 +273,7 @@ func (ca *CA) CreateIngress() {
 	ingress := &netv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: ca.GetSelector(),
 		},
 		Spec:       netv1.IngressSpec{
 			Rules: []netv1.IngressRule{
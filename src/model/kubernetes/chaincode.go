@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"mictract/config"
+	"mictract/enum"
 	"mictract/global"
 	"path/filepath"
 	"strconv"
@@ -20,14 +21,137 @@ type Chaincode struct {
 	PackageID		string
 	ChannelID 		int
 	NetworkID 		int
+	// Language decides how the built artefact is run, empty is golang
+	Language		string
+	// Image has the artefact built in, the artefact on the NFS is run if it is empty
+	Image			string
 }
 
-func NewChaincode(netID int, channelID int, packageID string, chaincodeID int) *Chaincode {
+func NewChaincode(netID int, channelID int, packageID string, chaincodeID int, language, image string) *Chaincode {
 	return &Chaincode{
 		NetworkID: netID,
 		ChannelID: channelID,
 		PackageID: packageID,
 		ChaincodeID: chaincodeID,
+		Language: language,
+		Image: image,
+	}
+}
+
+// the artefact built by scripts/external/build*.sh is mounted here
+const chaincodeArtefactPath = "/host/var/run/chaincode"
+
+// chaincodeRuntime is how the artefact of a language is run as a chaincode server
+type chaincodeRuntime struct {
+	Image		string
+	Command		[]string
+	WorkingDir	string
+}
+
+// chaincodeRuntimes by enum.ChaincodeLanguage*, the address and package ID come from the config map,
+// $(VAR) in the command is expanded by kubernetes.
+var chaincodeRuntimes = map[string]chaincodeRuntime{
+	// a static binary
+	enum.ChaincodeLanguageGolang: {
+		Image:		"alpine:3.11",
+		Command:	[]string{chaincodeArtefactPath},
+	},
+	// the package directory with its node_modules, served by fabric-shim
+	enum.ChaincodeLanguageNode: {
+		Image:		"node:12-alpine",
+		Command:	[]string{
+			"node_modules/.bin/fabric-chaincode-node", "server",
+			"--chaincode-address=$(CHAINCODE_ADDRESS)",
+			"--chaincode-id=$(CHAINCODE_CCID)",
+		},
+		WorkingDir:	chaincodeArtefactPath,
+	},
+	// a fat jar whose main starts a ChaincodeServer,
+	// fabric-chaincode-java reads CHAINCODE_SERVER_ADDRESS and CORE_CHAINCODE_ID_NAME
+	enum.ChaincodeLanguageJava: {
+		Image:		"openjdk:11-jre-slim",
+		Command:	[]string{"java", "-jar", chaincodeArtefactPath},
+	},
+}
+
+func (cc *Chaincode) getRuntime() chaincodeRuntime {
+	runtime, ok := chaincodeRuntimes[cc.Language]
+	if !ok {
+		// chaincodes built before languages were detected
+		runtime = chaincodeRuntimes[enum.ChaincodeLanguageGolang]
+	}
+	return runtime
+}
+
+// Dockerfile builds the image of the chaincode from a context holding the artefact as `chaincode`,
+// the artefact is put where the pod would mount it, so the command stays the same.
+func (cc *Chaincode) Dockerfile() string {
+	return fmt.Sprintf("FROM %s\nCOPY chaincode %s\n", cc.getRuntime().Image, chaincodeArtefactPath)
+}
+
+// newPodSpec returns the pod running the image if there is one, otherwise the artefact on the NFS
+func (cc *Chaincode) newPodSpec() apiv1.PodSpec {
+	container := cc.newContainer()
+	if cc.Image != "" {
+		container.Image = cc.Image
+		// the tag of a retried build is reused
+		container.ImagePullPolicy = apiv1.PullAlways
+		return apiv1.PodSpec{
+			Containers: []apiv1.Container{container},
+		}
+	}
+
+	container.VolumeMounts = []apiv1.VolumeMount{
+		{
+			Name:             "cc",
+			MountPath:        chaincodeArtefactPath,
+			SubPath: filepath.Join(
+				"chaincodes",
+				fmt.Sprintf("chaincode%d", cc.ChaincodeID),
+				"chaincode"),
+		},
+	}
+	return apiv1.PodSpec{
+		Containers: []apiv1.Container{container},
+		Volumes: []apiv1.Volume{
+			{
+				Name:         "cc",
+				VolumeSource: apiv1.VolumeSource{
+					NFS: &apiv1.NFSVolumeSource{
+						Server: config.NFS_SERVER_URL,
+						Path: config.NFS_EXPOSED_PATH,
+					},
+				},
+			},
+		},
+	}
+}
+
+// newContainer returns the chaincode container for the language
+func (cc *Chaincode) newContainer() apiv1.Container {
+	runtime := cc.getRuntime()
+
+	return apiv1.Container{
+		Name:  "chaincode",
+		Image: runtime.Image,
+		Command: runtime.Command,
+		WorkingDir: runtime.WorkingDir,
+		EnvFrom: []apiv1.EnvFromSource{
+			{
+				ConfigMapRef: &apiv1.ConfigMapEnvSource{
+					LocalObjectReference: apiv1.LocalObjectReference{
+						Name: cc.GetName() + "-env",
+					},
+				},
+			},
+		},
+		Ports: []apiv1.ContainerPort{
+			{
+				Name:          "chaincode",
+				Protocol:      apiv1.ProtocolTCP,
+				ContainerPort: 9999,
+			},
+		},
 	}
 }
 
@@ -60,11 +184,15 @@ func (cc *Chaincode) CreateConfigMap() {
 	configMap := &apiv1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name + "-env",
+			Labels: cc.GetSelector(),
 		},
 		Data:	map[string]string{
 			"WHISPER": "Marx bless, no bugs",
 			"CHAINCODE_ADDRESS": "0.0.0.0:9999",
 			"CHAINCODE_CCID": cc.PackageID,
+			// names used by the java shim
+			"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999",
+			"CORE_CHAINCODE_ID_NAME": cc.PackageID,
 		},
 	}
 
@@ -92,6 +220,7 @@ func (cc *Chaincode) CreateDeployment() {
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: cc.GetSelector(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
@@ -102,52 +231,7 @@ func (cc *Chaincode) CreateDeployment() {
 					Name: name,
 					Labels: matchlabels,
 				},
-				Spec: apiv1.PodSpec{
-					Containers: []apiv1.Container{
-						{
-							Name:  "chaincode",
-							Image: "alpine:3.11",
-							Command: []string{"/host/var/run/chaincode"},
-							EnvFrom: []apiv1.EnvFromSource{
-								{
-									ConfigMapRef: &apiv1.ConfigMapEnvSource{
-										LocalObjectReference: apiv1.LocalObjectReference{
-											Name: name + "-env",
-										},
-									},
-								},
-							},
-							Ports: []apiv1.ContainerPort{
-								{
-									Name:          "chaincode",
-									Protocol:      apiv1.ProtocolTCP,
-									ContainerPort: 9999,
-								},
-							},
-							VolumeMounts: []apiv1.VolumeMount{
-								{
-									Name:             "cc",
-									MountPath:        "/host/var/run/chaincode",
-									SubPath: filepath.Join(
-										"chaincodes",
-										fmt.Sprintf("chaincode%d", cc.ChaincodeID),
-										"chaincode"),
-								},
-							},
-						},
-					},
-					Volumes: []apiv1.Volume{
-						{
-							Name:         "cc",
-							VolumeSource: apiv1.VolumeSource{
-								NFS: &apiv1.NFSVolumeSource{
-									Server: config.NFS_SERVER_URL,
-									Path: config.NFS_EXPOSED_PATH,
-								},
-							},
-						},
-					},
-				},
+				Spec: cc.newPodSpec(),
 			},
 		},
 	}
@@ -168,6 +252,7 @@ func (cc *Chaincode) CreateService() {
 	service := &apiv1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: cc.GetSelector(),
 		},
 		Spec: apiv1.ServiceSpec{
 			Type: apiv1.ServiceTypeClusterIP,
@@ -241,6 +326,48 @@ func (cc *Chaincode) Delete() {
 	}
 }
 
+// Rollout points the running chaincode at cc.PackageID and restarts its pods,
+// the shim registers itself with CHAINCODE_CCID, which must match the committed definition.
+// The deployment replaces the old pod once the new one is running.
+func (cc *Chaincode) Rollout() error {
+	name := cc.GetName()
+
+	configMap, err := global.K8sClientset.CoreV1().
+		ConfigMaps(apiv1.NamespaceDefault).
+		Get(context.TODO(), name + "-env", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	configMap.Data["CHAINCODE_CCID"] = cc.PackageID
+	configMap.Data["CORE_CHAINCODE_ID_NAME"] = cc.PackageID
+	configMap.Data["CHAINCODE_SERVER_ADDRESS"] = "0.0.0.0:9999"
+	if _, err := global.K8sClientset.CoreV1().
+		ConfigMaps(apiv1.NamespaceDefault).
+		Update(context.TODO(), configMap, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	// pods don't see changes of the config map, a new pod template makes the deployment roll,
+	// the container follows the language and the image, which may change with the new source
+	deployment, err := global.K8sClientset.AppsV1().
+		Deployments(apiv1.NamespaceDefault).
+		Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations["mictract/package-id"] = cc.PackageID
+	podSpec := cc.newPodSpec()
+	deployment.Spec.Template.Spec.Containers = podSpec.Containers
+	deployment.Spec.Template.Spec.Volumes = podSpec.Volumes
+	_, err = global.K8sClientset.AppsV1().
+		Deployments(apiv1.NamespaceDefault).
+		Update(context.TODO(), deployment, metav1.UpdateOptions{})
+	return err
+}
+
 func (cc *Chaincode) Watch() {
 	watch(cc, &cc.callback)
 }
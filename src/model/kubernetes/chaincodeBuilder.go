@@ -0,0 +1,179 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mictract/config"
+	"mictract/global"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ChaincodeBuilder is a kaniko pod building the image of a chaincode and pushing it to the registry,
+// the context is chaincodes/chaincode<id>/image on the NFS, which has the Dockerfile and the artefact.
+type ChaincodeBuilder struct {
+	callback
+	ChaincodeID		int
+	ChannelID 		int
+	NetworkID 		int
+	// Destination is the image pushed, in the name the builder reaches the registry by
+	Destination		string
+}
+
+func NewChaincodeBuilder(netID int, channelID int, chaincodeID int, destination string) *ChaincodeBuilder {
+	return &ChaincodeBuilder{
+		NetworkID: netID,
+		ChannelID: channelID,
+		ChaincodeID: chaincodeID,
+		Destination: destination,
+	}
+}
+
+func (b *ChaincodeBuilder) GetName() string {
+	return fmt.Sprintf(
+		"cc%d-chan%d-net%d-builder",
+		b.ChaincodeID,
+		b.ChannelID,
+		b.NetworkID)
+}
+
+func (b *ChaincodeBuilder) GetSelector() map[string]string {
+	return map[string]string{
+		"app": "mictract",
+		"net": strconv.Itoa(b.NetworkID),
+		"channel": strconv.Itoa(b.ChannelID),
+		"chaincode": strconv.Itoa(b.ChaincodeID),
+		"tier": "chaincode-builder",
+	}
+}
+
+func (b *ChaincodeBuilder) GetPod() (*apiv1.Pod, error) {
+	return getPod(b)
+}
+
+// Connect to K8S to create the pod, which exits once the image is pushed.
+func (b *ChaincodeBuilder) Create() {
+	name := b.GetName()
+
+	args := []string{
+		"--context=dir:///workspace",
+		"--dockerfile=/workspace/Dockerfile",
+		"--destination=" + b.Destination,
+	}
+	if config.CC_IMAGE_REGISTRY_INSECURE {
+		args = append(args, "--insecure", "--skip-tls-verify")
+	}
+
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: b.GetSelector(),
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy: apiv1.RestartPolicyNever,
+			Containers: []apiv1.Container{
+				{
+					Name:  "kaniko",
+					Image: "gcr.io/kaniko-project/executor:v1.6.0",
+					Args: args,
+					VolumeMounts: []apiv1.VolumeMount{
+						{
+							Name:             "context",
+							MountPath:        "/workspace",
+							SubPath: filepath.Join(
+								"chaincodes",
+								fmt.Sprintf("chaincode%d", b.ChaincodeID),
+								"image"),
+						},
+					},
+				},
+			},
+			Volumes: []apiv1.Volume{
+				{
+					Name:         "context",
+					VolumeSource: apiv1.VolumeSource{
+						NFS: &apiv1.NFSVolumeSource{
+							Server: config.NFS_SERVER_URL,
+							Path: config.NFS_EXPOSED_PATH,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := global.K8sClientset.CoreV1().
+		Pods(apiv1.NamespaceDefault).
+		Create(context.TODO(), pod, metav1.CreateOptions{})
+
+	if err != nil {
+		global.Logger.Error("Create chaincode builder pod error", zap.Error(err))
+	}
+}
+
+func (b *ChaincodeBuilder) AwaitableCreate() error {
+	return awaitableCreate(b)
+}
+
+// Connect to K8S to delete the pod.
+func (b *ChaincodeBuilder) Delete() {
+	err := global.K8sClientset.CoreV1().
+		Pods(apiv1.NamespaceDefault).
+		Delete(context.TODO(), b.GetName(), metav1.DeleteOptions{})
+
+	if err != nil {
+		global.Logger.Error("Delete chaincode builder pod error", zap.Error(err))
+	}
+}
+
+// Build runs the builder to completion and deletes it,
+// a builder left by an interrupted build is deleted first. The log of kaniko is returned on failure.
+func (b *ChaincodeBuilder) Build(timeout time.Duration) error {
+	if pod, err := b.GetPod(); err != nil {
+		return err
+	} else if pod != nil {
+		if err := awaitableDelete(b, time.Minute); err != nil {
+			return err
+		}
+	}
+
+	b.Create()
+	defer b.Delete()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pod, err := global.K8sClientset.CoreV1().
+			Pods(apiv1.NamespaceDefault).
+			Get(context.TODO(), b.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		switch pod.Status.Phase {
+		case apiv1.PodSucceeded:
+			return nil
+		case apiv1.PodFailed:
+			log, _ := global.K8sClientset.CoreV1().
+				Pods(apiv1.NamespaceDefault).
+				GetLogs(b.GetName(), &apiv1.PodLogOptions{}).
+				Do(context.TODO()).
+				Raw()
+			return fmt.Errorf("fail to build %s: %s", b.Destination, string(log))
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for %s", b.GetName())
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func (b *ChaincodeBuilder) Watch() {
+	watch(b, &b.callback)
+}
+
+func (b *ChaincodeBuilder) ExecCommand(cmd ...string) (string, string, error) {
+	return execCommand(b, cmd...)
+}
@@ -0,0 +1,17 @@
+package kubernetes_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"mictract/model/kubernetes"
+	"testing"
+)
+
+func TestChaincodeDockerfile(t *testing.T) {
+	assert.Equal(t,
+		"FROM node:12-alpine\nCOPY chaincode /host/var/run/chaincode\n",
+		kubernetes.NewChaincode(1, 1, "mycc:123", 1, "node", "").Dockerfile())
+	// chaincodes built before languages were detected are golang
+	assert.Equal(t,
+		"FROM alpine:3.11\nCOPY chaincode /host/var/run/chaincode\n",
+		kubernetes.NewChaincode(1, 1, "mycc:123", 1, "", "").Dockerfile())
+}
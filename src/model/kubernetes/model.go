@@ -12,6 +12,7 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 	"mictract/global"
 	"sync"
+	"time"
 )
 
 type K8sModel interface {
@@ -89,6 +90,12 @@ func watch(m K8sModel, cb *callback) {
 }
 
 func execCommand(m K8sModel, cmd ...string) (string, string, error) {
+	return execCommandInContainer(m, "", cmd...)
+}
+
+// execCommandInContainer is execCommand for pods with more than one container,
+// container can be empty if there is only one.
+func execCommandInContainer(m K8sModel, container string, cmd ...string) (string, string, error) {
 	var podName string
 	if pod, err := m.GetPod(); err != nil {
 		return "", "", err
@@ -102,6 +109,7 @@ func execCommand(m K8sModel, cmd ...string) (string, string, error) {
 		Namespace(apiv1.NamespaceDefault).
 		SubResource("exec").
 		VersionedParams(&apiv1.PodExecOptions{
+			Container: container,
 			Command:   cmd,
 			Stdout:    true,
 			Stderr:    true,
@@ -176,6 +184,27 @@ func awaitableCreate(m K8sModel) (err error) {
 }
 
 
+// awaitableDelete deletes your k8s model and waits until its pod is gone,
+// so that files the pod holds, eg: the ledger of a peer, can be used by others.
+func awaitableDelete(m K8sModel, timeout time.Duration) error {
+	m.Delete()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pod, err := m.GetPod()
+		if err != nil {
+			return err
+		}
+		if pod == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for %s to be deleted", m.GetName())
+		}
+		time.Sleep(time.Second)
+	}
+}
+
 type callback struct {
 	// onPodPhaseUpdateOnce is a list contains callback `func(apiv1.PodPhase, apiv1.PodPhase) bool`.
 	// Note: each callback here can decide to remove itself.
@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"context"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mictract/global"
+	"strconv"
+)
+
+// Object is a top-level kubernetes resource created by mictract,
+// i.e. a deployment, service, configmap or ingress of some K8sModel.
+type Object struct {
+	Kind 		string 				`json:"kind"`
+	Name 		string 				`json:"name"`
+	Labels 		map[string]string 	`json:"labels"`
+}
+
+// GetNetworkID returns -1 if the object does not belong to a network (e.g. tools).
+func (obj *Object) GetNetworkID() int {
+	netID, err := strconv.Atoi(obj.Labels["net"])
+	if err != nil {
+		return -1
+	}
+	return netID
+}
+
+// ListObjects lists deployments, services, configmaps and ingresses matching the label selector,
+// e.g. "app=mictract,net=1"
+func ListObjects(selector string) ([]Object, error) {
+	opts := metav1.ListOptions{LabelSelector: selector}
+	objs := []Object{}
+	add := func(kind string, meta metav1.ObjectMeta) {
+		objs = append(objs, Object{Kind: kind, Name: meta.Name, Labels: meta.Labels})
+	}
+
+	deployments, err := global.K8sClientset.AppsV1().
+		Deployments(apiv1.NamespaceDefault).
+		List(context.TODO(), opts)
+	if err != nil {
+		return objs, err
+	}
+	for _, item := range deployments.Items {
+		add("Deployment", item.ObjectMeta)
+	}
+
+	services, err := global.K8sClientset.CoreV1().
+		Services(apiv1.NamespaceDefault).
+		List(context.TODO(), opts)
+	if err != nil {
+		return objs, err
+	}
+	for _, item := range services.Items {
+		add("Service", item.ObjectMeta)
+	}
+
+	configMaps, err := global.K8sClientset.CoreV1().
+		ConfigMaps(apiv1.NamespaceDefault).
+		List(context.TODO(), opts)
+	if err != nil {
+		return objs, err
+	}
+	for _, item := range configMaps.Items {
+		add("ConfigMap", item.ObjectMeta)
+	}
+
+	ingresses, err := global.K8sClientset.NetworkingV1().
+		Ingresses(apiv1.NamespaceDefault).
+		List(context.TODO(), opts)
+	if err != nil {
+		return objs, err
+	}
+	for _, item := range ingresses.Items {
+		add("Ingress", item.ObjectMeta)
+	}
+
+	return objs, nil
+}
+
+// DeleteObject deletes the resource, pods of a deployment are deleted in cascade.
+func DeleteObject(obj Object) error {
+	var err error
+	switch obj.Kind {
+	case "Deployment":
+		policy := metav1.DeletePropagationBackground
+		err = global.K8sClientset.AppsV1().
+			Deployments(apiv1.NamespaceDefault).
+			Delete(context.TODO(), obj.Name, metav1.DeleteOptions{PropagationPolicy: &policy})
+	case "Service":
+		err = global.K8sClientset.CoreV1().
+			Services(apiv1.NamespaceDefault).
+			Delete(context.TODO(), obj.Name, metav1.DeleteOptions{})
+	case "ConfigMap":
+		err = global.K8sClientset.CoreV1().
+			ConfigMaps(apiv1.NamespaceDefault).
+			Delete(context.TODO(), obj.Name, metav1.DeleteOptions{})
+	case "Ingress":
+		err = global.K8sClientset.NetworkingV1().
+			Ingresses(apiv1.NamespaceDefault).
+			Delete(context.TODO(), obj.Name, metav1.DeleteOptions{})
+	}
+	return err
+}
+
+// DeleteAllObjectsInNetwork deletes everything labelled with the network,
+// including what the Delete method of each K8sModel may miss.
+func DeleteAllObjectsInNetwork(netID int) error {
+	objs, err := ListObjects("app=mictract,net=" + strconv.Itoa(netID))
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := DeleteObject(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
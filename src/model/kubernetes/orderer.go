@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"mictract/config"
+	"mictract/enum"
 	"mictract/global"
 	"path/filepath"
 	"strconv"
@@ -18,12 +19,22 @@ type Orderer struct {
 	callback
 	OrdererID 	int
 	NetworkID 	int
+
+	// ChannelParticipation starts the orderer without a system channel (Fabric 2.3+),
+	// channels are joined through the admin endpoint on port 7053.
+	ChannelParticipation bool
+	// Consensus of the network, BFT orderers need Fabric 3.0 and imply ChannelParticipation
+	Consensus string
 }
 
 func NewOrderer(netID int, ordererID int) *Orderer {
 	return &Orderer{NetworkID: netID, OrdererID: ordererID}
 }
 
+func (o *Orderer) isBFT() bool {
+	return o.Consensus == enum.ConsensusBFT
+}
+
 // Get orderer name.
 // Example: orderer1-net1
 func (o *Orderer) GetName() string {
@@ -71,6 +82,7 @@ func (o *Orderer) CreateConfigMap() {
 	configMap := &apiv1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name + "-env",
+			Labels: o.GetSelector(),
 		},
 		Data:	map[string]string{
 			"FABRIC_LOGGING_SPEC":"INFO",
@@ -92,6 +104,28 @@ func (o *Orderer) CreateConfigMap() {
 		},
 	}
 
+	if o.ChannelParticipation {
+		delete(configMap.Data, "ORDERER_GENERAL_GENESISMETHOD")
+		delete(configMap.Data, "ORDERER_GENERAL_GENESISFILE")
+		configMap.Data["ORDERER_GENERAL_BOOTSTRAPMETHOD"] 			= "none"
+		configMap.Data["ORDERER_CHANNELPARTICIPATION_ENABLED"] 		= "true"
+		configMap.Data["ORDERER_ADMIN_LISTENADDRESS"] 				= "0.0.0.0:7053"
+		configMap.Data["ORDERER_ADMIN_TLS_ENABLED"] 				= "true"
+		configMap.Data["ORDERER_ADMIN_TLS_PRIVATEKEY"] 				= "/var/hyperledger/orderer/tls/server.key"
+		configMap.Data["ORDERER_ADMIN_TLS_CERTIFICATE"] 			= "/var/hyperledger/orderer/tls/server.crt"
+		configMap.Data["ORDERER_ADMIN_TLS_ROOTCAS"] 				= "[/var/hyperledger/orderer/tls/ca.crt]"
+		configMap.Data["ORDERER_ADMIN_TLS_CLIENTAUTHREQUIRED"] 		= "true"
+		configMap.Data["ORDERER_ADMIN_TLS_CLIENTROOTCAS"] 			= "[/var/hyperledger/orderer/tls/ca.crt]"
+	}
+
+	if o.isBFT() {
+		// channel participation is always enabled and kafka is gone since Fabric 3.0
+		delete(configMap.Data, "ORDERER_CHANNELPARTICIPATION_ENABLED")
+		delete(configMap.Data, "ORDERER_KAFKA_TOPIC_REPLICATIONFACTOR")
+		delete(configMap.Data, "ORDERER_KAFKA_VERBOSE")
+		configMap.Data["ORDERER_CONSENSUS_WALDIR"] 	= "/var/hyperledger/production/orderer/smartbft/wal"
+	}
+
 	_, err := global.K8sClientset.CoreV1().
 		ConfigMaps(apiv1.NamespaceDefault).
 		Create(context.TODO(), configMap, metav1.CreateOptions{})
@@ -117,6 +151,7 @@ func (o *Orderer) CreateDeployment() {
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: o.GetSelector(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
@@ -216,6 +251,22 @@ func (o *Orderer) CreateDeployment() {
 		},
 	}
 
+	if o.ChannelParticipation {
+		// the first volume is the genesis block of system-channel
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Image = "hyperledger/fabric-orderer:2.3.0"
+		container.VolumeMounts = container.VolumeMounts[1:]
+		container.Ports = append(container.Ports, apiv1.ContainerPort{
+			Name:          "admin",
+			Protocol:      apiv1.ProtocolTCP,
+			ContainerPort: 7053,
+		})
+		deployment.Spec.Template.Spec.Volumes = deployment.Spec.Template.Spec.Volumes[1:]
+	}
+	if o.isBFT() {
+		deployment.Spec.Template.Spec.Containers[0].Image = "hyperledger/fabric-orderer:3.0.0"
+	}
+
 	_, err := global.K8sClientset.AppsV1().
 		Deployments(apiv1.NamespaceDefault).
 		Create(context.TODO(), deployment, metav1.CreateOptions{})
@@ -234,6 +285,7 @@ func (o *Orderer) CreateService() {
 	service := &apiv1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: o.GetSelector(),
 		},
 		Spec: apiv1.ServiceSpec{
 			Type: apiv1.ServiceTypeClusterIP,
@@ -256,6 +308,17 @@ func (o *Orderer) CreateService() {
 		},
 	}
 
+	if o.ChannelParticipation {
+		service.Spec.Ports = append(service.Spec.Ports, apiv1.ServicePort{
+			Name: "admin",
+			Port: 7053,
+			TargetPort: intstr.IntOrString{
+				Type:   intstr.String,
+				StrVal: "admin",
+			},
+		})
+	}
+
 	_, err := global.K8sClientset.CoreV1().
 		Services(apiv1.NamespaceDefault).
 		Create(context.TODO(), service, metav1.CreateOptions{})
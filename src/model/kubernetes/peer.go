@@ -9,9 +9,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"mictract/config"
+	"mictract/enum"
 	"mictract/global"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Peer struct {
@@ -19,6 +22,9 @@ type Peer struct {
 	PeerID			int
 	OrganizationID	int
 	NetworkID 		int
+
+	// Consensus of the network, peers of BFT networks need Fabric 3.0 to validate BFT blocks
+	Consensus		string
 }
 
 func NewPeer(netID int, orgID int, peerID int) *Peer {
@@ -75,6 +81,7 @@ func (p *Peer) CreateConfigMap() {
 	configMap := &apiv1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name + "-env",
+			Labels: p.GetSelector(),
 		},
 		Data:	map[string]string{
 			// These two env args are to indicate the communication address between the peer and the docker, when deploy a chaincode
@@ -132,6 +139,7 @@ func (p *Peer) CreateDeployment() {
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: p.GetSelector(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
@@ -267,6 +275,10 @@ func (p *Peer) CreateDeployment() {
 		},
 	}
 
+	if p.Consensus == enum.ConsensusBFT {
+		deployment.Spec.Template.Spec.Containers[0].Image = "hyperledger/fabric-peer:3.0.0"
+	}
+
 	_, err := global.K8sClientset.AppsV1().
 		Deployments(apiv1.NamespaceDefault).
 		Create(context.TODO(), deployment, metav1.CreateOptions{})
@@ -286,6 +298,7 @@ func (p *Peer) CreateService() {
 	service := &apiv1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
+			Labels: p.GetSelector(),
 		},
 		Spec: apiv1.ServiceSpec{
 			Type: apiv1.ServiceTypeClusterIP,
@@ -367,6 +380,22 @@ func (p *Peer) Delete() {
 	}
 }
 
+// AwaitableDelete stops the peer, its data on the NFS is kept.
+func (p *Peer) AwaitableDelete() error {
+	return awaitableDelete(p, 2*time.Minute)
+}
+
+// AdminCommand is a `peer node` admin command against the data of the peer, eg: AdminCommand("unjoin", "-c", "channel1"),
+// which must be run in the tools pod while the peer is stopped, the peer holds the ledger lock while running.
+func (p *Peer) AdminCommand(args ...string) []string {
+	root := filepath.Join("/mictract", p.GetSubPath())
+	return []string{"sh", "-c", fmt.Sprintf(
+		"FABRIC_CFG_PATH=/mictract/scripts/external "+
+			"CORE_PEER_MSPCONFIGPATH=%s CORE_PEER_LOCALMSPID=org%dMSP CORE_PEER_FILESYSTEMPATH=%s "+
+			"peer node %s",
+		filepath.Join(root, "msp"), p.OrganizationID, filepath.Join(root, "prod"), strings.Join(args, " "))}
+}
+
 func (p *Peer) Watch() {
 	watch(p, &p.callback)
 }
@@ -7,6 +7,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"mictract/config"
+	"mictract/enum"
 	"mictract/global"
 )
 
@@ -64,6 +65,42 @@ func (t *Tools) CreateDeployment() {
 								},
 							},
 						},
+						{
+							// configtxgen of 2.x does not know BFT
+							Name:  "tools-v3",
+							Image: "hyperledger/fabric-tools:3.0.0",
+							Command: []string{ "sleep", "infinity" },
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:             "networks",
+									MountPath:        "/mictract",
+								},
+							},
+						},
+						{
+							// builds node chaincode, see scripts/external/build-node.sh
+							Name:  "builder-node",
+							Image: "node:12-alpine",
+							Command: []string{ "sleep", "infinity" },
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:             "networks",
+									MountPath:        "/mictract",
+								},
+							},
+						},
+						{
+							// builds java chaincode, see scripts/external/build-java.sh
+							Name:  "builder-java",
+							Image: "maven:3.6-openjdk-11",
+							Command: []string{ "sleep", "infinity" },
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:             "networks",
+									MountPath:        "/mictract",
+								},
+							},
+						},
 					},
 					Volumes: []corev1.Volume{
 						{
@@ -118,5 +155,23 @@ func (t *Tools) Watch() {
 }
 
 func (t *Tools) ExecCommand(cmd ...string) (string, string, error) {
-	return execCommand(t, cmd...)
+	return execCommandInContainer(t, "tools", cmd...)
+}
+
+// ExecCommandV3 runs the command with fabric-tools 3.0, which is needed by BFT networks.
+func (t *Tools) ExecCommandV3(cmd ...string) (string, string, error) {
+	return execCommandInContainer(t, "tools-v3", cmd...)
+}
+
+// ExecBuildCommand runs the command in the container with the toolchain of the chaincode language,
+// golang is built by tools, which has go.
+func (t *Tools) ExecBuildCommand(language string, cmd ...string) (string, string, error) {
+	switch language {
+	case enum.ChaincodeLanguageNode:
+		return execCommandInContainer(t, "builder-node", cmd...)
+	case enum.ChaincodeLanguageJava:
+		return execCommandInContainer(t, "builder-java", cmd...)
+	default:
+		return t.ExecCommand(cmd...)
+	}
 }
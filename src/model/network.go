@@ -2,9 +2,8 @@ package model
 
 import (
 	"fmt"
-	"go.uber.org/zap"
 	mConfig "mictract/config"
-	"mictract/global"
+	"mictract/enum"
 	"os"
 	"path"
 	"path/filepath"
@@ -20,6 +19,12 @@ type Network struct {
 
 	Consensus  	string 		`json:"consensus" binding:"required"`
 	TlsEnabled 	bool   		`json:"tlsEnabled"`
+	// enum.ChannelModeSystemChannel(default) or enum.ChannelModeParticipation
+	ChannelMode string 		`json:"channelMode"`
+
+	// External networks are imported from a connection profile,
+	// their nodes are not run by mictract, so nothing is created or deleted in k8s for them.
+	External 	bool 		`json:"external"`
 }
 
 func GetNetworkNameByID(netID int) string {
@@ -29,18 +34,24 @@ func (n *Network) GetName() string {
 	return GetNetworkNameByID(n.ID)
 }
 
-func (n *Network) RemoveAllFile() {
-	if err := os.RemoveAll(filepath.Join(mConfig.LOCAL_BASE_PATH, GetNetworkNameByID(n.ID))); err != nil {
-		global.Logger.Error("fail to remove all file", zap.Error(err))
-	}
+// UsesChannelParticipation is true if the network has no system channel.
+// Networks created before channel modes existed have an empty ChannelMode, which means system-channel.
+func (n *Network) UsesChannelParticipation() bool {
+	return n.ChannelMode == enum.ChannelModeParticipation
+}
+
+// RemoveAllFile removes /mictract/networks/netN, including crypto materials,
+// sdk configs, the genesis block and workspaces
+func (n *Network) RemoveAllFile() error {
+	return os.RemoveAll(filepath.Join(mConfig.LOCAL_BASE_PATH, GetNetworkNameByID(n.ID)))
 }
 
 // only for OrdererOrg
-func (orderer *CaUser) RenderConfigtx() error {
+// configtx.yaml is written into dir
+func (orderer *CaUser) RenderConfigtx(dir string) error {
 	templ := template.Must(template.ParseFiles(path.Join(mConfig.LOCAL_MOUNT_PATH, "configtx.yaml.tpl")))
 
-	filename := fmt.Sprintf("/mictract/networks/net%d/configtx.yaml", orderer.NetworkID)
-	writer, err := os.Create(filename)
+	writer, err := os.Create(filepath.Join(dir, "configtx.yaml"))
 	if err != nil {
 		return err
 	}
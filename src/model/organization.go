@@ -6,7 +6,6 @@ import (
 	"mictract/config"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -18,6 +17,13 @@ type Organization struct {
 
 	CreatedAt 			time.Time
 	IsOrdererOrg	 	bool
+
+	// only for organizations of an external network,
+	// they override the names mictract generates for its own organizations
+	External 			bool 	`json:"external"`
+	ExternalMSPID 		string 	`json:"external_msp_id"`
+	ExternalCAName 		string 	`json:"external_ca_name"`
+	ExternalCAURL 		string 	`json:"external_ca_url"`
 }
 
 func GetOrganizationNameByIDAndBool(orgID int, isOrdOrg bool) string {
@@ -44,6 +50,9 @@ func (org *Organization) GetName() string {
 }
 
 func (org *Organization) GetMSPID() string {
+	if org.ExternalMSPID != "" {
+		return org.ExternalMSPID
+	}
 	if org.IsOrdererOrganization() {
 		return fmt.Sprintf("ordererMSP")
 	} else {
@@ -60,6 +69,9 @@ func (org *Organization) GetCAID() string {
 }
 
 func (org *Organization) GetCAURLInK8S() string {
+	if org.External {
+		return org.ExternalCAURL
+	}
 	if org.IsOrdererOrganization() {
 		return fmt.Sprintf("https://ca-net%d:7054", org.NetworkID)
 	} else {
@@ -67,6 +79,12 @@ func (org *Organization) GetCAURLInK8S() string {
 	}
 }
 
+// HasCA is false for an external organization whose CA is not in its connection profile,
+// users can not be registered or enrolled in such an organization.
+func (org *Organization) HasCA() bool {
+	return !org.External || org.ExternalCAURL != ""
+}
+
 func (org *Organization) GetMSPPath() string {
 	ret := filepath.Join(config.LOCAL_BASE_PATH, fmt.Sprintf("net%d", org.NetworkID))
 	if org.IsOrdererOrganization() {
@@ -77,34 +95,6 @@ func (org *Organization) GetMSPPath() string {
 	return filepath.Join(ret, "msp")
 }
 
-func (org *Organization) GetConfigtxFile() string {
-	var configtxTemplate = `
-Organizations:
-    - &<OrgName>
-        Name: <MSPID>
-        ID: <MSPID>
-        MSPDir: <MSPPath>
-        Policies:
-            Readers:
-                Type: Signature
-                Rule: "OR('<MSPID>.admin', '<MSPID>.peer', '<MSPID>.client')"
-            Writers:
-                Type: Signature
-                Rule: "OR('<MSPID>.admin', '<MSPID>.client')"
-            Admins:
-                Type: Signature
-                Rule: "OR('<MSPID>.admin')"
-            Endorsement:
-                Type: Signature
-                Rule: "OR('<MSPID>.peer')"
-`
-	configtxTemplate = strings.ReplaceAll(configtxTemplate, "<OrgName>", org.GetName())
-	configtxTemplate = strings.ReplaceAll(configtxTemplate, "<MSPID>",   org.GetMSPID())
-	configtxTemplate = strings.ReplaceAll(configtxTemplate, "<MSPPath>", org.GetMSPPath())
-	return configtxTemplate
-}
-
-
 func (org *Organization) GetMSPDir() string {
 	netName := fmt.Sprintf("net%d", org.NetworkID)
 
@@ -0,0 +1,22 @@
+package model
+
+// Plan is what applying a network spec will do, actions are executed in order.
+// It is not stored in db.
+type Plan struct {
+	NetworkID 	int 			`json:"networkID"`
+	Actions 	[]PlanAction 	`json:"actions"`
+	// differences between the spec and the network which apply won't converge, e.g. removing a peer
+	Warnings 	[]string 		`json:"warnings"`
+}
+
+// PlanAction refers to organizations, channels and chaincodes by nickname,
+// since they may not exist until the previous actions are executed.
+type PlanAction struct {
+	Type 			string 		`json:"type"`
+	Description 	string 		`json:"description"`
+
+	Organization 	string 		`json:"organization,omitempty"`
+	Organizations 	[]string 	`json:"organizations,omitempty"`
+	Channel 		string 		`json:"channel,omitempty"`
+	Chaincode 		string 		`json:"chaincode,omitempty"`
+}
@@ -7,11 +7,14 @@ package request
 //}
 type AddNetworkReq struct {
 	Nickname    string `form:"nickname" json:"nickname" binding:"required"`
+	// solo, etcdraft or BFT(at least 4 orderers)
 	Consensus	string `form:"consensus" json:"consensus" binding:"required"`
 	OrdererCount int 	`form:"ordererCount" form:"ordererCount" binding:"required"`
 	PeerCounts	[]int	`form:"peerCounts" json:"peerCounts" binding:"required"`
 	OrgNicknames []string `form:"organizationNicknames" json:"organizationNicknames" binding:"required"`
 	TlsEnabled	bool	`form:"tlsEnalbed"`
+	// system-channel(default) or participation(no system channel, Fabric 2.3+), BFT implies participation
+	ChannelMode string 	`form:"channelMode" json:"channelMode"`
 }
 
 type AddOrgReq struct {
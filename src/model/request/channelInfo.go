@@ -4,3 +4,38 @@ package request
 //	NetID	int	`form:"netid" binding:"required"`
 //	ChannelID	int	`form:"channelid" binding:"required"`
 //}
+
+// UpdatePolicyReq sets the policy named Name of the config group at Path,
+// eg: Path ["Application", "org1MSP"], Name "Writers", Type "Signature", Rule "OR('org1MSP.admin')".
+// An empty Path means the channel group.
+type UpdatePolicyReq struct {
+	Path 	[]string 	`form:"path" json:"path"`
+	Name 	string 		`form:"name" json:"name" binding:"required"`
+	// Signature or ImplicitMeta
+	Type 	string 		`form:"type" json:"type" binding:"required"`
+	Rule 	string 		`form:"rule" json:"rule" binding:"required"`
+	// Pending saves the update to collect signatures of the organizations instead of submitting it
+	Pending bool 		`form:"pending" json:"pending"`
+}
+
+// UpdateChannelParametersReq changes capability levels, eg: ["V2_0"], and batch parameters of a channel.
+// Omitted capabilities and zero batch fields are left unchanged.
+type UpdateChannelParametersReq struct {
+	ChannelCapabilities 	[]string 	`form:"channelCapabilities" json:"channelCapabilities"`
+	OrdererCapabilities 	[]string 	`form:"ordererCapabilities" json:"ordererCapabilities"`
+	ApplicationCapabilities []string 	`form:"applicationCapabilities" json:"applicationCapabilities"`
+	// eg: "2s"
+	BatchTimeout 			string 		`form:"batchTimeout" json:"batchTimeout"`
+	MaxMessageCount 		uint32 		`form:"maxMessageCount" json:"maxMessageCount"`
+	AbsoluteMaxBytes 		uint32 		`form:"absoluteMaxBytes" json:"absoluteMaxBytes"`
+	PreferredMaxBytes 		uint32 		`form:"preferredMaxBytes" json:"preferredMaxBytes"`
+	// Pending saves the update to collect signatures of the organizations instead of submitting it
+	Pending 				bool 		`form:"pending" json:"pending"`
+}
+
+// SetAnchorsReq chooses the anchor peers of an organization in a channel,
+// an empty PeerIDs means all peers of the organization.
+type SetAnchorsReq struct {
+	OrganizationID 	int 	`form:"organizationID" json:"organizationID" binding:"required"`
+	PeerIDs 		[]int 	`form:"peerIDs" json:"peerIDs"`
+}
@@ -0,0 +1,22 @@
+package request
+
+// ImportNetworkReq registers a network which is not deployed by mictract.
+// Every organization of the profile that has peers needs an admin.
+type ImportNetworkReq struct {
+	Nickname 		string 				`form:"nickname" json:"nickname" binding:"required"`
+	Consensus 		string 				`form:"consensus" json:"consensus"`
+	// connection profile of the network, yaml or json. tlsCACerts must be given as pem.
+	Profile 		string 				`form:"profile" json:"profile" binding:"required"`
+	// mspid of the orderers, it is not in a connection profile
+	OrdererMSPID 	string 				`form:"ordererMSPID" json:"ordererMSPID"`
+	Admins 			[]ImportAdminReq 	`form:"admins" json:"admins" binding:"required"`
+}
+
+type ImportAdminReq struct {
+	// the key of the organization in the connection profile
+	Organization 	string 	`form:"organization" json:"organization" binding:"required"`
+	// defaults to the common name of the certificate
+	Name 			string 	`form:"name" json:"name"`
+	Certificate 	string 	`form:"certificate" json:"certificate" binding:"required"`
+	PrivateKey 		string 	`form:"privateKey" json:"privateKey" binding:"required"`
+}
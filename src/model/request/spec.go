@@ -0,0 +1,55 @@
+package request
+
+// NetworkSpec declares the desired topology of a network,
+// see POST /api/spec/plan and POST /api/spec/apply.
+// Organizations, channels and chaincodes are matched with existing ones by nickname.
+//
+// networkID: 0          # 0 creates a new network
+// nickname: net
+// consensus: etcdraft
+// orderers: 3
+// organizations:
+//   - nickname: org1
+//     peers: 2
+// channels:
+//   - nickname: channel1
+//     organizations: [org1]
+// chaincodes:
+//   - nickname: cc1
+//     channel: channel1
+//     path: /mictract/uploads/cc1.tar.gz
+type NetworkSpec struct {
+	NetworkID 		int 				`json:"networkID" yaml:"networkID"`
+	Nickname 		string 				`json:"nickname" yaml:"nickname"`
+	Consensus 		string 				`json:"consensus" yaml:"consensus"`
+	// system-channel(default) or participation, only used to create a network
+	ChannelMode 	string 				`json:"channelMode" yaml:"channelMode"`
+	Orderers 		int 				`json:"orderers" yaml:"orderers"`
+	Organizations 	[]OrganizationSpec 	`json:"organizations" yaml:"organizations"`
+	Channels 		[]ChannelSpec 		`json:"channels" yaml:"channels"`
+	Chaincodes 		[]ChaincodeSpec 	`json:"chaincodes" yaml:"chaincodes"`
+}
+
+type OrganizationSpec struct {
+	Nickname 	string 	`json:"nickname" yaml:"nickname"`
+	Peers 		int 	`json:"peers" yaml:"peers"`
+}
+
+type ChannelSpec struct {
+	Nickname 		string 		`json:"nickname" yaml:"nickname"`
+	// nicknames of the member organizations
+	Organizations 	[]string 	`json:"organizations" yaml:"organizations"`
+}
+
+type ChaincodeSpec struct {
+	Nickname 		string 	`json:"nickname" yaml:"nickname"`
+	// nickname of the channel
+	Channel 		string 	`json:"channel" yaml:"channel"`
+	// src.tar.gz of the chaincode on the server
+	Path 			string 	`json:"path" yaml:"path"`
+	Label 			string 	`json:"label" yaml:"label"`
+	Policy 			string 	`json:"policy" yaml:"policy"`
+	Version 		string 	`json:"version" yaml:"version"`
+	Sequence 		int64 	`json:"sequence" yaml:"sequence"`
+	InitRequired 	bool 	`json:"initRequired" yaml:"initRequired"`
+}
@@ -2,6 +2,7 @@ package response
 
 import (
 	"mictract/model"
+	"strconv"
 )
 
 type Chaincode struct {
@@ -12,6 +13,9 @@ type Chaincode struct {
 	ChannelID 		int 	`json:"channelID"`
 
 	Status		 	string 	`json:"status"`
+	// the stage running, or the one which failed
+	Stage			string	`json:"stage"`
+	OrgResults		[]model.ChaincodeOrgResult	`json:"orgResults"`
 
 	Label	 	 	string	`json:"label"`
 	Address 	 	string	`json:"address"`
@@ -21,12 +25,16 @@ type Chaincode struct {
 	InitRequired 	bool 	`json:"initRequired"`
 
 	PackageID	 	string	`json:"packageID"`
+	Language		string	`json:"language"`
+	Image			string	`json:"image"`
 }
 
 func NewChaincode(cc *model.Chaincode) Chaincode {
 	return Chaincode{
 		Nickname: cc.Nickname,
 		Status: cc.Status,
+		Stage: cc.Stage,
+		OrgResults: cc.OrgResults,
 		ChaincodeID: cc.ID,
 		NetworkID: cc.NetworkID,
 		ChannelID: cc.ChannelID,
@@ -38,6 +46,8 @@ func NewChaincode(cc *model.Chaincode) Chaincode {
 		Version: cc.Version,
 		Sequence: cc.Sequence,
 		InitRequired: cc.InitRequired,
+		Language: cc.Language,
+		Image: cc.Image,
 	}
 }
 
@@ -47,4 +57,50 @@ func NewChaincodes(ccs []model.Chaincode) []Chaincode {
 		_ccs = append(_ccs, NewChaincode(&cci))
 	}
 	return _ccs
-}
\ No newline at end of file
+}
+
+type ChaincodeVersion struct {
+	Label	 	 	string		`json:"label"`
+	PolicyStr    	string		`json:"policy"`
+	Version  	 	string 		`json:"version"`
+	Sequence 	 	int64  		`json:"sequence"`
+	InitRequired 	bool 		`json:"initRequired"`
+	PackageID	 	string		`json:"packageID"`
+	CreateTime		string		`json:"createTime"`
+}
+
+func NewChaincodeVersions(ccvs []model.ChaincodeVersion) []ChaincodeVersion {
+	_ccvs := []ChaincodeVersion{}
+	for _, ccv := range ccvs {
+		_ccvs = append(_ccvs, ChaincodeVersion{
+			Label: ccv.Label,
+			PolicyStr: ccv.PolicyStr,
+			Version: ccv.Version,
+			Sequence: ccv.Sequence,
+			InitRequired: ccv.InitRequired,
+			PackageID: ccv.PackageID,
+			CreateTime: strconv.FormatInt(ccv.CreatedAt.Unix(), 10),
+		})
+	}
+	return _ccvs
+}
+
+// ChaincodeApproval is whether an organization of the channel has approved a definition
+type ChaincodeApproval struct {
+	OrganizationID	int		`json:"organizationID"`
+	MSPID			string	`json:"mspID"`
+	Approved		bool	`json:"approved"`
+}
+
+// NewChaincodeApprovals maps approvals keyed by MSP ID to the organizations
+func NewChaincodeApprovals(orgs []model.Organization, approvals map[string]bool) []ChaincodeApproval {
+	_approvals := []ChaincodeApproval{}
+	for _, org := range orgs {
+		_approvals = append(_approvals, ChaincodeApproval{
+			OrganizationID: org.ID,
+			MSPID: org.GetMSPID(),
+			Approved: approvals[org.GetMSPID()],
+		})
+	}
+	return _approvals
+}
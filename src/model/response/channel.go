@@ -10,3 +10,49 @@ type Channel struct {
 	// only set when call api /api/channel/:id
 	Height 			uint64			`json:"height"`
 }
+
+type Policy struct {
+	Type 	string 	`json:"type"`
+	Rule 	string 	`json:"rule"`
+}
+
+// PolicyGroup is a node of the policy tree of a channel config
+type PolicyGroup struct {
+	Name 		string 				`json:"name"`
+	Path 		[]string 			`json:"path"`
+	ModPolicy 	string 				`json:"modPolicy"`
+	Policies 	map[string]Policy 	`json:"policies"`
+	Groups 		[]PolicyGroup 		`json:"groups"`
+}
+
+type ChannelPolicies struct {
+	ChannelID 	int 		`json:"channelID"`
+	ChannelName string 		`json:"channelName"`
+	Sequence 	uint64 		`json:"sequence"`
+	Root 		PolicyGroup `json:"root"`
+}
+
+type ChannelParameters struct {
+	ChannelID 				int 		`json:"channelID"`
+	ChannelName 			string 		`json:"channelName"`
+	Sequence 				uint64 		`json:"sequence"`
+	ChannelCapabilities 	[]string 	`json:"channelCapabilities"`
+	OrdererCapabilities 	[]string 	`json:"ordererCapabilities"`
+	ApplicationCapabilities []string 	`json:"applicationCapabilities"`
+	BatchTimeout 			string 		`json:"batchTimeout"`
+	MaxMessageCount 		uint32 		`json:"maxMessageCount"`
+	AbsoluteMaxBytes 		uint32 		`json:"absoluteMaxBytes"`
+	PreferredMaxBytes 		uint32 		`json:"preferredMaxBytes"`
+}
+
+type AnchorPeer struct {
+	Host 	string 	`json:"host"`
+	Port 	int 	`json:"port"`
+	// 0 if the address is not one of the peers in mictract
+	PeerID 	int 	`json:"peerID"`
+}
+
+type OrganizationAnchors struct {
+	OrganizationID 	int 			`json:"organizationID"`
+	Anchors 		[]AnchorPeer 	`json:"anchors"`
+}
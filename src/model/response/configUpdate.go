@@ -0,0 +1,12 @@
+package response
+
+import "mictract/model"
+
+type ConfigUpdate struct {
+	model.ConfigUpdate
+	SignedBy 			[]string 	`json:"signedBy"`
+
+	// only set when call api /api/config-update/:id
+	UnsatisfiedPolicies []string 	`json:"unsatisfiedPolicies"`
+	Stale 				bool 		`json:"stale"`
+}
@@ -0,0 +1,7 @@
+package response
+
+import "mictract/model"
+
+type Job struct {
+	model.Job
+}
@@ -6,6 +6,8 @@ type Network struct {
 	Consensus 		string 			`json:"consensus"`
 	TlsEnabled 		bool 			`json:"tlsEnabled"`
 	Status 			string 			`json:"status"`
+	External 		bool 			`json:"external"`
+	ChannelMode 	string 			`json:"channelMode"`
 	CreateTime 		string 			`json:"createTime"`
 	Orderers 		[]Orderer 		`json:"orderers"`
 	Organizations 	[]Organization 	`json:"organizations"`
@@ -20,4 +20,18 @@ func NewOrderers(os []model.CaUser) []Orderer {
 		orderers = append(orderers, *NewOrderer(&o))
 	}
 	return orderers
-}
\ No newline at end of file
+}
+
+// Consenter is a raft consenter found in a config block,
+// OrdererID is 0 if it is not an orderer mictract knows.
+type Consenter struct {
+	Host 		string 	`json:"host"`
+	Port 		int 	`json:"port"`
+	OrdererID 	int 	`json:"ordererID"`
+}
+
+type ChannelConsenters struct {
+	ChannelID 	int 			`json:"channelID"`
+	ChannelName string 			`json:"channelName"`
+	Consenters 	[]Consenter 	`json:"consenters"`
+}
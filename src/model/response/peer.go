@@ -21,3 +21,27 @@ func NewPeers(ps []model.CaUser) []Peer {
 	}
 	return peers
 }
+
+// ChannelPeer is a peer of an organization in the channel
+type ChannelPeer struct {
+	Peer
+	OrganizationID 	int 	`json:"organizationID"`
+	// false if the peer hasn't joined the channel or is not reachable
+	Joined 			bool 	`json:"joined"`
+	Height 			uint64 	`json:"height"`
+}
+
+// NewChannelPeers takes ledger heights by peer ID
+func NewChannelPeers(ps []model.CaUser, heights map[int]uint64) []ChannelPeer {
+	peers := []ChannelPeer{}
+	for _, p := range ps {
+		height, joined := heights[p.ID]
+		peers = append(peers, ChannelPeer{
+			Peer: 			*NewPeer(&p),
+			OrganizationID: p.OrganizationID,
+			Joined: 		joined,
+			Height: 		height,
+		})
+	}
+	return peers
+}
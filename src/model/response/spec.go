@@ -0,0 +1,8 @@
+package response
+
+import "mictract/model"
+
+type ApplySpec struct {
+	Plan 	*model.Plan 	`json:"plan"`
+	Job 	*Job 			`json:"job"`
+}
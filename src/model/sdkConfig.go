@@ -45,6 +45,8 @@ type SDKConfigNode struct {
 	TLSCACerts struct {
 		Pem string `yaml:"pem"`
 	} `yaml:"tlsCACerts"`
+
+	GRPCOptions map[string]interface{} `yaml:"grpcOptions,omitempty"`
 }
 
 type SDKConfigChannel struct {
@@ -58,6 +60,7 @@ type SDKConfigChannel struct {
 
 type SDKConfigCA struct {
 	URL        string `yaml:"url"`
+	CAName     string `yaml:"caName,omitempty"`
 
 	TLSCACerts struct {
 		Pem []string `yaml:"pem"`
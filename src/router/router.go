@@ -15,7 +15,10 @@ func GetRouter() (router *gin.Engine) {
 		NetworkRouter.POST("/", api.CreateNetwork)
 		NetworkRouter.GET("/", api.ListNetworks)
 		NetworkRouter.DELETE("/", api.DeleteNetwork)
+		NetworkRouter.POST("/import", api.ImportNetwork)
 		NetworkRouter.GET("/:id", api.GetNetworkByID)
+		NetworkRouter.GET("/:id/export", api.ExportNetwork)
+		NetworkRouter.GET("/:id/consenters", api.ListConsenters)
 	}
 
 	ChannelRouter := APIRoute.Group("channel")
@@ -23,6 +26,15 @@ func GetRouter() (router *gin.Engine) {
 		ChannelRouter.POST("/", api.AddChannel)
 		ChannelRouter.GET("/", api.ListChannels)
 		ChannelRouter.GET("/:id", api.GetChannelByID)
+		ChannelRouter.GET("/:id/policy", api.GetChannelPolicies)
+		ChannelRouter.PUT("/:id/policy", api.UpdateChannelPolicy)
+		ChannelRouter.GET("/:id/parameters", api.GetChannelParameters)
+		ChannelRouter.PUT("/:id/parameters", api.UpdateChannelParameters)
+		ChannelRouter.GET("/:id/anchors", api.GetChannelAnchors)
+		ChannelRouter.PUT("/:id/anchors", api.SetChannelAnchors)
+		ChannelRouter.GET("/:id/peer", api.ListPeersInChannel)
+		ChannelRouter.POST("/:id/peer", api.JoinPeerToChannel)
+		ChannelRouter.DELETE("/:id/organization", api.RemoveOrgFromChannel)
 	}
 
 	OrganizationRouter := APIRoute.Group("organization")
@@ -30,6 +42,8 @@ func GetRouter() (router *gin.Engine) {
 		OrganizationRouter.POST("/", api.AddOrg)
 		OrganizationRouter.GET("/", api.ListOrganizations)
 		OrganizationRouter.GET("/:id", api.GetOrganizationByID)
+		OrganizationRouter.DELETE("/:id/consortium", api.RemoveOrgFromConsortium)
+		OrganizationRouter.PUT("/:id/crl", api.DistributeOrgCRL)
 	}
 
 	UserRouter := APIRoute.Group("user")
@@ -45,17 +59,16 @@ func GetRouter() (router *gin.Engine) {
 		PeerRouter.POST("/", api.AddPeer)
 		PeerRouter.GET("/", api.ListPeersByOrganization)
 		PeerRouter.GET("/:id", api.GetPeerByID)
-
-		//PeerChannelRouter := PeerRouter.Group("channel")
-		//{
-		//	PeerChannelRouter.POST("/", api.JoinPeerToChannel)
-		//	PeerChannelRouter.GET("/", api.ListChannelsInPeer)
-		//}
+		PeerRouter.DELETE("/:id", api.RemovePeer)
+		PeerRouter.GET("/:id/channel", api.ListChannelsInPeer)
+		PeerRouter.DELETE("/:id/channel", api.UnjoinPeerFromChannel)
+		PeerRouter.POST("/:id/reset", api.ResetPeerLedger)
 	}
 
 	OrdererRouter := APIRoute.Group("orderer")
 	{
 		OrdererRouter.POST("/", api.AddOrderer)
+		OrdererRouter.DELETE("/:id", api.RemoveOrderer)
 		OrdererRouter.GET("/", api.ListOrderersByNetwork)
 		OrdererRouter.GET("/:id", api.GetOrdererByID)
 	}
@@ -72,8 +85,13 @@ func GetRouter() (router *gin.Engine) {
 
 		CCRouter.POST("/install", api.InstallChaincode)
 		CCRouter.POST("/approve", api.ApproveChaincode)
+		CCRouter.GET("/readiness", api.CheckChaincodeCommitReadiness)
+		CCRouter.GET("/approval", api.ListChaincodeApprovals)
 		CCRouter.POST("/commit", api.CommitChaincode)
 		CCRouter.POST("/start", api.StartChaincodeEntity)
+		CCRouter.POST("/retry", api.RetryChaincode)
+		CCRouter.POST("/upgrade", api.UpgradeChaincode)
+		CCRouter.GET("/version", api.ListChaincodeVersions)
 		// CCRouter.POST("/invoke", api.InvokeChaincode)
 
 		TxRouter := CCRouter.Group("transaction")
@@ -85,5 +103,35 @@ func GetRouter() (router *gin.Engine) {
 		}
 	}
 
+	SpecRouter := APIRoute.Group("spec")
+	{
+		SpecRouter.POST("/plan", api.PlanSpec)
+		SpecRouter.POST("/apply", api.ApplySpec)
+	}
+
+	ReconcileRouter := APIRoute.Group("reconcile")
+	{
+		ReconcileRouter.GET("/", api.ListOrphanObjects)
+		ReconcileRouter.DELETE("/", api.RemoveOrphanObjects)
+	}
+
+	JobRouter := APIRoute.Group("job")
+	{
+		JobRouter.GET("/", api.ListJobs)
+		JobRouter.GET("/:id", api.GetJobByID)
+		JobRouter.POST("/:id/cancel", api.CancelJob)
+	}
+
+	ConfigUpdateRouter := APIRoute.Group("config-update")
+	{
+		ConfigUpdateRouter.GET("/", api.ListConfigUpdates)
+		ConfigUpdateRouter.GET("/:id", api.GetConfigUpdateByID)
+		ConfigUpdateRouter.GET("/:id/envelope", api.DownloadConfigUpdate)
+		ConfigUpdateRouter.POST("/:id/signature", api.UploadConfigSignature)
+		ConfigUpdateRouter.POST("/:id/sign", api.SignConfigUpdate)
+		ConfigUpdateRouter.POST("/:id/submit", api.SubmitConfigUpdate)
+		ConfigUpdateRouter.DELETE("/:id", api.CancelConfigUpdate)
+	}
+
 	return
 }
@@ -8,10 +8,14 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"mictract/dao"
+	"mictract/enum"
 	"mictract/global"
 	"mictract/model"
+	"mictract/model/kubernetes"
 	"mictract/service/factory"
 	"mictract/service/factory/sdk"
+	"os"
+	"strings"
 )
 
 type CaUserService struct {
@@ -102,17 +106,35 @@ func (cuSvc *CaUserService) Enroll(mspClient *msp.Client, isTLS bool) error {
 	return nil
 }
 
-func (cuSvc *CaUserService) Revoke(mspClient *msp.Client) error {
+// Revoke revokes all certificates of the user, both enrollment and TLS ones,
+// and returns the CRL the CA generates afterwards.
+// reason is one of the ocsp reasons, eg: "keycompromise", "cessationofoperation".
+func (cuSvc *CaUserService) Revoke(mspClient *msp.Client, reason string) ([]byte, error) {
 	req := &msp.RevocationRequest{
-		Name: cuSvc.cu.GetName(),
-		Reason: "Marx bless, no bugs",
+		Name:   cuSvc.cu.GetName(),
+		Reason: reason,
+		GenCRL: true,
 	}
 
-	_, err := mspClient.Revoke(req)
+	resp, err := mspClient.Revoke(req)
 	if err != nil {
-		return errors.WithMessage(err, "fail to revoke " + cuSvc.cu.GetName())
+		return nil, errors.WithMessage(err, "fail to revoke "+cuSvc.cu.GetName())
 	}
-	return nil
+	global.Logger.Info(fmt.Sprintf("%d certificates of %s are revoked", len(resp.RevokedCerts), cuSvc.cu.GetName()))
+	return resp.CRL, nil
+}
+
+// removeCredentials deletes the certificates, the db row and the crypto directory of the user,
+// it undoes creating and enrolling a user
+func (cuSvc *CaUserService) removeCredentials() error {
+	delete(global.AdminSigns, cuSvc.cu.GetName())
+	if err := dao.DeleteCertsByUserID(cuSvc.cu.ID); err != nil {
+		return err
+	}
+	if err := dao.DeleteCaUserByID(cuSvc.cu.ID); err != nil {
+		return err
+	}
+	return os.RemoveAll(cuSvc.cu.GetBasePath())
 }
 
 func (cuSvc *CaUserService)JoinChannel(chID int, ordererURL string) error {
@@ -130,13 +152,80 @@ func (cuSvc *CaUserService)JoinChannel(chID int, ordererURL string) error {
 		return errors.WithMessage(err, "fail to get rc ")
 	}
 
+	chName := model.GetChannelNameByID(chID)
+	if ch, err := dao.FindChannelByID(chID); err == nil {
+		chName = ch.GetName()
+	}
+
 	return rc.JoinChannel(
-		model.GetChannelNameByID(chID),
+		chName,
 		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
 		resmgmt.WithOrdererEndpoint(ordererURL),
 		resmgmt.WithTargetEndpoints(cuSvc.cu.GetName()))
 }
 
+// UnjoinChannel removes the ledger of the channel from the peer.
+func (cuSvc *CaUserService) UnjoinChannel(chName string) error {
+	global.Logger.Info(fmt.Sprintf("[%s unjoin %s]", cuSvc.cu.GetName(), chName))
+	defer global.Logger.Info(fmt.Sprintf("[%s unjoin %s] done!", cuSvc.cu.GetName(), chName))
+	return cuSvc.runPeerAdminCommand("unjoin", "-c", chName)
+}
+
+// ResetLedger resets the ledgers of all channels of the peer to their genesis blocks,
+// the peer pulls the blocks again from the orderer and rebuilds its state once started.
+func (cuSvc *CaUserService) ResetLedger() error {
+	global.Logger.Info(fmt.Sprintf("[Reset ledger of %s]", cuSvc.cu.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Reset ledger of %s] done!", cuSvc.cu.GetName()))
+	return cuSvc.runPeerAdminCommand("reset")
+}
+
+// runPeerAdminCommand runs `peer node <args>` against the data of the peer.
+// These commands need the peer stopped, so the peer is restarted around it.
+func (cuSvc *CaUserService) runPeerAdminCommand(args ...string) error {
+	if cuSvc.cu.Type != "peer" {
+		return errors.New("only support peer")
+	}
+	org, err := dao.FindOrganizationByID(cuSvc.cu.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if org.External {
+		return errors.New(org.GetName() + " is an external organization, its peers are not managed by mictract")
+	}
+	net, err := dao.FindNetworkByID(cuSvc.cu.NetworkID)
+	if err != nil {
+		return err
+	}
+
+	peer := kubernetes.NewPeer(cuSvc.cu.NetworkID, cuSvc.cu.OrganizationID, cuSvc.cu.ID)
+	peer.Consensus = net.Consensus
+
+	// 1. stop
+	global.Logger.Info("1. Stop the peer")
+	if err := peer.AwaitableDelete(); err != nil {
+		return err
+	}
+
+	// 2. run, with the same fabric version as the peer
+	global.Logger.Info(fmt.Sprintf("2. peer node %s", strings.Join(args, " ")))
+	tools := kubernetes.Tools{}
+	exec := tools.ExecCommand
+	if net.Consensus == enum.ConsensusBFT {
+		exec = tools.ExecCommandV3
+	}
+	_, stderr, cmdErr := exec(peer.AdminCommand(args...)...)
+	if cmdErr != nil {
+		cmdErr = errors.WithMessage(cmdErr, stderr)
+	}
+
+	// 3. start the peer again whatever happened
+	global.Logger.Info("3. Start the peer")
+	if err := peer.AwaitableCreate(); err != nil {
+		return err
+	}
+	return cmdErr
+}
+
 func (cuSvc *CaUserService)GetJoinedChannel() ([]string, error) {
 	if cuSvc.cu.Type != "peer" {
 		return []string{}, errors.New("only support peer")
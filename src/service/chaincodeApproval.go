@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"mictract/service/factory/sdk"
+)
+
+// InstallForOrg installs the package on all peers of the organization,
+// the result is kept on the chaincode like the pipeline does.
+func (ccSvc *ChaincodeService) InstallForOrg(org *model.Organization) error {
+	if err := ccSvc.checkOrgInChannel(org); err != nil {
+		return err
+	}
+	return ccSvc.recordOrgResult(org, enum.StatusInstalling, ccSvc.installOnOrg(org))
+}
+
+// ApproveForOrg approves the definition of the chaincode on behalf of the organization only,
+// other organizations of the channel approve it by their own calls.
+func (ccSvc *ChaincodeService) ApproveForOrg(org *model.Organization) error {
+	if err := ccSvc.checkOrgInChannel(org); err != nil {
+		return err
+	}
+	return ccSvc.recordOrgResult(org, enum.StatusApproving, ccSvc.approveForOrg(org))
+}
+
+// CheckCommitReadinessForOrg asks the first peer of the organization which organizations
+// have approved the definition, the result is keyed by MSP ID.
+func (ccSvc *ChaincodeService) CheckCommitReadinessForOrg(org *model.Organization) (map[string]bool, error) {
+	if err := ccSvc.checkOrgInChannel(org); err != nil {
+		return nil, err
+	}
+	adminUser, err := dao.FindSystemUserInOrganization(org.ID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to get adminUser")
+	}
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to get rc")
+	}
+	peers, err := dao.FindAllPeersInOrganization(org.ID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to get peers")
+	}
+	if len(peers) == 0 {
+		return nil, errors.New(fmt.Sprintf("%s has no peers", org.GetName()))
+	}
+	approvals, err := ccSvc.CheckCCCommitReadiness(rc, peers[0].GetName())
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to check commit readiness")
+	}
+	return *approvals, nil
+}
+
+// GetApprovals returns the approval state of the definition with version and sequence,
+// keyed by MSP ID. Empty version and zero sequence mean the current definition,
+// policy and init-required of a committed sequence are taken from its history.
+func (ccSvc *ChaincodeService) GetApprovals(version string, sequence int64) (map[string]bool, error) {
+	def := *ccSvc.cc
+	if sequence != 0 && sequence != def.Sequence {
+		ccvs, err := dao.FindAllVersionsOfChaincode(def.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, ccv := range ccvs {
+			if ccv.Sequence == sequence {
+				def.Version, def.PolicyStr, def.InitRequired = ccv.Version, ccv.PolicyStr, ccv.InitRequired
+			}
+		}
+		def.Sequence = sequence
+	}
+	if version != "" {
+		def.Version = version
+	}
+
+	ch, err := dao.FindChannelByID(def.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ch.OrganizationIDs) == 0 {
+		return nil, errors.New(fmt.Sprintf("%s has no organizations", ch.GetName()))
+	}
+	org, err := dao.FindOrganizationByID(ch.OrganizationIDs[0])
+	if err != nil {
+		return nil, err
+	}
+	return NewChaincodeService(&def).CheckCommitReadinessForOrg(org)
+}
+
+// checkOrgInChannel fails if the organization is not a member of the chaincode's channel
+func (ccSvc *ChaincodeService) checkOrgInChannel(org *model.Organization) error {
+	ch, err := dao.FindChannelByID(ccSvc.cc.ChannelID)
+	if err != nil {
+		return err
+	}
+	for _, orgID := range ch.OrganizationIDs {
+		if orgID == org.ID {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("%s is not in %s", org.GetName(), ch.GetName()))
+}
+
+// recordOrgResult keeps the outcome of stage for the organization on the chaincode,
+// err is returned as it is.
+func (ccSvc *ChaincodeService) recordOrgResult(org *model.Organization, stage string, err error) error {
+	result := model.ChaincodeOrgResult{OrganizationID: org.ID, Stage: stage, Status: enum.StatusSuccess}
+	if err != nil {
+		global.Logger.Error(fmt.Sprintf("%s fails in %s: %s", org.GetName(), stage, err.Error()))
+		result.Status, result.Error = enum.StatusError, err.Error()
+	}
+	ccSvc.cc.SetOrgResult(result)
+	if dbErr := dao.UpdateChaincodeOrgResults(ccSvc.cc); dbErr != nil {
+		return dbErr
+	}
+	return err
+}
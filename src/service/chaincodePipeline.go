@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"mictract/service/factory"
+	"strings"
+)
+
+// chaincodeStages are the stages of ChaincodePipeline, in order
+var chaincodeStages = []string{
+	enum.StatusUnpacking,
+	enum.StatusBuilding,
+	enum.StatusInstalling,
+	enum.StatusApproving,
+	enum.StatusCommitting,
+	enum.StatusStarting,
+}
+
+// ChaincodePipeline deploys a chaincode whose source has been uploaded to its directory.
+// The running stage is persisted on the chaincode, so a failed pipeline can be retried from the stage which failed.
+// Installing and approving are done per organization, their results are kept on the chaincode
+// and a retry skips the organizations which have succeeded.
+// With ManualApproval the pipeline doesn't approve, it waits at approving, pending,
+// until every organization has approved by ChaincodeService.ApproveForOrg.
+type ChaincodePipeline struct {
+	cc    *model.Chaincode
+	ccSvc *ChaincodeService
+}
+
+func NewChaincodePipeline(cc *model.Chaincode) *ChaincodePipeline {
+	return &ChaincodePipeline{
+		cc:    cc,
+		ccSvc: NewChaincodeService(cc),
+	}
+}
+
+// Start runs all stages
+func (p *ChaincodePipeline) Start(jSvc *JobService) error {
+	return p.run(jSvc, enum.StatusUnpacking)
+}
+
+// errChaincodePending stops the pipeline without failing it, while approvals are missing
+var errChaincodePending = errors.New("waiting for approvals")
+
+// Claim moves a chaincode whose status is one of from to the stage it stopped at, and reports whether it did.
+// Of concurrent retries and approvals only the one which claims the chaincode resumes the pipeline.
+func (p *ChaincodePipeline) Claim(from ...string) (bool, error) {
+	stage := p.resumeStage()
+	ok, err := dao.SwapChaincodeStatusByID(p.cc.ID, from, stage)
+	if ok {
+		p.cc.Status = stage
+	}
+	return ok, err
+}
+
+// Retry runs the pipeline of a claimed chaincode again, from the stage it stopped at
+func (p *ChaincodePipeline) Retry(jSvc *JobService) error {
+	stage := p.resumeStage()
+	if p.cc.Status != stage {
+		return errors.New(fmt.Sprintf("%s is %s, it must be claimed before it is retried", p.cc.GetName(), p.cc.Status))
+	}
+	return p.run(jSvc, stage)
+}
+
+func (p *ChaincodePipeline) resumeStage() string {
+	if p.cc.Stage == "" {
+		// chaincodes created before stages were kept
+		return enum.StatusUnpacking
+	}
+	return p.cc.Stage
+}
+
+func (p *ChaincodePipeline) run(jSvc *JobService, from string) error {
+	start := -1
+	for i, stage := range chaincodeStages {
+		if stage == from {
+			start = i
+		}
+	}
+	if start < 0 {
+		return errors.New("unknown chaincode stage " + from)
+	}
+
+	ch, err := dao.FindChannelByID(p.cc.ChannelID)
+	if err != nil {
+		return err
+	}
+	stages := map[string]func(ch *model.Channel) error{
+		enum.StatusUnpacking:  p.unpack,
+		enum.StatusBuilding:   p.build,
+		enum.StatusInstalling: p.install,
+		enum.StatusApproving:  p.approve,
+		enum.StatusCommitting: p.commit,
+		enum.StatusStarting:   p.start,
+	}
+
+	for _, stage := range chaincodeStages[start:] {
+		if err := jSvc.Step(fmt.Sprintf("%s %s", stage, p.cc.GetName())); err != nil {
+			return err
+		}
+		if err := dao.UpdateChaincodeStageByID(p.cc.ID, stage); err != nil {
+			return err
+		}
+		p.cc.Stage, p.cc.Status = stage, stage
+
+		if err := stages[stage](ch); err == errChaincodePending {
+			p.cc.Status = enum.StatusPending
+			global.Logger.Info(fmt.Sprintf("%s is waiting for approvals", p.cc.GetName()))
+			return dao.UpdateChaincodeStatusByID(p.cc.ID, enum.StatusPending)
+		} else if err != nil {
+			p.cc.Status = enum.StatusError
+			dao.UpdateChaincodeStatusByID(p.cc.ID, enum.StatusError)
+			return errors.WithMessage(err, "fail at "+stage)
+		}
+	}
+
+	p.cc.Status = enum.StatusRunning
+	if err := dao.UpdateChaincodeStatusByID(p.cc.ID, enum.StatusRunning); err != nil {
+		return err
+	}
+	if _, err := factory.NewChaincodeFactory().NewChaincodeVersion(p.cc); err != nil {
+		return errors.WithMessage(err, "fail to record chaincode version")
+	}
+
+	global.Logger.Info(fmt.Sprintf("%s has been created successfully", p.cc.GetName()))
+	return nil
+}
+
+func (p *ChaincodePipeline) unpack(ch *model.Channel) error {
+	return p.ccSvc.Unpack()
+}
+
+func (p *ChaincodePipeline) build(ch *model.Channel) error {
+	return p.ccSvc.Build()
+}
+
+func (p *ChaincodePipeline) install(ch *model.Channel) error {
+	return p.forEachOrg(ch, enum.StatusInstalling, p.ccSvc.installOnOrg)
+}
+
+func (p *ChaincodePipeline) approve(ch *model.Channel) error {
+	if p.cc.ManualApproval {
+		return p.awaitApprovals(ch)
+	}
+	return p.forEachOrg(ch, enum.StatusApproving, func(org *model.Organization) error {
+		return retryChaincodeStage(3, func() error {
+			return p.ccSvc.approveForOrg(org)
+		})
+	})
+}
+
+// awaitApprovals records which organizations have approved the definition,
+// errChaincodePending is returned if any of them hasn't.
+func (p *ChaincodePipeline) awaitApprovals(ch *model.Channel) error {
+	orgs, err := dao.FindAllOrganizationsInChannel(ch)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get orgs")
+	}
+	approvals, err := p.ccSvc.GetApprovals("", 0)
+	if err != nil {
+		return err
+	}
+
+	pending := false
+	for _, org := range orgs {
+		result := model.ChaincodeOrgResult{OrganizationID: org.ID, Stage: enum.StatusApproving, Status: enum.StatusSuccess}
+		if !approvals[org.GetMSPID()] {
+			result.Status = enum.StatusPending
+			pending = true
+		}
+		p.cc.SetOrgResult(result)
+	}
+	if err := dao.UpdateChaincodeOrgResults(p.cc); err != nil {
+		return err
+	}
+	if pending {
+		return errChaincodePending
+	}
+	return nil
+}
+
+// commit, note: "implicit policy evaluation failed" if use rc(include org),
+// rc(include network) is needed to get enough endorsements
+func (p *ChaincodePipeline) commit(ch *model.Channel) error {
+	return retryChaincodeStage(3, func() error {
+		return p.ccSvc.commitForChannel(ch)
+	})
+}
+
+func (p *ChaincodePipeline) start(ch *model.Channel) error {
+	net, err := dao.FindNetworkByID(ch.NetworkID)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get net")
+	}
+	if net.External {
+		// peers of an external network can not reach a container in our cluster,
+		// the chaincode server has to be run by the network's operator
+		global.Logger.Info(fmt.Sprintf("%s is external, skip starting cc container", net.GetName()))
+		return nil
+	}
+	return p.ccSvc.CreateEntity()
+}
+
+// forEachOrg runs fn for every organization in the channel which has not succeeded in the stage,
+// all of them are tried and their results are saved, the stage fails if any of them fails.
+func (p *ChaincodePipeline) forEachOrg(ch *model.Channel, stage string, fn func(org *model.Organization) error) error {
+	orgs, err := dao.FindAllOrganizationsInChannel(ch)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get orgs")
+	}
+
+	failed := []string{}
+	for i := range orgs {
+		org := &orgs[i]
+		if p.cc.Succeeded(stage, org.ID) {
+			global.Logger.Info(fmt.Sprintf("%s has succeeded in %s, skip", org.GetName(), stage))
+			continue
+		}
+
+		if err := p.ccSvc.recordOrgResult(org, stage, fn(org)); err != nil {
+			failed = append(failed, org.GetName())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New(fmt.Sprintf("%s failed for %s", stage, strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+// retryChaincodeStage tries fn at most count+1 times, lifecycle transactions fail now and then
+// while peers are catching up with the channel
+func retryChaincodeStage(count int, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil || count <= 0 {
+			return err
+		}
+		global.Logger.Error("Retrying", zap.Error(err))
+		count--
+	}
+}
@@ -5,18 +5,25 @@ import (
 	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io/ioutil"
 	cb "github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/policydsl"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"mictract/config"
 	"mictract/dao"
 	"mictract/enum"
 	"mictract/global"
 	"mictract/model"
 	"mictract/model/kubernetes"
+	"mictract/service/factory"
+	"mictract/service/factory/sdk"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	lcpackager "github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/lifecycle"
 )
@@ -45,14 +52,7 @@ func (ccSvc *ChaincodeService)GetCCPkg() ([]byte, error) {
 }
 
 func (ccSvc *ChaincodeService)Unpack() error {
-	// tar zxvf src.tar.gz
-	tools := kubernetes.Tools{}
-	if _, _, err := tools.ExecCommand(
-		"tar",
-		"zxvf",
-		filepath.Join(ccSvc.cc.GetCCPath(), "src.tar.gz"),
-		"-C",
-		ccSvc.cc.GetCCPath()); err != nil {
+	if err := ccSvc.extract(); err != nil {
 		dao.UpdateChaincodeStatusByID(ccSvc.cc.ID, enum.StatusError)
 		return err
 	}
@@ -65,11 +65,25 @@ func (ccSvc *ChaincodeService)Unpack() error {
 	if err := dao.UpdateChaincodePackageIDByID(ccSvc.cc.ID, packageID); err != nil {
 		return err
 	}
+	ccSvc.cc.PackageID = packageID
 	global.Logger.Info("├── test_local_packageID: " + packageID)
 
 	return nil
 }
 
+// extract unpacks src.tar.gz into the chaincode directory
+func (ccSvc *ChaincodeService) extract() error {
+	// tar zxvf src.tar.gz
+	tools := kubernetes.Tools{}
+	_, _, err := tools.ExecCommand(
+		"tar",
+		"zxvf",
+		filepath.Join(ccSvc.cc.GetCCPath(), "src.tar.gz"),
+		"-C",
+		ccSvc.cc.GetCCPath())
+	return err
+}
+
 func (ccSvc *ChaincodeService)PackageExternalCC(label, address string) (ccPkg []byte, err error) {
 	payload1 := bytes.NewBuffer(nil)
 	gw1 := gzip.NewWriter(payload1)
@@ -196,7 +210,7 @@ func (ccSvc *ChaincodeService)ApproveCC(orgResMgmt *resmgmt.Client, ordererURL s
 	}
 
 	txnID, err := orgResMgmt.LifecycleApproveCC(
-		model.GetChannelNameByID(ccSvc.cc.ChannelID),
+		ccSvc.getChannelName(),
 		approveCCReq,
 		resmgmt.WithOrdererEndpoint(ordererURL),
 		resmgmt.WithTargetEndpoints(peerURLs...),
@@ -258,7 +272,7 @@ func (ccSvc *ChaincodeService)CommitCC(orgResMgmt *resmgmt.Client, ordererUrl st
 		InitRequired:      ccSvc.cc.InitRequired,
 	}
 	txID, err := orgResMgmt.LifecycleCommitCC(
-		model.GetChannelNameByID(ccSvc.cc.ChannelID),
+		ccSvc.getChannelName(),
 		req,
 		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
 		resmgmt.WithOrdererEndpoint(ordererUrl),
@@ -278,7 +292,7 @@ func (ccSvc *ChaincodeService)QueryCommittedCC(orgResMgmt *resmgmt.Client) error
 	}
 
 	resps, err := orgResMgmt.LifecycleQueryCommittedCC(
-		model.GetChannelNameByID(ccSvc.cc.ChannelID),
+		ccSvc.getChannelName(),
 		req,
 		resmgmt.WithRetry(retry.DefaultResMgmtOpts))
 	if err != nil {
@@ -303,21 +317,113 @@ func packArgs(paras []string) [][]byte {
 }
 
 func (ccSvc *ChaincodeService) Build() error {
+	if err := ccSvc.build(filepath.Join(ccSvc.cc.GetCCPath(), "chaincode")); err != nil {
+		return err
+	}
+	return dao.UpdateChaincodeBuild(ccSvc.cc)
+}
+
+// build detects the language of src and builds it into output with the script of the language,
+// the chaincode pod runs <ccpath>/chaincode, see kubernetes.Chaincode for how each language is run.
+// With config.CC_IMAGE_REGISTRY, output is built into an image further, which the pod runs instead.
+func (ccSvc *ChaincodeService) build(output string) error {
+	src := filepath.Join(ccSvc.cc.GetCCPath(), "src")
+	language, err := DetectChaincodeLanguage(src)
+	if err != nil {
+		return err
+	}
+	ccSvc.cc.Language = language
+	global.Logger.Info("├── language: " + language)
+
 	tools := kubernetes.Tools{}
-	if _, _, err := tools.ExecCommand(
-		filepath.Join(config.LOCAL_SCRIPTS_PATH, "external", "build.sh"),
-		filepath.Join(ccSvc.cc.GetCCPath(), "chaincode"),
-		filepath.Join(ccSvc.cc.GetCCPath(), "src")); err != nil {
+	if _, _, err := tools.ExecBuildCommand(
+		language,
+		filepath.Join(config.LOCAL_SCRIPTS_PATH, "external", chaincodeBuildScripts[language]),
+		output,
+		src); err != nil {
 		return err
 	}
 
+	ccSvc.cc.Image = ""
+	if config.CC_IMAGE_REGISTRY != "" {
+		return ccSvc.buildImage(output)
+	}
 	return nil
 }
 
+// buildImage builds the artefact at output into an image by kaniko, and pushes it to the registry.
+// The image is tagged by the sequence, so an upgrade gets a new one and the old one is kept.
+func (ccSvc *ChaincodeService) buildImage(output string) error {
+	ccc := kubernetes.NewChaincode(ccSvc.cc.NetworkID, ccSvc.cc.ChannelID, ccSvc.cc.PackageID, ccSvc.cc.ID,
+		ccSvc.cc.Language, "")
+
+	// context: Dockerfile and the artefact as `chaincode`
+	contextPath := filepath.Join(ccSvc.cc.GetCCPath(), "image")
+	if err := os.RemoveAll(contextPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(contextPath, os.ModePerm); err != nil {
+		return err
+	}
+	tools := kubernetes.Tools{}
+	if _, _, err := tools.ExecCommand("cp", "-r", output, filepath.Join(contextPath, "chaincode")); err != nil {
+		return errors.WithMessage(err, "fail to copy the artefact")
+	}
+	if err := ioutil.WriteFile(filepath.Join(contextPath, "Dockerfile"), []byte(ccc.Dockerfile()), 0644); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("mictract/%s:%d", ccc.GetName(), ccSvc.cc.Sequence)
+	pushRegistry := config.CC_IMAGE_PUSH_REGISTRY
+	if pushRegistry == "" {
+		pushRegistry = config.CC_IMAGE_REGISTRY
+	}
+	global.Logger.Info("├── image: " + name)
+	if err := kubernetes.NewChaincodeBuilder(ccSvc.cc.NetworkID, ccSvc.cc.ChannelID, ccSvc.cc.ID, pushRegistry + "/" + name).
+		Build(10 * time.Minute); err != nil {
+		return err
+	}
+
+	ccSvc.cc.Image = config.CC_IMAGE_REGISTRY + "/" + name
+	return nil
+}
+
+// chaincodeBuildScripts are in scripts/external, each of them takes OUTPUT and CCPATH,
+// and runs in the tools container with the toolchain of its language:
+// golang: go build, OUTPUT is the binary
+// node: npm install (and npm run build if there is a build script), OUTPUT is the package directory
+// java: maven package or gradle shadowJar, OUTPUT is the fat jar
+var chaincodeBuildScripts = map[string]string{
+	enum.ChaincodeLanguageGolang:	"build.sh",
+	enum.ChaincodeLanguageNode:		"build-node.sh",
+	enum.ChaincodeLanguageJava:		"build-java.sh",
+}
+
+// DetectChaincodeLanguage tells the language of the chaincode source in dir by its build file:
+// go.mod, package.json, pom.xml or build.gradle(.kts).
+func DetectChaincodeLanguage(dir string) (string, error) {
+	buildFiles := []struct {
+		name		string
+		language	string
+	}{
+		{"go.mod", enum.ChaincodeLanguageGolang},
+		{"package.json", enum.ChaincodeLanguageNode},
+		{"pom.xml", enum.ChaincodeLanguageJava},
+		{"build.gradle", enum.ChaincodeLanguageJava},
+		{"build.gradle.kts", enum.ChaincodeLanguageJava},
+	}
+	for _, f := range buildFiles {
+		if _, err := os.Stat(filepath.Join(dir, f.name)); err == nil {
+			return f.language, nil
+		}
+	}
+	return "", errors.New("unknown chaincode language, one of go.mod, package.json, pom.xml, build.gradle is needed in src")
+}
+
 func (ccSvc *ChaincodeService) CreateEntity() error {
 	global.Logger.Info("Starting external chaincode")
 	if err := kubernetes.
-		NewChaincode(ccSvc.cc.NetworkID, ccSvc.cc.ChannelID, ccSvc.cc.PackageID, ccSvc.cc.ID).
+		NewChaincode(ccSvc.cc.NetworkID, ccSvc.cc.ChannelID, ccSvc.cc.PackageID, ccSvc.cc.ID, ccSvc.cc.Language, ccSvc.cc.Image).
 		AwaitableCreate(); err != nil {
 		return err
 	}
@@ -327,5 +433,278 @@ func (ccSvc *ChaincodeService) CreateEntity() error {
 
 func (ccSvc *ChaincodeService) RemoveEntity()  {
 	global.Logger.Info("Removing external chaincode")
-	kubernetes.NewChaincode(ccSvc.cc.NetworkID, ccSvc.cc.ChannelID, ccSvc.cc.PackageID, ccSvc.cc.ID).Delete()
+	kubernetes.NewChaincode(ccSvc.cc.NetworkID, ccSvc.cc.ChannelID, ccSvc.cc.PackageID, ccSvc.cc.ID, ccSvc.cc.Language, ccSvc.cc.Image).Delete()
+}
+
+// getChannelName returns the name of the chaincode's channel,
+// channels imported from an external network keep their own names.
+func (ccSvc *ChaincodeService) getChannelName() string {
+	ch, err := dao.FindChannelByID(ccSvc.cc.ChannelID)
+	if err != nil {
+		return model.GetChannelNameByID(ccSvc.cc.ChannelID)
+	}
+	return ch.GetName()
+}
+
+// Upgrade commits a new definition of the chaincode built from srcTarGz, with the sequence increased by one.
+// Empty version and policyStr keep the current ones, an empty label becomes "<label>_<sequence>",
+// the label must change, otherwise the package ID stays the same.
+// The new binary is built next to the running one, which keeps serving until the new definition is committed,
+// then the chaincode pod is rolled to the new package ID.
+// The chaincode must have been claimed by moving it from running to upgrading.
+func (ccSvc *ChaincodeService) Upgrade(srcTarGz string, label, version, policyStr string) error {
+	global.Logger.Info(fmt.Sprintf("[Upgrade %s]", ccSvc.cc.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Upgrade %s] done!", ccSvc.cc.GetName()))
+
+	// 1. check
+	global.Logger.Info("1. check")
+	if ccSvc.cc.Status != enum.StatusUpgrading {
+		return errors.New(fmt.Sprintf("%s is %s, it must be claimed before it is upgraded", ccSvc.cc.GetName(), ccSvc.cc.Status))
+	}
+	srcPath := filepath.Join(ccSvc.cc.GetCCPath(), "src.tar.gz")
+	archivePath := ccSvc.cc.GetSrcArchivePath(ccSvc.cc.Sequence)
+	srcDir := filepath.Join(ccSvc.cc.GetCCPath(), "src")
+	nextBinary := filepath.Join(ccSvc.cc.GetCCPath(), "chaincode.next")
+	archived, replaced, committed := false, false, false
+	defer func() {
+		// until the new definition is committed, the old one is still serving
+		if !committed {
+			if archived {
+				_ = os.Rename(archivePath, srcPath)
+			}
+			// src/ is unpacked from the old source again
+			if replaced {
+				if err := os.RemoveAll(srcDir); err != nil {
+					global.Logger.Error("fail to remove the new source", zap.Error(err))
+				} else if err := ccSvc.extract(); err != nil {
+					global.Logger.Error("fail to unpack the old source", zap.Error(err))
+				}
+			}
+			_ = os.Remove(srcTarGz)
+			_ = os.RemoveAll(nextBinary)
+			dao.UpdateChaincodeStatusByID(ccSvc.cc.ID, enum.StatusRunning)
+		}
+	}()
+
+	next := *ccSvc.cc
+	next.Sequence++
+	if label == "" {
+		label = fmt.Sprintf("%s_%d", strings.TrimSuffix(ccSvc.cc.Label, fmt.Sprintf("_%d", ccSvc.cc.Sequence)), next.Sequence)
+	}
+	if label == ccSvc.cc.Label {
+		return errors.New("the label must change, otherwise the package ID stays the same")
+	}
+	next.Label = label
+	if version != "" {
+		next.Version = version
+	}
+	if policyStr != "" {
+		if _, err := ccSvc.GeneratePolicy(policyStr); err != nil {
+			return errors.WithMessage(err, "invalid policy")
+		}
+		next.PolicyStr = policyStr
+	}
+	ch, err := dao.FindChannelByID(ccSvc.cc.ChannelID)
+	if err != nil {
+		return err
+	}
+	net, err := dao.FindNetworkByID(ccSvc.cc.NetworkID)
+	if err != nil {
+		return err
+	}
+	// definitions from before version history was kept
+	ccvs, err := dao.FindAllVersionsOfChaincode(ccSvc.cc.ID)
+	if err != nil {
+		return err
+	}
+	if len(ccvs) == 0 {
+		if _, err := factory.NewChaincodeFactory().NewChaincodeVersion(ccSvc.cc); err != nil {
+			return err
+		}
+	}
+
+	// 2. unpack, the old source package is kept in history/
+	global.Logger.Info("2. unpack the new source")
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(srcPath, archivePath); err != nil {
+		return errors.WithMessage(err, "fail to archive the old source")
+	}
+	archived = true
+	if err := os.Rename(srcTarGz, srcPath); err != nil {
+		return errors.WithMessage(err, "fail to save the new source")
+	}
+	replaced = true
+	if err := os.RemoveAll(srcDir); err != nil {
+		return err
+	}
+	nextSvc := NewChaincodeService(&next)
+	if err := nextSvc.extract(); err != nil {
+		return errors.WithMessage(err, "fail to unpack cc")
+	}
+	ccPkg, err := nextSvc.GetCCPkg()
+	if err != nil {
+		return err
+	}
+	next.PackageID = nextSvc.GetPackageID(next.Label, ccPkg)
+	global.Logger.Info("├── packageID: " + next.PackageID)
+
+	// 3. build
+	global.Logger.Info("3. build")
+	if err := nextSvc.build(nextBinary); err != nil {
+		return errors.WithMessage(err, "fail to build cc")
+	}
+
+	// 4. install, approve and commit
+	global.Logger.Info("4. install, approve and commit the new definition")
+	if err := nextSvc.installOnChannel(ch); err != nil {
+		return err
+	}
+	if err := nextSvc.approveForChannel(ch); err != nil {
+		return err
+	}
+	if err := nextSvc.commitForChannel(ch); err != nil {
+		return err
+	}
+	committed = true
+	*ccSvc.cc = next
+	if err := dao.UpdateChaincodeDefinition(&next); err != nil {
+		return err
+	}
+	if _, err := factory.NewChaincodeFactory().NewChaincodeVersion(&next); err != nil {
+		return err
+	}
+
+	// 5. roll the chaincode pod
+	global.Logger.Info("5. roll the chaincode pod")
+	// the artefact of node is a directory, which can't be renamed over
+	if err := os.RemoveAll(filepath.Join(ccSvc.cc.GetCCPath(), "chaincode")); err != nil {
+		dao.UpdateChaincodeStatusByID(ccSvc.cc.ID, enum.StatusError)
+		return errors.WithMessage(err, "fail to replace the binary")
+	}
+	if err := os.Rename(nextBinary, filepath.Join(ccSvc.cc.GetCCPath(), "chaincode")); err != nil {
+		dao.UpdateChaincodeStatusByID(ccSvc.cc.ID, enum.StatusError)
+		return errors.WithMessage(err, "fail to replace the binary")
+	}
+	if net.External {
+		global.Logger.Info(fmt.Sprintf("%s is external, skip rolling cc container", net.GetName()))
+	} else if err := kubernetes.NewChaincode(next.NetworkID, next.ChannelID, next.PackageID, next.ID, next.Language, next.Image).Rollout(); err != nil {
+		dao.UpdateChaincodeStatusByID(ccSvc.cc.ID, enum.StatusError)
+		return errors.WithMessage(err, "fail to roll cc container")
+	}
+
+	return dao.UpdateChaincodeStatusByID(ccSvc.cc.ID, enum.StatusRunning)
+}
+
+// installOnChannel installs the package on all peers of the organizations in the channel
+func (ccSvc *ChaincodeService) installOnChannel(ch *model.Channel) error {
+	orgs, err := dao.FindAllOrganizationsInChannel(ch)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get orgs")
+	}
+	for i := range orgs {
+		if err := ccSvc.installOnOrg(&orgs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installOnOrg installs the package on all peers of the organization
+func (ccSvc *ChaincodeService) installOnOrg(org *model.Organization) error {
+	global.Logger.Info(fmt.Sprintf("install %s to %s", ccSvc.cc.Label, org.GetName()))
+	adminUser, err := dao.FindSystemUserInOrganization(org.ID)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get adminUser")
+	}
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get rc")
+	}
+	if err := ccSvc.InstallCC(rc); err != nil {
+		return errors.WithMessage(err, "fail to install cc to "+org.GetName())
+	}
+	return nil
+}
+
+// approveForChannel approves the definition on behalf of every organization in the channel
+func (ccSvc *ChaincodeService) approveForChannel(ch *model.Channel) error {
+	orgs, err := dao.FindAllOrganizationsInChannel(ch)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get orgs")
+	}
+	for i := range orgs {
+		if err := ccSvc.approveForOrg(&orgs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// approveForOrg approves the definition with the admin of the organization, on its first peer
+func (ccSvc *ChaincodeService) approveForOrg(org *model.Organization) error {
+	global.Logger.Info(fmt.Sprintf("%s approve cc", org.GetName()))
+	orderers, err := dao.FindAllOrderersInNetwork(org.NetworkID)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get orderers")
+	}
+	if len(orderers) == 0 {
+		return errors.New(fmt.Sprintf("%s has no orderers", model.GetNetworkNameByID(org.NetworkID)))
+	}
+	adminUser, err := dao.FindSystemUserInOrganization(org.ID)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get adminUser")
+	}
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get rc")
+	}
+	peers, err := dao.FindAllPeersInOrganization(org.ID)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get peers")
+	}
+	if len(peers) == 0 {
+		return errors.New(fmt.Sprintf("%s has no peers", org.GetName()))
+	}
+	if err := ccSvc.ApproveCC(rc, orderers[0].GetName(), peers[0].GetName()); err != nil {
+		return errors.WithMessage(err, "fail to approve cc for "+org.GetName())
+	}
+	return nil
+}
+
+// commitForChannel commits the definition, endorsed by the peers of all organizations in the channel
+func (ccSvc *ChaincodeService) commitForChannel(ch *model.Channel) error {
+	orderers, err := dao.FindAllOrderersInNetwork(ch.NetworkID)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get orderers")
+	}
+	if len(orderers) == 0 {
+		return errors.New(fmt.Sprintf("%s has no orderers", model.GetNetworkNameByID(ch.NetworkID)))
+	}
+	if len(ch.OrganizationIDs) == 0 {
+		return errors.New(fmt.Sprintf("%s has no organizations", ch.GetName()))
+	}
+	peers, err := dao.FindAllPeersInChannel(ch)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get peers")
+	}
+	peerURLs := []string{}
+	for _, peer := range peers {
+		peerURLs = append(peerURLs, peer.GetName())
+	}
+
+	// note: "implicit policy evaluation failed" if use rc(include org)
+	adminUser, err := dao.FindSystemUserInOrganization(ch.OrganizationIDs[0])
+	if err != nil {
+		return errors.WithMessage(err, "fail to get adminUser")
+	}
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClientIncludeNetwork(adminUser)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get rc")
+	}
+	if err := ccSvc.CommitCC(rc, orderers[0].GetName(), peerURLs...); err != nil {
+		return errors.WithMessage(err, "fail to commit cc")
+	}
+	return nil
 }
@@ -0,0 +1,36 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"mictract/enum"
+	"mictract/service"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectChaincodeLanguage(t *testing.T) {
+	for file, language := range map[string]string{
+		"go.mod":			enum.ChaincodeLanguageGolang,
+		"package.json":		enum.ChaincodeLanguageNode,
+		"pom.xml":			enum.ChaincodeLanguageJava,
+		"build.gradle":		enum.ChaincodeLanguageJava,
+		"build.gradle.kts":	enum.ChaincodeLanguageJava,
+	} {
+		dir, err := ioutil.TempDir("", "src")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, file), []byte{}, 0644))
+
+		detected, err := service.DetectChaincodeLanguage(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, language, detected)
+		os.RemoveAll(dir)
+	}
+
+	dir, err := ioutil.TempDir("", "src")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	_, err = service.DetectChaincodeLanguage(dir)
+	assert.Error(t, err)
+}
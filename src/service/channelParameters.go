@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"mictract/dao"
+	"mictract/global"
+	"mictract/model"
+	"mictract/model/kubernetes"
+	"mictract/service/configtx"
+	"strings"
+)
+
+// GetParameters reads the capabilities and batch parameters from the latest config block,
+// the sequence of the config they are read from is returned as well.
+func (cSvc *ChannelService) GetParameters() (*configtx.ChannelParameters, uint64, error) {
+	ctx, err := cSvc.GetConfigTx()
+	if err != nil {
+		return nil, 0, err
+	}
+	params, err := ctx.ChannelParameters()
+	if err != nil {
+		return nil, 0, err
+	}
+	return params, ctx.Sequence(), nil
+}
+
+// UpdateParameters changes the capabilities and batch parameters of the channel.
+// Capabilities must be supported by the images of all running nodes which check them:
+// channel capabilities by peers and orderers, orderer ones by orderers, application ones by peers.
+func (cSvc *ChannelService) UpdateParameters(params configtx.ChannelParameters) error {
+	global.Logger.Info(fmt.Sprintf("[Update parameters of %s]", cSvc.ch.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Update parameters of %s] done!", cSvc.ch.GetName()))
+	if cSvc.ch.ID == -1 {
+		return errors.New("parameters of system-channel can't be updated")
+	}
+
+	// 1. check
+	global.Logger.Info("1. Check node versions")
+	if err := cSvc.checkCapabilitiesSupported(params); err != nil {
+		return err
+	}
+
+	// 2. sign, capabilities of the channel group need the orderer admin as well
+	global.Logger.Info("2. Obtaining admin signatures")
+	signs, err := cSvc.GetAllAdminSigningIdentities()
+	if err != nil {
+		return err
+	}
+	ordSigns, err := cSvc.getOrdererAdminSigningIdentity()
+	if err != nil {
+		return err
+	}
+	signs = append(signs, ordSigns...)
+
+	// 3. update channel config
+	global.Logger.Info("3. Update channel config...")
+	return cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.SetChannelParameters(params)
+	}, signs)
+}
+
+// ProposeParametersUpdate is UpdateParameters for channels whose organizations sign on their own.
+func (cSvc *ChannelService) ProposeParametersUpdate(params configtx.ChannelParameters) (*model.ConfigUpdate, error) {
+	if err := cSvc.checkCapabilitiesSupported(params); err != nil {
+		return nil, err
+	}
+	return cSvc.ProposeConfigUpdate("update capabilities and batch parameters", func(ctx *configtx.ConfigTx) error {
+		return ctx.SetChannelParameters(params)
+	})
+}
+
+// checkCapabilitiesSupported compares the requested capabilities with the image versions of the running pods.
+// Nodes without a pod, eg: of external organizations, can't be checked and are skipped.
+func (cSvc *ChannelService) checkCapabilitiesSupported(params configtx.ChannelParameters) error {
+	peers, err := dao.FindAllPeersInChannel(cSvc.ch)
+	if err != nil {
+		return err
+	}
+	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
+	if err != nil {
+		return err
+	}
+
+	peerVersions := map[string]string{}
+	for _, peer := range peers {
+		pod, err := kubernetes.NewPeer(peer.NetworkID, peer.OrganizationID, peer.ID).GetPod()
+		if err != nil {
+			return err
+		}
+		if pod == nil {
+			global.Logger.Info(fmt.Sprintf("%s is not running in mictract, skip", peer.GetName()))
+			continue
+		}
+		peerVersions[peer.GetName()] = imageVersion(pod.Spec.Containers[0].Image)
+	}
+	ordererVersions := map[string]string{}
+	for _, orderer := range orderers {
+		pod, err := kubernetes.NewOrderer(orderer.NetworkID, orderer.ID).GetPod()
+		if err != nil {
+			return err
+		}
+		if pod == nil {
+			global.Logger.Info(fmt.Sprintf("%s is not running in mictract, skip", orderer.GetName()))
+			continue
+		}
+		ordererVersions[orderer.GetName()] = imageVersion(pod.Spec.Containers[0].Image)
+	}
+
+	check := func(group string, capabilities []string, nodeVersions ...map[string]string) error {
+		for _, capability := range capabilities {
+			minVersion, err := configtx.CapabilityMinVersion(group, capability)
+			if err != nil {
+				return err
+			}
+			unsupported := []string{}
+			for _, versions := range nodeVersions {
+				for name, version := range versions {
+					if version == "" || version == "latest" {
+						global.Logger.Info(fmt.Sprintf("the version of %s is unknown, skip", name))
+						continue
+					}
+					if configtx.CompareVersions(version, minVersion) < 0 {
+						unsupported = append(unsupported, fmt.Sprintf("%s(%s)", name, version))
+					}
+				}
+			}
+			if len(unsupported) > 0 {
+				return errors.New(fmt.Sprintf("capability %s needs Fabric %s, which is not supported by %s",
+					capability, minVersion, strings.Join(unsupported, ", ")))
+			}
+		}
+		return nil
+	}
+
+	if err := check("", params.ChannelCapabilities, peerVersions, ordererVersions); err != nil {
+		return err
+	}
+	if err := check(configtx.OrdererGroupKey, params.OrdererCapabilities, ordererVersions); err != nil {
+		return err
+	}
+	return check(configtx.ApplicationGroupKey, params.ApplicationCapabilities, peerVersions)
+}
+
+// imageVersion returns the tag of an image, eg: "2.2.1" of "hyperledger/fabric-peer:2.2.1"
+func imageVersion(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
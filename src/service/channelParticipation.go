@@ -0,0 +1,221 @@
+package service
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"mictract/config"
+	"mictract/dao"
+	"mictract/global"
+	"mictract/model"
+	"mictract/service/factory/sdk"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// ordererAdminPort is where the channel participation API of orderers listens,
+// see kubernetes.Orderer.ChannelParticipation
+const ordererAdminPort = 7053
+
+// newOrdererAdminClient returns a client of the orderers' admin endpoint,
+// which requires mutual tls with a certificate issued by the orderer organization's CA.
+// The tls certificate of ordererorg's system user is used.
+func newOrdererAdminClient(netID int) (*http.Client, error) {
+	ordOrg, err := dao.FindOrdererOrganizationInNetwork(netID)
+	if err != nil {
+		return nil, err
+	}
+	cacert, err := dao.FindTLSCACertByOrganizationID(ordOrg.ID)
+	if err != nil {
+		return nil, err
+	}
+	adminUser, err := dao.FindSystemUserInOrganization(ordOrg.ID)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := dao.FindCertByUserID(adminUser.ID, true)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to get tls cert of "+adminUser.GetName())
+	}
+
+	pair, err := tls.X509KeyPair([]byte(cert.Certification), []byte(cert.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(cacert.Certification)) {
+		return nil, errors.New("fail to parse ca cert of " + ordOrg.GetName())
+	}
+
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      pool,
+				Certificates: []tls.Certificate{pair},
+			},
+		},
+	}, nil
+}
+
+// JoinOrderer joins the orderer to the channel through the channel participation API.
+// block is the genesis block of the channel, or its latest config block for an orderer added later.
+// An orderer which has joined the channel already is not an error.
+func (cSvc *ChannelService) JoinOrderer(orderer *model.CaUser, block []byte) error {
+	global.Logger.Info(fmt.Sprintf("[[join %s to %s]]", orderer.GetName(), cSvc.ch.GetName()))
+
+	client, err := newOrdererAdminClient(cSvc.ch.NetworkID)
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("config-block", cSvc.ch.GetName()+".block")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(block); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://%s:%d/participation/v1/channels", orderer.GetURL(), ordererAdminPort)
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.WithMessage(err, "fail to call the admin endpoint of "+orderer.GetName())
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusConflict:
+		global.Logger.Info(fmt.Sprintf("%s has joined %s already", orderer.GetName(), cSvc.ch.GetName()))
+		return nil
+	default:
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("%s fail to join %s: %s %s",
+			orderer.GetName(), cSvc.ch.GetName(), resp.Status, string(msg))
+	}
+}
+
+// GetConfigBlockFromOrderer returns the latest config block of the channel,
+// which the orderer has applied already, unlike the one from peers.
+func (cSvc *ChannelService) GetConfigBlockFromOrderer() ([]byte, error) {
+	orderer, err := cSvc.getOrderer()
+	if err != nil {
+		return []byte{}, err
+	}
+	ordOrg, err := dao.FindOrdererOrganizationInNetwork(cSvc.ch.NetworkID)
+	if err != nil {
+		return []byte{}, err
+	}
+	ordAdmin, err := dao.FindSystemUserInOrganization(ordOrg.ID)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(ordAdmin)
+	if err != nil {
+		return []byte{}, errors.WithMessage(err, "fail to get rc")
+	}
+	cfg, err := rc.QueryConfigBlockFromOrderer(cSvc.ch.GetName(), resmgmt.WithOrdererEndpoint(orderer.GetName()))
+	if err != nil {
+		return []byte{}, errors.WithMessage(err, "fail to query config block of "+cSvc.ch.GetName())
+	}
+
+	return proto.Marshal(cfg)
+}
+
+// CreateChannelWithParticipation joins every orderer to the genesis block generated by configtxgen,
+// it replaces CreateChannel in networks without a system channel.
+func (cSvc *ChannelService) CreateChannelWithParticipation(orderers []model.CaUser, genesisBlockPath string) error {
+	global.Logger.Info("[channel is creating with channel participation]")
+	defer global.Logger.Info("[channel is creating with channel participation] done!")
+
+	block, err := ioutil.ReadFile(genesisBlockPath)
+	if err != nil {
+		return err
+	}
+
+	// 1. join orderers
+	global.Logger.Info("1. Joining orderers to the genesis block...")
+	for i := range orderers {
+		if err := cSvc.JoinOrderer(&orderers[i], block); err != nil {
+			return err
+		}
+	}
+
+	// 2. the genesis block must be available before peers join
+	global.Logger.Info("2. Waiting for the orderer to create the channel...")
+	return cSvc.WaitForConfigSequence(0, true)
+}
+
+// RenderParticipationConfigtx is RenderConfigtx for networks without a system channel:
+// the profile contains the Orderer section with all orderers of the network as consenters
+// (etcdraft consenters, or the consenter mapping of BFT), so configtxgen can output the genesis block of the channel directly.
+// configtx.yaml is written into dir, which should be a workspace of the channel
+func (cSvc *ChannelService) RenderParticipationConfigtx(dir string) error {
+	global.Logger.Info("[[render channel participation config tx]]")
+	templ := template.Must(template.ParseFiles(path.Join(config.LOCAL_MOUNT_PATH, "channel-participation.yaml.tpl")))
+
+	orgs, err := dao.FindAllOrganizationsInChannel(cSvc.ch)
+	if err != nil {
+		return err
+	}
+	ordOrg, err := dao.FindOrdererOrganizationInNetwork(cSvc.ch.NetworkID)
+	if err != nil {
+		return err
+	}
+	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
+	if err != nil {
+		return err
+	}
+	net, err := dao.FindNetworkByID(cSvc.ch.NetworkID)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		NetworkID  int
+		Consensus  string
+		Org        *model.Organization
+		OrdererOrg *model.Organization
+		Orderers   []*model.CaUser
+	}{
+		NetworkID:  cSvc.ch.NetworkID,
+		Consensus:  net.Consensus,
+		Org:        &orgs[0],
+		OrdererOrg: ordOrg,
+	}
+	for i := range orderers {
+		data.Orderers = append(data.Orderers, &orderers[i])
+	}
+
+	writer, err := os.Create(filepath.Join(dir, "configtx.yaml"))
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return templ.Execute(writer, data)
+}
@@ -1,7 +1,7 @@
 package service
 
 import (
-	"encoding/base64"
+	"bytes"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
@@ -16,12 +16,19 @@ import (
 	"mictract/dao"
 	"mictract/global"
 	"mictract/model"
-	"mictract/model/kubernetes"
+	"mictract/service/configtx"
 	"mictract/service/factory/sdk"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"text/template"
+	"time"
+)
+
+const (
+	configPollInterval = time.Second
+	configWaitTimeout  = 60 * time.Second
 )
 
 type ChannelService struct {
@@ -53,15 +60,11 @@ func (cSvc *ChannelService)GetAllAdminSigningIdentities() ([]msp.SigningIdentity
 	return signs, nil
 }
 
-func (cSvc *ChannelService)CreateChannel(ordererURL string) error {
+// CreateChannel submits the channel creation tx generated by configtxgen
+func (cSvc *ChannelService)CreateChannel(ordererURL string, channelConfigTxPath string) error {
 	global.Logger.Info("[channel is creating]")
 	defer global.Logger.Info("[channel is creating] done!")
 
-	channelConfigTxPath := filepath.Join(
-		config.LOCAL_BASE_PATH,
-		model.GetNetworkNameByID(cSvc.ch.NetworkID),
-		fmt.Sprintf("%s.tx", cSvc.ch.GetName()))
-
 	orgID := cSvc.ch.OrganizationIDs[0]
 	org, err := dao.FindOrganizationByID(orgID)
 	if err != nil {
@@ -100,15 +103,20 @@ func (cSvc *ChannelService)CreateChannel(ordererURL string) error {
 		req,
 		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
 		resmgmt.WithOrdererEndpoint(ordererURL))
+	if err != nil {
+		return err
+	}
 
-	return err
+	// 5. the genesis block must be available before peers join
+	global.Logger.Info("3. Waiting for the orderer to create the channel...")
+	return cSvc.WaitForConfigSequence(0, true)
 }
 
 
 func (cSvc *ChannelService)GetSysChannelConfig() ([]byte, error) {
 	global.Logger.Info("[[get system-channel config]]")
 
-	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
+	orderer, err := cSvc.getOrderer()
 	if err != nil {
 		return []byte{}, err
 	}
@@ -129,7 +137,7 @@ func (cSvc *ChannelService)GetSysChannelConfig() ([]byte, error) {
 
 	cfg, err := rc.QueryConfigBlockFromOrderer(
 		"system-channel",
-		resmgmt.WithOrdererEndpoint(orderers[0].GetName()))
+		resmgmt.WithOrdererEndpoint(orderer.GetName()))
 	if err != nil {
 		return []byte{}, errors.WithMessage(err, "fail to query system-channel config")
 	}
@@ -230,76 +238,215 @@ func (cSvc *ChannelService) GetBlock(blockID uint64) (*common.Block, error) {
 	return lc.QueryBlock(blockID, ledger.WithTargetEndpoints(peers[0].GetName()))
 }
 
-func (cSvc *ChannelService)GetAndStoreConfig() error {
-	global.Logger.Info("[[get and store config]]")
-
-	// 1. get
-	bt := []byte{}
+// GetConfigTx fetches the latest config block of the channel
+// and decodes it for editing.
+func (cSvc *ChannelService) GetConfigTx() (*configtx.ConfigTx, error) {
+	var bt []byte
 	var err error
 	if cSvc.ch.ID != -1 {
 		bt, err = cSvc.GetChannelConfig()
-		if err != nil {
-			return err
-		}
 	} else {
 		bt, err = cSvc.GetSysChannelConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return configtx.NewFromBlockBytes(bt)
+}
+
+// getSubmitter returns the user who submits and reads config of the channel.
+// The system-channel is updated by the orderer organization,
+// others by the first organization of the channel.
+func (cSvc *ChannelService) getSubmitter() (*model.CaUser, error) {
+	if cSvc.ch.ID == -1 {
+		ordOrg, err := dao.FindOrdererOrganizationInNetwork(cSvc.ch.NetworkID)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		return dao.FindSystemUserInOrganization(ordOrg.ID)
 	}
+	return dao.FindSystemUserInOrganization(cSvc.ch.OrganizationIDs[0])
+}
 
-	// 2. store
-	f, err := os.Create(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "config_block.pb"))
+// getOrderer returns the orderer which config updates are submitted to and read from:
+// the first one of ch.OrdererIDs that still exists, otherwise the first one of the network.
+func (cSvc *ChannelService) getOrderer() (*model.CaUser, error) {
+	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = f.Write(bt)
-	if err != nil {
-		return err
+	for _, id := range cSvc.ch.OrdererIDs {
+		for i := range orderers {
+			if orderers[i].ID == id {
+				return &orderers[i], nil
+			}
+		}
 	}
-	f.Close()
-	return nil
+	if len(orderers) == 0 {
+		return nil, errors.New(fmt.Sprintf("%s has no orderers", model.GetNetworkNameByID(cSvc.ch.NetworkID)))
+	}
+	return &orderers[0], nil
 }
 
-func (cSvc *ChannelService)updateConfig(signs []msp.SigningIdentity) error {
+// updateConfig submits a config update envelope to the orderer.
+// opts can carry signatures collected elsewhere, see resmgmt.WithConfigSignatures
+func (cSvc *ChannelService) updateConfig(envelope []byte, signs []msp.SigningIdentity, opts ...resmgmt.RequestOption) error {
 	global.Logger.Info("[[update config]]")
-	envelopeFile, err := os.Open(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "org_update_in_envelope.pb"))
-	if err != nil {
-		return err
-	}
-	defer envelopeFile.Close()
 
-	orgID := cSvc.ch.OrganizationIDs[0]
-
-	adminUser, err := dao.FindSystemUserInOrganization(orgID)
+	adminUser, err := cSvc.getSubmitter()
 	if err != nil {
 		return err
 	}
 
-	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
+	orderer, err := cSvc.getOrderer()
 	if err != nil {
 		return err
 	}
 
 	req := resmgmt.SaveChannelRequest{
 		ChannelID:         cSvc.ch.GetName(),
-		ChannelConfig:     envelopeFile,
+		ChannelConfig:     bytes.NewReader(envelope),
 		SigningIdentities: signs,
 	}
 	resmgmtClient, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
 	if err != nil {
 		return err
 	}
-	_, err = resmgmtClient.SaveChannel(
-		req,
+	opts = append(opts,
 		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
-		resmgmt.WithOrdererEndpoint(orderers[0].GetName()))
+		resmgmt.WithOrdererEndpoint(orderer.GetName()))
+	_, err = resmgmtClient.SaveChannel(req, opts...)
 	if err != nil {
 		return errors.WithMessage(err, "fail to update channel config")
 	}
 	return nil
 }
 
+// getOrdererConfigSequence returns the config sequence of the channel seen by the orderer
+// which config updates are submitted to.
+func (cSvc *ChannelService) getOrdererConfigSequence() (uint64, error) {
+	adminUser, err := cSvc.getSubmitter()
+	if err != nil {
+		return 0, err
+	}
+	orderer, err := cSvc.getOrderer()
+	if err != nil {
+		return 0, err
+	}
+
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
+	if err != nil {
+		return 0, errors.WithMessage(err, "fail to get rc")
+	}
+	block, err := rc.QueryConfigBlockFromOrderer(
+		cSvc.ch.GetName(),
+		resmgmt.WithOrdererEndpoint(orderer.GetName()))
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, err := configtx.NewFromBlock(block)
+	if err != nil {
+		return 0, err
+	}
+	return ctx.Sequence(), nil
+}
+
+// getPeerConfigSequence returns the config sequence of the channel seen by the peer
+// which GetChannelConfig reads from.
+func (cSvc *ChannelService) getPeerConfigSequence() (uint64, error) {
+	bt, err := cSvc.GetChannelConfig()
+	if err != nil {
+		return 0, err
+	}
+	ctx, err := configtx.NewFromBlockBytes(bt)
+	if err != nil {
+		return 0, err
+	}
+	return ctx.Sequence(), nil
+}
+
+// WaitForConfigSequence blocks until the orderer has applied the config with sequence seq,
+// and, unless ordererOnly is set, the peer which the next config update is built from has received it.
+// Errors during polling are expected (e.g. the channel is not created yet) and only logged.
+func (cSvc *ChannelService) WaitForConfigSequence(seq uint64, ordererOnly bool) error {
+	global.Logger.Info(fmt.Sprintf("[[wait for config sequence %d of %s]]", seq, cSvc.ch.GetName()))
+
+	checkPeer := !ordererOnly && cSvc.ch.ID != -1
+	deadline := time.Now().Add(configWaitTimeout)
+	for {
+		ok, err := func() (bool, error) {
+			cur, err := cSvc.getOrdererConfigSequence()
+			if err != nil || cur < seq {
+				return false, err
+			}
+			if !checkPeer {
+				return true, nil
+			}
+			cur, err = cSvc.getPeerConfigSequence()
+			return err == nil && cur >= seq, err
+		}()
+		if ok {
+			return nil
+		}
+		if err != nil {
+			global.Logger.Debug("config is not available yet", zap.Error(err))
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("timeout waiting for config sequence %d of %s", seq, cSvc.ch.GetName())
+		}
+		time.Sleep(configPollInterval)
+	}
+}
+
+// getOrdererAdminSigningIdentity is enough to sign updates
+// which only touch the Orderer group and the system-channel.
+func (cSvc *ChannelService) getOrdererAdminSigningIdentity() ([]msp.SigningIdentity, error) {
+	ordOrg, err := dao.FindOrdererOrganizationInNetwork(cSvc.ch.NetworkID)
+	if err != nil {
+		return []msp.SigningIdentity{}, err
+	}
+	sign, err := NewOrganizationService(ordOrg).GetAdminSigningIdentity()
+	if err != nil {
+		return []msp.SigningIdentity{}, errors.WithMessage(err, "ordererAdmin fail to sign")
+	}
+	return []msp.SigningIdentity{sign}, nil
+}
+
+// lock serializes config transactions of the channel,
+// otherwise two updates computed from the same config block conflict.
+func (cSvc *ChannelService) lock() func() {
+	return global.ChannelLocks.Lock(fmt.Sprintf("%s-%s",
+		model.GetNetworkNameByID(cSvc.ch.NetworkID), cSvc.ch.GetName()))
+}
+
+// applyConfigUpdate fetches the latest config, applies mutate to it, submits the resulting update
+// and waits until it takes effect, holding the channel lock the whole time.
+func (cSvc *ChannelService) applyConfigUpdate(mutate func(ctx *configtx.ConfigTx) error, signs []msp.SigningIdentity) error {
+	defer cSvc.lock()()
+
+	ctx, err := cSvc.GetConfigTx()
+	if err != nil {
+		return err
+	}
+	if err := mutate(ctx); err != nil {
+		return err
+	}
+	envelope, err := ctx.ComputeEnvelope()
+	if err != nil {
+		return errors.WithMessage(err, "fail to compute config update")
+	}
+
+	if err := cSvc.updateConfig(envelope, signs); err != nil {
+		return err
+	}
+
+	// the next update must be built from the config containing this one
+	return cSvc.WaitForConfigSequence(ctx.Sequence()+1, false)
+}
+
 // AddOrg uses the existing organization's certificate to update the configuration of the channel
 func (cSvc *ChannelService) AddOrg(orgID int) error {
 	global.Logger.Info(fmt.Sprintf("[Add org%d to channel%d]", orgID, cSvc.ch.ID))
@@ -310,215 +457,547 @@ func (cSvc *ChannelService) AddOrg(orgID int) error {
 		return err
 	}
 
-	// 1. Obtaining channel config
-	global.Logger.Info("1. Obtaining channel config")
-	if err := cSvc.GetAndStoreConfig(); err != nil {
+	// 1. build org definition
+	global.Logger.Info("1. Build org definition")
+	orgDef, err := NewOrganizationService(org).GetConfigtxOrganization()
+	if err != nil {
 		return err
 	}
 
-	// 2. generate configtx.yaml
-	global.Logger.Info("2. generate configtx.yaml")
-	configtxFile, err := os.Create(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "configtx.yaml"))
+	// 2. sign
+	global.Logger.Info("2. Obtaining admin signatures")
+	signs, err := cSvc.GetAllAdminSigningIdentities()
 	if err != nil {
-		return errors.WithMessage(err, "fail to open configtx.yaml")
+		return err
 	}
 
-	_, err = configtxFile.WriteString(org.GetConfigtxFile())
-	if err != nil {
-		return errors.WithMessage(err, "fail to write configtx.yaml")
+	// 3. update channel config
+	global.Logger.Info("3. Update channel config...")
+	if err := cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.AddApplicationOrg(orgDef)
+	}, signs); err != nil {
+		return err
 	}
 
-	// 3. call addorg.sh to generate org_update_in_envelope.pb
-	global.Logger.Info("3. call addorg.sh to generate org_update_in_envelope.pb")
-	tools := kubernetes.Tools{}
-	_, _, err = tools.ExecCommand(
-		filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"),
-		"addOrg",
-		cSvc.ch.GetName(),
-		org.GetMSPID(),)
+	cSvc.ch.OrganizationIDs = append(cSvc.ch.OrganizationIDs, orgID)
+	return dao.UpdateOrgIDs(cSvc.ch.ID, orgID)
+}
+
+// AddOrgAndJoinPeers adds the organization to the channel,
+// then joins all its peers to the channel and makes them anchor peers.
+func (cSvc *ChannelService) AddOrgAndJoinPeers(orgID int) error {
+	global.Logger.Info(fmt.Sprintf("[Add org%d and its peers to channel%d]", orgID, cSvc.ch.ID))
+	defer global.Logger.Info(fmt.Sprintf("[Add org%d and its peers to channel%d] done!", orgID, cSvc.ch.ID))
+
+	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
 	if err != nil {
-		return errors.WithMessage(err, "fail to exec addorg.sh")
+		return err
 	}
 
-	// 4. sign for org_update_in_envelope.pb
-	global.Logger.Info("4. sign for org_update_in_envelope.pb")
-	signs, err := cSvc.GetAllAdminSigningIdentities()
-		//NewNetworkService(net).GetAllAdminSigningIdentities()
-	if err != nil {
+	// 1. AddOrg returns after the orderer has applied the update,
+	//    so the orderer won't reject the new peers as outsiders of the channel.
+	if err := cSvc.AddOrg(orgID); err != nil {
 		return err
 	}
 
-	// 5. update org_update_in_envelope.pb
-	global.Logger.Info("5. Update channel config...")
-	if err := cSvc.updateConfig(signs); err != nil {
+	// 2. join
+	peers, err := dao.FindAllPeersInOrganization(orgID)
+	if err != nil {
 		return err
 	}
+	for _, peer := range peers {
+		global.Logger.Info(fmt.Sprintf("%s join channel", peer.GetName()))
+		if err := NewCaUserService(&peer).JoinChannel(cSvc.ch.ID, orderers[0].GetName()); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("%s fail to join channel%d", peer.GetName(), cSvc.ch.ID))
+		}
+	}
 
-	cSvc.ch.OrganizationIDs = append(cSvc.ch.OrganizationIDs, orgID)
-	return dao.UpdateOrgIDs(cSvc.ch.ID, orgID)
+	// 3. anchors
+	global.Logger.Info(fmt.Sprintf("update anchors(org%d)", orgID))
+	return cSvc.UpdateAnchors(orgID)
 }
 
-func (cSvc *ChannelService)UpdateAnchors(orgID int) error {
-	global.Logger.Info("[[update anchor]]")
-	// 不要让用户自定义了，让所有peer都成为锚节点
-	if orgID <= 0 {
-		return errors.New(fmt.Sprintf("org ID is incorrect. ID: %d", orgID))
+// RemoveOrg removes the organization's group from the channel config and unjoins its peers.
+// It refuses if endorsement policies of the channel or of its chaincodes name the organization,
+// those must be changed first, otherwise transactions could never be endorsed again.
+func (cSvc *ChannelService) RemoveOrg(orgID int) error {
+	global.Logger.Info(fmt.Sprintf("[Remove org%d from channel%d]", orgID, cSvc.ch.ID))
+	defer global.Logger.Info(fmt.Sprintf("[Remove org%d from channel%d] done!", orgID, cSvc.ch.ID))
+	if cSvc.ch.ID == -1 {
+		return errors.New("use NetworkService.RemoveOrgFromConsortium for system-channel")
 	}
 
 	org, err := dao.FindOrganizationByID(orgID)
 	if err != nil {
 		return err
 	}
-	net, err := dao.FindNetworkByID(cSvc.ch.NetworkID)
+
+	// 1. check
+	global.Logger.Info("1. Check membership and endorsement policies")
+	rest := []int{}
+	for _, id := range cSvc.ch.OrganizationIDs {
+		if id != orgID {
+			rest = append(rest, id)
+		}
+	}
+	if len(rest) == len(cSvc.ch.OrganizationIDs) {
+		return errors.New(fmt.Sprintf("%s is not in %s", org.GetName(), cSvc.ch.GetName()))
+	}
+	if len(rest) == 0 {
+		return errors.New("can not remove the last organization of the channel")
+	}
+	ccs, err := dao.FindAllChaincodesInChannel(cSvc.ch.ID)
 	if err != nil {
 		return err
 	}
+	for _, cc := range ccs {
+		if configtx.RuleReferences(cc.PolicyStr, org.GetMSPID()) {
+			return errors.New(fmt.Sprintf("the endorsement policy of %s names %s: %s",
+				cc.GetName(), org.GetMSPID(), cc.PolicyStr))
+		}
+	}
+	ctx, err := cSvc.GetConfigTx()
+	if err != nil {
+		return err
+	}
+	names, err := ctx.PoliciesReferencing(org.GetMSPID(), configtx.ApplicationGroupKey)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return errors.New(fmt.Sprintf("application policies of %s name %s: %s",
+			cSvc.ch.GetName(), org.GetMSPID(), strings.Join(names, ", ")))
+	}
 
-	// generate config_block.pb
-	if err := cSvc.GetAndStoreConfig(); err != nil {
+	// 2. sign
+	global.Logger.Info("2. Obtaining admin signatures")
+	signs, err := cSvc.GetAllAdminSigningIdentities()
+	if err != nil {
+		return err
+	}
+
+	// 3. update channel config,
+	//    submitted and read back by an organization which stays in the channel
+	global.Logger.Info("3. Update channel config...")
+	cSvc.ch.OrganizationIDs = append(rest, orgID)
+	if err := cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.RemoveApplicationOrg(org.GetMSPID())
+	}, signs); err != nil {
 		return err
 	}
 
-	// generate anchors.json
+	cSvc.ch.OrganizationIDs = rest
+	if err := dao.RemoveOrgID(cSvc.ch.ID, orgID); err != nil {
+		return err
+	}
+
+	// 4. unjoin, the orderer won't deliver blocks to the peers any more
+	if org.External {
+		global.Logger.Info("4. peers of an external organization are not managed by mictract, skip")
+		return nil
+	}
+	global.Logger.Info("4. Unjoin peers")
 	peers, err := dao.FindAllPeersInOrganization(orgID)
 	if err != nil {
 		return err
 	}
+	failures := []string{}
+	for i := range peers {
+		if err := NewCaUserService(&peers[i]).UnjoinChannel(cSvc.ch.GetName()); err != nil {
+			global.Logger.Error(fmt.Sprintf("%s fail to unjoin %s", peers[i].GetName(), cSvc.ch.GetName()), zap.Error(err))
+			failures = append(failures, fmt.Sprintf("%s: %s", peers[i].GetName(), err.Error()))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(fmt.Sprintf("%s is removed from %s, but some peers fail to unjoin: %s",
+			org.GetName(), cSvc.ch.GetName(), strings.Join(failures, "; ")))
+	}
+	return nil
+}
 
-	st := `{"mod_policy":"Admins","value":{"anchor_peers":[`
-	for _, peer := range peers {
-		st += `{"host":"` + peer.GetURL() + `","port":7051},`
+// JoinPeers joins the peers to the channel, their organizations must be in the channel.
+func (cSvc *ChannelService) JoinPeers(peerIDs []int) error {
+	global.Logger.Info(fmt.Sprintf("[Join peers %v to %s]", peerIDs, cSvc.ch.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Join peers %v to %s] done!", peerIDs, cSvc.ch.GetName()))
+
+	peers := []model.CaUser{}
+	for _, id := range peerIDs {
+		peer, err := dao.FindCaUserByID(id)
+		if err != nil {
+			return err
+		}
+		if peer.Type != "peer" || peer.NetworkID != cSvc.ch.NetworkID {
+			return errors.New(fmt.Sprintf("%s is not a peer of the network of %s", peer.GetName(), cSvc.ch.GetName()))
+		}
+		inChannel := false
+		for _, orgID := range cSvc.ch.OrganizationIDs {
+			if orgID == peer.OrganizationID {
+				inChannel = true
+				break
+			}
+		}
+		if !inChannel {
+			return errors.New(fmt.Sprintf("the organization of %s is not in %s", peer.GetName(), cSvc.ch.GetName()))
+		}
+		peers = append(peers, *peer)
 	}
-	//st += `{"host":"` + "lilingj.github.io" + `","port":7051},`
-	// jq这个坑货，多一个逗号就解析不出来
-	st = st[:(len(st) - 1)]
-	st += `]},"version":"0"}`
-	f, err := os.Create(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "anchors.json"))
+
+	orderer, err := cSvc.getOrderer()
 	if err != nil {
 		return err
 	}
-	if _, err = f.WriteString(st); err != nil {
-		return err
+	for i := range peers {
+		global.Logger.Info(fmt.Sprintf("%s join channel", peers[i].GetName()))
+		if err := NewCaUserService(&peers[i]).JoinChannel(cSvc.ch.ID, orderer.GetName()); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("%s fail to join %s", peers[i].GetName(), cSvc.ch.GetName()))
+		}
 	}
-	f.Close()
+	return nil
+}
 
-	// call addorg.sh to generate org_update_in_envelope.pb
-	// TODO
-	//cmd := exec.Command(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"), "updateAnchors", c.Name, org.MSPID)
-	//output, err := cmd.CombinedOutput()
-	//global.Logger.Info(string(output))
-	tools := kubernetes.Tools{}
-	_, _, err = tools.ExecCommand(
-		filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"),
-		"updateAnchors",
-		cSvc.ch.GetName(),
-		org.GetMSPID())
-	if err != nil {
-		return errors.WithMessage(err, "fail to exec addorg.sh")
+// GetPeerHeights returns the ledger height of the channel on every peer of its organizations, by peer ID.
+// Peers which haven't joined the channel or are not reachable are left out.
+func (cSvc *ChannelService) GetPeerHeights() (map[int]uint64, error) {
+	heights := map[int]uint64{}
+	for _, orgID := range cSvc.ch.OrganizationIDs {
+		adminUser, err := dao.FindSystemUserInOrganization(orgID)
+		if err != nil {
+			return nil, err
+		}
+		lc, err := sdk.NewSDKClientFactory().NewLedgerClient(adminUser, cSvc.ch)
+		if err != nil {
+			return nil, err
+		}
+		peers, err := dao.FindAllPeersInOrganization(orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, peer := range peers {
+			info, err := lc.QueryInfo(ledger.WithTargetEndpoints(peer.GetName()))
+			if err != nil {
+				global.Logger.Debug(fmt.Sprintf("fail to query %s of %s", cSvc.ch.GetName(), peer.GetName()), zap.Error(err))
+				continue
+			}
+			heights[peer.ID] = info.BCI.Height
+		}
 	}
+	return heights, nil
+}
 
-	// sign for org_update_in_envelope.pb and update it
-	signs, err := NewNetworkService(net).GetAllAdminSigningIdentities()
+// UpdateAnchors makes all peers of the organization its anchor peers in the channel.
+func (cSvc *ChannelService)UpdateAnchors(orgID int) error {
+	return cSvc.SetAnchors(orgID, nil)
+}
+
+// GetAnchors returns the anchor peers of every organization in the channel, by organization ID.
+func (cSvc *ChannelService) GetAnchors() (map[int][]configtx.Address, error) {
+	orgs, err := dao.FindAllOrganizationsInChannel(cSvc.ch)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	ctx, err := cSvc.GetConfigTx()
+	if err != nil {
+		return nil, err
 	}
 
-	// update org_update_in_envelope.pb
-	return cSvc.updateConfig(signs)
+	ret := map[int][]configtx.Address{}
+	for _, org := range orgs {
+		anchors, err := ctx.AnchorPeers(org.GetMSPID())
+		if err != nil {
+			return nil, err
+		}
+		ret[org.ID] = anchors
+	}
+	return ret, nil
 }
 
-// AddOrderers
-// eg: GetSystemChannel(n.ID).AddOrderers(Order{fmt.Sprintf("orderer%d.net%d.com", len(n.Orderers) + 1, n.ID)})
-func (cSvc *ChannelService)AddOrderers(orderer model.CaUser) error {
-	global.Logger.Info("[[Add Orderer to channel]]")
-	if cSvc.ch.ID != -1 {
-		return errors.New("only for system-channel")
+// SetAnchors replaces the anchor peers of the organization in the channel,
+// so that large organizations don't gossip-bootstrap from every peer.
+// An empty peerIDs means all peers of the organization.
+func (cSvc *ChannelService) SetAnchors(orgID int, peerIDs []int) error {
+	global.Logger.Info(fmt.Sprintf("[Set anchors of org%d in %s]", orgID, cSvc.ch.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Set anchors of org%d in %s] done!", orgID, cSvc.ch.GetName()))
+	if orgID <= 0 {
+		return errors.New(fmt.Sprintf("org ID is incorrect. ID: %d", orgID))
 	}
 
-	// generate config_block.pb
-	if err := cSvc.GetAndStoreConfig(); err != nil {
+	org, err := dao.FindOrganizationByID(orgID)
+	if err != nil {
+		return err
+	}
+	net, err := dao.FindNetworkByID(cSvc.ch.NetworkID)
+	if err != nil {
 		return err
 	}
 
-	// generate ord1.json
-	orderers, err := dao.FindAllOrderersInNetwork(cSvc.ch.NetworkID)
+	// 1. check
+	global.Logger.Info("1. Check anchor peers")
+	inChannel := false
+	for _, id := range cSvc.ch.OrganizationIDs {
+		if id == orgID {
+			inChannel = true
+			break
+		}
+	}
+	if !inChannel {
+		return errors.New(fmt.Sprintf("%s is not in %s", org.GetName(), cSvc.ch.GetName()))
+	}
+	peers, err := dao.FindAllPeersInOrganization(orgID)
 	if err != nil {
 		return err
 	}
-	net, err := dao.FindNetworkByID(cSvc.ch.NetworkID)
+	if len(peerIDs) > 0 {
+		selected := []model.CaUser{}
+		for _, id := range peerIDs {
+			found := false
+			for _, peer := range peers {
+				if peer.ID == id {
+					selected = append(selected, peer)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errors.New(fmt.Sprintf("peer%d is not a peer of %s", id, org.GetName()))
+			}
+		}
+		peers = selected
+	}
+	anchors := []configtx.Address{}
+	for _, peer := range peers {
+		anchors = append(anchors, configtx.Address{Host: peer.GetURL(), Port: 7051})
+	}
+
+	signs, err := NewNetworkService(net).GetAllAdminSigningIdentities()
 	if err != nil {
 		return err
 	}
 
+	// 2. update channel config
+	global.Logger.Info("2. Update channel config...")
+	return cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.SetAnchorPeers(org.GetMSPID(), anchors)
+	}, signs)
+}
 
-	st := `["`
-	for _, orderer := range orderers {
-		tlscert, err := dao.FindCertByUserID(orderer.ID, true)
+// SetRevocationList replaces the CRLs in the MSP definition of the organization,
+// so that the channel rejects the certificates revoked by its CA.
+// The orderer organization is in the Orderer group, peer organizations are in the Application group,
+// or in the consortium of system-channel.
+func (cSvc *ChannelService) SetRevocationList(org *model.Organization, crls [][]byte) error {
+	global.Logger.Info(fmt.Sprintf("[Update CRL of %s in %s]", org.GetName(), cSvc.ch.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Update CRL of %s in %s] done!", org.GetName(), cSvc.ch.GetName()))
+
+	path := []string{configtx.ApplicationGroupKey, org.GetMSPID()}
+	if org.IsOrdererOrganization() {
+		path = []string{configtx.OrdererGroupKey, org.GetMSPID()}
+	} else if cSvc.ch.ID == -1 {
+		path = []string{configtx.ConsortiumsGroupKey, consortiumName, org.GetMSPID()}
+	}
+
+	// 1. sign, the MSP value is modified by the admins of the organization itself,
+	// also in the consortium of system-channel
+	global.Logger.Info("1. Obtaining admin signatures")
+	var signs []msp.SigningIdentity
+	if org.IsOrdererOrganization() {
+		var err error
+		if signs, err = cSvc.getOrdererAdminSigningIdentity(); err != nil {
+			return err
+		}
+	} else {
+		sign, err := NewOrganizationService(org).GetAdminSigningIdentity()
 		if err != nil {
 			return err
 		}
-		st += `{"client_tls_cert":"` + base64.StdEncoding.EncodeToString([]byte(tlscert.Certification)) +
-			`","host":"` + orderer.GetURL() +
-			`","port":7050,` +
-			`"server_tls_cert":"` + base64.StdEncoding.EncodeToString([]byte(tlscert.Certification)) + `"},`
+		signs = []msp.SigningIdentity{sign}
 	}
-	st += "]"
-	f1, err := os.Create(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "ord1.json"))
+
+	// 2. update channel config
+	global.Logger.Info("2. Update channel config...")
+	return cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.SetRevocationList(crls, path...)
+	}, signs)
+}
+
+// AddOrderers adds the orderer to the consenters and orderer addresses of the channel.
+// consensus must be "etcdraft"
+func (cSvc *ChannelService)AddOrderers(orderer model.CaUser) error {
+	global.Logger.Info(fmt.Sprintf("[[Add %s to channel]]", orderer.GetName()))
+
+	ordOrg, err := dao.FindOrganizationByID(orderer.OrganizationID)
 	if err != nil {
 		return err
 	}
-	if _, err = f1.WriteString(st); err != nil {
+	tlscert, err := dao.FindCertByUserID(orderer.ID, true)
+	if err != nil {
 		return err
 	}
-	f1.Close()
 
-	// generate ord2.json
-	st = `[`
-	for _, orderer := range orderers {
-		st += `"` + orderer.GetURL() + `",`
+	signs, err := cSvc.getOrdererAdminSigningIdentity()
+	if err != nil {
+		return err
 	}
-	st += "]"
-	f2, err := os.Create(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "ord2.json"))
+
+	return cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		// 1. consenter
+		if err := ctx.AddConsenter(configtx.Consenter{
+			Host:          orderer.GetURL(),
+			Port:          7050,
+			ClientTLSCert: []byte(tlscert.Certification),
+			ServerTLSCert: []byte(tlscert.Certification),
+		}); err != nil {
+			return err
+		}
+
+		// 2. addresses
+		address := orderer.GetURL() + ":7050"
+		addresses, err := ctx.OrdererAddresses()
+		if err != nil {
+			return err
+		}
+		if err := ctx.SetOrdererAddresses(append(addresses, address)); err != nil {
+			return err
+		}
+		endpoints, err := ctx.OrdererEndpoints(ordOrg.GetMSPID())
+		if err != nil {
+			return err
+		}
+		if len(endpoints) > 0 {
+			return ctx.SetOrdererEndpoints(ordOrg.GetMSPID(), append(endpoints, address))
+		}
+		return nil
+	}, signs)
+}
+
+// RemoveOrderer removes the orderer from the consenters and orderer addresses of the channel.
+// The orderer should not be in ch.OrdererIDs any more, otherwise the update is submitted to itself.
+// consensus must be "etcdraft"
+func (cSvc *ChannelService) RemoveOrderer(orderer model.CaUser) error {
+	global.Logger.Info(fmt.Sprintf("[[Remove %s from channel]]", orderer.GetName()))
+
+	ordOrg, err := dao.FindOrganizationByID(orderer.OrganizationID)
 	if err != nil {
 		return err
 	}
-	if _, err = f2.WriteString(st); err != nil {
+
+	signs, err := cSvc.getOrdererAdminSigningIdentity()
+	if err != nil {
 		return err
 	}
-	f2.Close()
 
-	// call addorg.sh to generate org_update_in_envelope.pb
-	// TODO
-	//cmd := exec.Command(filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"), "addOrderers", c.Name)
-	//output, err := cmd.CombinedOutput()
-	//global.Logger.Info(string(output))
-	tools := kubernetes.Tools{}
-	_, _, err = tools.ExecCommand(
-		filepath.Join(config.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"),
-		"addOrderers",
-		cSvc.ch.GetName(),
-	)
+	address := orderer.GetURL() + ":7050"
+	without := func(addresses []string) []string {
+		ret := []string{}
+		for _, a := range addresses {
+			if a != address {
+				ret = append(ret, a)
+			}
+		}
+		return ret
+	}
+
+	return cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		// 1. consenter
+		if err := ctx.RemoveConsenter(orderer.GetURL(), 7050); err != nil {
+			return err
+		}
+
+		// 2. addresses
+		addresses, err := ctx.OrdererAddresses()
+		if err != nil {
+			return err
+		}
+		if err := ctx.SetOrdererAddresses(without(addresses)); err != nil {
+			return err
+		}
+		endpoints, err := ctx.OrdererEndpoints(ordOrg.GetMSPID())
+		if err != nil {
+			return err
+		}
+		if len(endpoints) > 0 {
+			return ctx.SetOrdererEndpoints(ordOrg.GetMSPID(), without(endpoints))
+		}
+		return nil
+	}, signs)
+}
+
+// GetConsenters returns the raft consenters in the latest config block of the channel
+func (cSvc *ChannelService) GetConsenters() ([]configtx.Consenter, error) {
+	ctx, err := cSvc.GetConfigTx()
 	if err != nil {
-		return errors.WithMessage(err, "fail to exec addorg.sh")
+		return nil, err
 	}
+	consensusType, err := ctx.ConsensusType()
+	if err != nil {
+		return nil, err
+	}
+	if consensusType != "etcdraft" {
+		return []configtx.Consenter{}, nil
+	}
+	return ctx.Consenters()
+}
 
-	// sign for org_update_in_envelope.pb and update it
-	signs, err := NewNetworkService(net).GetAllAdminSigningIdentities()
+// GetPolicies decodes the policies of every config group of the channel,
+// the sequence of the config they are read from is returned as well.
+func (cSvc *ChannelService) GetPolicies() (*configtx.PolicyGroup, uint64, error) {
+	ctx, err := cSvc.GetConfigTx()
+	if err != nil {
+		return nil, 0, err
+	}
+	tree, err := ctx.PolicyTree()
+	if err != nil {
+		return nil, 0, err
+	}
+	return tree, ctx.Sequence(), nil
+}
+
+// UpdatePolicy adds or replaces the policy named name of the config group at path,
+// eg: path ["Application", "org1MSP"]. An empty path means the channel group.
+// Admins of all organizations in the channel sign the update, and the orderer admin as well
+// if the channel group or the Orderer group is touched, which satisfies the default "MAJORITY Admins".
+func (cSvc *ChannelService) UpdatePolicy(path []string, name string, policy configtx.Policy) error {
+	global.Logger.Info(fmt.Sprintf("[Update policy %s of %v in %s]", name, path, cSvc.ch.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Update policy %s of %v in %s] done!", name, path, cSvc.ch.GetName()))
+	if cSvc.ch.ID == -1 {
+		return errors.New("policies of system-channel can't be updated")
+	}
+
+	// 1. sign
+	global.Logger.Info("1. Obtaining admin signatures")
+	signs, err := cSvc.GetAllAdminSigningIdentities()
 	if err != nil {
 		return err
 	}
+	if len(path) == 0 || path[0] == configtx.OrdererGroupKey {
+		ordSigns, err := cSvc.getOrdererAdminSigningIdentity()
+		if err != nil {
+			return err
+		}
+		signs = append(signs, ordSigns...)
+	}
+
+	// 2. update channel config
+	global.Logger.Info("2. Update channel config...")
+	return cSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.SetPolicy(name, policy, path...)
+	}, signs)
+}
 
-	// update org_update_in_envelope.pb
-	return cSvc.updateConfig(signs)
+// ProposePolicyUpdate is UpdatePolicy for channels whose organizations sign on their own,
+// the update waits for signatures as a model.ConfigUpdate.
+func (cSvc *ChannelService) ProposePolicyUpdate(path []string, name string, policy configtx.Policy) (*model.ConfigUpdate, error) {
+	return cSvc.ProposeConfigUpdate(fmt.Sprintf("update policy %s of %v", name, path), func(ctx *configtx.ConfigTx) error {
+		return ctx.SetPolicy(name, policy, path...)
+	})
 }
 
 // 渲染一个通道，只包含通道中第一个org
-func (cSvc *ChannelService) RenderConfigtx() error {
+// configtx.yaml is written into dir, which should be a workspace of the channel
+func (cSvc *ChannelService) RenderConfigtx(dir string) error {
 	global.Logger.Info("[[render config tx]]")
 	templ := template.Must(template.ParseFiles(path.Join(config.LOCAL_MOUNT_PATH, "channel.yaml.tpl")))
 
-	filename := fmt.Sprintf("/mictract/networks/net%d/configtx.yaml", cSvc.ch.NetworkID)
-	writer, err := os.Create(filename)
+	writer, err := os.Create(filepath.Join(dir, "configtx.yaml"))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/pkg/errors"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"mictract/service/configtx"
+	"mictract/service/factory/sdk"
+	"strings"
+)
+
+// ConfigUpdateService collects the signatures of a pending config update and submits it,
+// for channels whose organizations are operated by different teams.
+type ConfigUpdateService struct {
+	cu *model.ConfigUpdate
+}
+
+func NewConfigUpdateService(cu *model.ConfigUpdate) *ConfigUpdateService {
+	return &ConfigUpdateService{
+		cu: cu,
+	}
+}
+
+// ProposeConfigUpdate applies mutate to the latest config and saves the resulting update
+// as a pending model.ConfigUpdate instead of signing and submitting it.
+func (cSvc *ChannelService) ProposeConfigUpdate(description string, mutate func(ctx *configtx.ConfigTx) error) (*model.ConfigUpdate, error) {
+	global.Logger.Info(fmt.Sprintf("[Propose config update of %s: %s]", cSvc.ch.GetName(), description))
+	if cSvc.ch.ID == -1 {
+		return nil, errors.New("config updates of system-channel can't be proposed")
+	}
+
+	ctx, err := cSvc.GetConfigTx()
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(ctx); err != nil {
+		return nil, err
+	}
+	envelope, err := ctx.ComputeEnvelope()
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to compute config update")
+	}
+
+	cu := &model.ConfigUpdate{
+		NetworkID: 		cSvc.ch.NetworkID,
+		ChannelID: 		cSvc.ch.ID,
+		Description: 	description,
+		Envelope: 		envelope,
+		Sequence: 		ctx.Sequence(),
+		Signatures: 	[]model.ConfigSignature{},
+		Status: 		enum.StatusPending,
+	}
+	if err := dao.InsertConfigUpdate(cu); err != nil {
+		return nil, errors.WithMessage(err, "Unable to insert config update")
+	}
+	return cu, nil
+}
+
+func (cuSvc *ConfigUpdateService) getChannelService() (*ChannelService, error) {
+	ch, err := dao.FindChannelByID(cuSvc.cu.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	return NewChannelService(ch), nil
+}
+
+// getConfigUpdate returns the marshaled ConfigUpdate, which is what signatures are made over
+func (cuSvc *ConfigUpdateService) getConfigUpdate() ([]byte, error) {
+	env, err := configtx.ParseEnvelope(cuSvc.cu.Envelope)
+	if err != nil {
+		return nil, err
+	}
+	return env.ConfigUpdate, nil
+}
+
+// checkPending fails unless the update still takes signatures,
+// a failed update does, so that it can be fixed and submitted again.
+func (cuSvc *ConfigUpdateService) checkPending() error {
+	if cuSvc.cu.Status != enum.StatusPending && cuSvc.cu.Status != enum.StatusError {
+		return errors.New(fmt.Sprintf("%s is %s", cuSvc.cu.GetName(), cuSvc.cu.Status))
+	}
+	return nil
+}
+
+// Claim moves a pending or failed update to submitting, false if it has been claimed by someone else.
+// Submit needs the update claimed.
+func (cuSvc *ConfigUpdateService) Claim() (bool, error) {
+	ok, err := dao.SwapConfigUpdateStatusByID(cuSvc.cu.ID, []string{enum.StatusPending, enum.StatusError}, enum.StatusSubmitting)
+	if err != nil || !ok {
+		return false, err
+	}
+	cuSvc.cu.Status, cuSvc.cu.Error = enum.StatusSubmitting, ""
+	return true, nil
+}
+
+// GetEnvelope returns the unsigned envelope for `peer channel signconfigtx`
+func (cuSvc *ConfigUpdateService) GetEnvelope() []byte {
+	return cuSvc.cu.Envelope
+}
+
+// Sign signs the update with the admin of the organization held by mictract
+func (cuSvc *ConfigUpdateService) Sign(org *model.Organization) error {
+	global.Logger.Info(fmt.Sprintf("[%s signs %s]", org.GetName(), cuSvc.cu.GetName()))
+	if err := cuSvc.checkPending(); err != nil {
+		return err
+	}
+	if org.NetworkID != cuSvc.cu.NetworkID {
+		return errors.New(fmt.Sprintf("%s is not in the network of %s", org.GetName(), cuSvc.cu.GetName()))
+	}
+
+	signer, err := NewOrganizationService(org).GetAdminSigningIdentity()
+	if err != nil {
+		return err
+	}
+	adminUser, err := dao.FindSystemUserInOrganization(org.ID)
+	if err != nil {
+		return err
+	}
+	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get rc")
+	}
+	sig, err := rc.CreateConfigSignatureFromReader(signer, bytes.NewReader(cuSvc.cu.Envelope))
+	if err != nil {
+		return errors.WithMessage(err, "fail to sign "+cuSvc.cu.GetName())
+	}
+
+	ctx, err := cuSvc.getConfigTx()
+	if err != nil {
+		return err
+	}
+	_, err = cuSvc.addSignatures(ctx, []*cb.ConfigSignature{sig})
+	return err
+}
+
+// AddSignedEnvelope takes the signatures of an envelope signed by `peer channel signconfigtx`,
+// which must be the envelope of this update. It returns the number of new signatures.
+func (cuSvc *ConfigUpdateService) AddSignedEnvelope(signed []byte) (int, error) {
+	global.Logger.Info(fmt.Sprintf("[Add signatures to %s]", cuSvc.cu.GetName()))
+	if err := cuSvc.checkPending(); err != nil {
+		return 0, err
+	}
+
+	env, err := configtx.ParseEnvelope(signed)
+	if err != nil {
+		return 0, err
+	}
+	update, err := cuSvc.getConfigUpdate()
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(env.ConfigUpdate, update) {
+		return 0, errors.New(fmt.Sprintf("the envelope is not the one of %s", cuSvc.cu.GetName()))
+	}
+	if len(env.Signatures) == 0 {
+		return 0, errors.New("the envelope is not signed")
+	}
+
+	ctx, err := cuSvc.getConfigTx()
+	if err != nil {
+		return 0, err
+	}
+	return cuSvc.addSignatures(ctx, env.Signatures)
+}
+
+// addSignatures verifies the signatures against ctx, the latest channel config, and saves the ones of new signers,
+// a signer who signs again is not counted twice. The saved signatures are read again while the row is held,
+// so a concurrent signer is neither lost nor counted twice, and the status is checked again.
+func (cuSvc *ConfigUpdateService) addSignatures(ctx *configtx.ConfigTx, sigs []*cb.ConfigSignature) (int, error) {
+	update, err := cuSvc.getConfigUpdate()
+	if err != nil {
+		return 0, err
+	}
+
+	type verified struct {
+		mspID	string
+		creator	[]byte
+		sig		*cb.ConfigSignature
+	}
+	news := []verified{}
+	for _, sig := range sigs {
+		mspID, creator, err := ctx.VerifyAdminSignature(update, sig)
+		if err != nil {
+			return 0, err
+		}
+		news = append(news, verified{mspID: mspID, creator: creator, sig: sig})
+	}
+
+	added := 0
+	signatures, err := dao.AddConfigUpdateSignaturesByID(cuSvc.cu.ID,
+		[]string{enum.StatusPending, enum.StatusError},
+		func(saved []model.ConfigSignature) ([]model.ConfigSignature, error) {
+			added = 0
+			signers := map[string]bool{}
+			for _, old := range saved {
+				creator, err := configtx.SignatureCreator(old.SignatureHeader)
+				if err != nil {
+					return nil, err
+				}
+				signers[string(creator)] = true
+			}
+			for _, v := range news {
+				if signers[string(v.creator)] {
+					continue
+				}
+				signers[string(v.creator)] = true
+				saved = append(saved, model.ConfigSignature{
+					MSPID: 				v.mspID,
+					SignatureHeader: 	v.sig.SignatureHeader,
+					Signature: 			v.sig.Signature,
+				})
+				added++
+			}
+			return saved, nil
+		})
+	if err != nil {
+		return 0, err
+	}
+	cuSvc.cu.Signatures = signatures
+	return added, nil
+}
+
+// getConfigTx returns the latest config of the channel, which signatures are verified against
+func (cuSvc *ConfigUpdateService) getConfigTx() (*configtx.ConfigTx, error) {
+	cSvc, err := cuSvc.getChannelService()
+	if err != nil {
+		return nil, err
+	}
+	return cSvc.GetConfigTx()
+}
+
+// GetUnsatisfiedPolicies evaluates the collected signatures against the latest channel config.
+// stale is true if the config has changed since the update was proposed,
+// then the orderer would reject it whatever the signatures are.
+func (cuSvc *ConfigUpdateService) GetUnsatisfiedPolicies() (unsatisfied []string, stale bool, err error) {
+	ctx, err := cuSvc.getConfigTx()
+	if err != nil {
+		return nil, false, err
+	}
+
+	updateBytes, err := cuSvc.getConfigUpdate()
+	if err != nil {
+		return nil, false, err
+	}
+	update := &cb.ConfigUpdate{}
+	if err := proto.Unmarshal(updateBytes, update); err != nil {
+		return nil, false, errors.WithMessage(err, "fail to unmarshal config update")
+	}
+
+	// signatures are checked again, the MSPs may have changed since, eg: by a CRL
+	signers := map[string]bool{}
+	mspIDs := []string{}
+	for _, sig := range cuSvc.cu.Signatures {
+		mspID, creator, err := ctx.VerifyAdminSignature(updateBytes, &cb.ConfigSignature{
+			SignatureHeader: sig.SignatureHeader,
+			Signature: sig.Signature,
+		})
+		if err != nil {
+			global.Logger.Info(fmt.Sprintf("signature of %s is not counted: %s", sig.MSPID, err.Error()))
+			continue
+		}
+		if !signers[string(creator)] {
+			signers[string(creator)] = true
+			mspIDs = append(mspIDs, mspID)
+		}
+	}
+
+	unsatisfied, err = ctx.UnsatisfiedPolicies(update, mspIDs)
+	if err != nil {
+		return nil, false, err
+	}
+	return unsatisfied, ctx.Sequence() != cuSvc.cu.Sequence, nil
+}
+
+// Submit sends the update with the collected signatures to the orderer
+// and waits until it takes effect. The update must have been claimed by Claim.
+func (cuSvc *ConfigUpdateService) Submit() (err error) {
+	global.Logger.Info(fmt.Sprintf("[Submit %s]", cuSvc.cu.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Submit %s] done!", cuSvc.cu.GetName()))
+	if cuSvc.cu.Status != enum.StatusSubmitting {
+		return errors.New(fmt.Sprintf("%s is %s", cuSvc.cu.GetName(), cuSvc.cu.Status))
+	}
+	defer func() {
+		if err != nil {
+			_ = dao.UpdateConfigUpdateStatusByID(cuSvc.cu.ID, enum.StatusError, err.Error())
+		}
+	}()
+
+	// 1. check
+	global.Logger.Info("1. Check signatures")
+	unsatisfied, stale, err := cuSvc.GetUnsatisfiedPolicies()
+	if err != nil {
+		return err
+	}
+	if stale {
+		return errors.New("the channel config has changed since the update was proposed, please propose it again")
+	}
+	if len(unsatisfied) > 0 {
+		return errors.New("policies are not satisfied yet: " + strings.Join(unsatisfied, ", "))
+	}
+
+	// 2. submit
+	global.Logger.Info("2. Update channel config...")
+	cSvc, err := cuSvc.getChannelService()
+	if err != nil {
+		return err
+	}
+	sigs := []*cb.ConfigSignature{}
+	for _, sig := range cuSvc.cu.Signatures {
+		sigs = append(sigs, &cb.ConfigSignature{SignatureHeader: sig.SignatureHeader, Signature: sig.Signature})
+	}
+
+	defer cSvc.lock()()
+	if err := cSvc.updateConfig(cuSvc.cu.Envelope, nil, resmgmt.WithConfigSignatures(sigs...)); err != nil {
+		return err
+	}
+	if err := cSvc.WaitForConfigSequence(cuSvc.cu.Sequence+1, false); err != nil {
+		return err
+	}
+
+	cuSvc.cu.Status = enum.StatusSubmitted
+	return dao.UpdateConfigUpdateStatusByID(cuSvc.cu.ID, enum.StatusSubmitted, "")
+}
+
+// Cancel drops a pending or failed update, nothing is sent to the orderer
+func (cuSvc *ConfigUpdateService) Cancel() error {
+	if err := cuSvc.checkPending(); err != nil {
+		return err
+	}
+	ok, err := dao.SwapConfigUpdateStatusByID(cuSvc.cu.ID, []string{enum.StatusPending, enum.StatusError}, enum.StatusCanceled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New(fmt.Sprintf("%s is being submitted", cuSvc.cu.GetName()))
+	}
+	cuSvc.cu.Status = enum.StatusCanceled
+	return nil
+}
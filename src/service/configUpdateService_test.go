@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	_ "mictract/init"
+	"mictract/model"
+	"mictract/service"
+	"mictract/service/configtx"
+	"testing"
+	"time"
+)
+
+// newTestOrgCert issues a certificate with ou, self-signed if parent is nil
+func newTestOrgCert(t *testing.T, cn, ou string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn, OrganizationalUnit: []string{ou}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func signTestConfigUpdate(t *testing.T, update []byte, mspID string, certPEM []byte, key *ecdsa.PrivateKey) *cb.ConfigSignature {
+	creator, err := proto.Marshal(&mb.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+	assert.NoError(t, err)
+	nonce := make([]byte, 24)
+	_, err = rand.Read(nonce)
+	assert.NoError(t, err)
+	header, err := proto.Marshal(&cb.SignatureHeader{Creator: creator, Nonce: nonce})
+	assert.NoError(t, err)
+	digest := sha256.Sum256(append(append([]byte{}, header...), update...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	return &cb.ConfigSignature{SignatureHeader: header, Signature: sig}
+}
+
+func TestAddSignaturesKeepsConcurrentSigners(t *testing.T) {
+	ca, caKey, caPEM := newTestOrgCert(t, "ca.org1", "", nil, nil)
+	_, admin1Key, admin1PEM := newTestOrgCert(t, "Admin@org1", "admin", ca, caKey)
+	_, admin2Key, admin2PEM := newTestOrgCert(t, "Admin2@org1", "admin", ca, caKey)
+
+	// the update adds org1, the config it results in has the MSP signatures are verified against
+	ctx := configtx.New("channel1", &cb.Config{
+		ChannelGroup: &cb.ConfigGroup{
+			Groups: map[string]*cb.ConfigGroup{
+				"Application": {Groups: map[string]*cb.ConfigGroup{}, ModPolicy: "Admins"},
+			},
+			ModPolicy: "Admins",
+		},
+	})
+	assert.NoError(t, ctx.AddApplicationOrg(configtx.Organization{
+		MSPID:     "org1MSP",
+		RootCerts: [][]byte{caPEM},
+		NodeOUs:   true,
+	}))
+	envelope, err := ctx.ComputeEnvelope()
+	assert.NoError(t, err)
+	env, err := configtx.ParseEnvelope(envelope)
+	assert.NoError(t, err)
+	ctx = configtx.New("channel1", ctx.Updated())
+
+	cu := &model.ConfigUpdate{
+		Envelope:   envelope,
+		Signatures: []model.ConfigSignature{},
+		Status:     enum.StatusPending,
+	}
+	assert.NoError(t, dao.InsertConfigUpdate(cu))
+	defer global.DB.Delete(&model.ConfigUpdate{}, cu.ID)
+
+	// two requests, each with the row as it was loaded before either signed
+	cu1, err := dao.FindConfigUpdateByID(cu.ID)
+	assert.NoError(t, err)
+	cu2, err := dao.FindConfigUpdateByID(cu.ID)
+	assert.NoError(t, err)
+
+	added, err := service.AddSignatures(service.NewConfigUpdateService(cu1), ctx,
+		[]*cb.ConfigSignature{signTestConfigUpdate(t, env.ConfigUpdate, "org1MSP", admin1PEM, admin1Key)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+	added, err = service.AddSignatures(service.NewConfigUpdateService(cu2), ctx,
+		[]*cb.ConfigSignature{signTestConfigUpdate(t, env.ConfigUpdate, "org1MSP", admin2PEM, admin2Key)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	saved, err := dao.FindConfigUpdateByID(cu.ID)
+	assert.NoError(t, err)
+	assert.Len(t, saved.Signatures, 2)
+	assert.Len(t, cu2.Signatures, 2)
+
+	// the first signer is already saved, although the stale copy doesn't have it
+	added, err = service.AddSignatures(service.NewConfigUpdateService(cu2), ctx,
+		[]*cb.ConfigSignature{signTestConfigUpdate(t, env.ConfigUpdate, "org1MSP", admin1PEM, admin1Key)})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	// no signatures once the update is claimed, whatever the copy says
+	ok, err := service.NewConfigUpdateService(saved).Claim()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, err = service.AddSignatures(service.NewConfigUpdateService(cu1), ctx,
+		[]*cb.ConfigSignature{signTestConfigUpdate(t, env.ConfigUpdate, "org1MSP", admin2PEM, admin2Key)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,264 @@
+package configtx
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	ob "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/pkg/errors"
+)
+
+// BatchParameters are the `BatchTimeout` and `BatchSize` of the Orderer section in configtx.yaml,
+// sizes are in bytes.
+type BatchParameters struct {
+	BatchTimeout      string `json:"batchTimeout"`
+	MaxMessageCount   uint32 `json:"maxMessageCount"`
+	AbsoluteMaxBytes  uint32 `json:"absoluteMaxBytes"`
+	PreferredMaxBytes uint32 `json:"preferredMaxBytes"`
+}
+
+// ChannelParameters are the capability levels and batch parameters of a channel.
+// In SetChannelParameters, nil capabilities and zero batch fields are left unchanged.
+type ChannelParameters struct {
+	ChannelCapabilities     []string
+	OrdererCapabilities     []string
+	ApplicationCapabilities []string
+	Batch                   BatchParameters
+}
+
+// capabilityVersions is the first Fabric release knowing each capability,
+// by the group which carries it. An empty group key means the channel group.
+var capabilityVersions = map[string]map[string]string{
+	"": {
+		"V1_1":   "1.1.0",
+		"V1_3":   "1.3.0",
+		"V1_4_2": "1.4.2",
+		"V1_4_3": "1.4.3",
+		"V2_0":   "2.0.0",
+		"V3_0":   "3.0.0",
+	},
+	OrdererGroupKey: {
+		"V1_1":   "1.1.0",
+		"V1_4_2": "1.4.2",
+		"V2_0":   "2.0.0",
+	},
+	ApplicationGroupKey: {
+		"V1_1":   "1.1.0",
+		"V1_2":   "1.2.0",
+		"V1_3":   "1.3.0",
+		"V1_4_2": "1.4.2",
+		"V2_0":   "2.0.0",
+		"V2_5":   "2.5.0",
+	},
+}
+
+// CapabilityMinVersion returns the first Fabric release supporting capability in group,
+// which is "", "Orderer" or "Application".
+func CapabilityMinVersion(group string, capability string) (string, error) {
+	versions, ok := capabilityVersions[group]
+	if !ok {
+		return "", errors.Errorf("group %s has no capabilities", group)
+	}
+	version, ok := versions[capability]
+	if !ok {
+		return "", errors.Errorf("unknown capability %s of %s", capability, groupName(group))
+	}
+	return version, nil
+}
+
+// CompareVersions compares two versions like "2.2.1", missing parts are 0.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func groupName(group string) string {
+	if group == "" {
+		return "Channel"
+	}
+	return group
+}
+
+// Capabilities returns the sorted capabilities of the group at path,
+// eg: Capabilities("Application"). An empty path means the channel group.
+func (c *ConfigTx) Capabilities(path ...string) ([]string, error) {
+	group, err := c.getGroup(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []string{}
+	if _, ok := group.Values[CapabilitiesKey]; !ok {
+		return ret, nil
+	}
+	capabilities := &cb.Capabilities{}
+	if err := getValue(group, CapabilitiesKey, capabilities); err != nil {
+		return nil, err
+	}
+	for name := range capabilities.Capabilities {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// SetCapabilities replaces the capabilities of the group at path.
+// Only the channel, Orderer and Application groups carry capabilities.
+func (c *ConfigTx) SetCapabilities(names []string, path ...string) error {
+	if len(path) > 1 {
+		return errors.Errorf("group %v has no capabilities", path)
+	}
+	key := ""
+	if len(path) == 1 {
+		key = path[0]
+	}
+	for _, name := range names {
+		if _, err := CapabilityMinVersion(key, name); err != nil {
+			return err
+		}
+	}
+	group, err := c.getGroup(path...)
+	if err != nil {
+		return err
+	}
+
+	capabilities := &cb.Capabilities{Capabilities: map[string]*cb.Capability{}}
+	for _, name := range names {
+		capabilities.Capabilities[name] = &cb.Capability{}
+	}
+	return setValue(group, CapabilitiesKey, capabilities, AdminsPolicyKey)
+}
+
+// BatchParameters returns the batch timeout and batch size of the orderer.
+func (c *ConfigTx) BatchParameters() (BatchParameters, error) {
+	orderer, err := c.getGroup(OrdererGroupKey)
+	if err != nil {
+		return BatchParameters{}, err
+	}
+	batchTimeout := &ob.BatchTimeout{}
+	if err := getValue(orderer, BatchTimeoutKey, batchTimeout); err != nil {
+		return BatchParameters{}, err
+	}
+	batchSize := &ob.BatchSize{}
+	if err := getValue(orderer, BatchSizeKey, batchSize); err != nil {
+		return BatchParameters{}, err
+	}
+	return BatchParameters{
+		BatchTimeout:      batchTimeout.Timeout,
+		MaxMessageCount:   batchSize.MaxMessageCount,
+		AbsoluteMaxBytes:  batchSize.AbsoluteMaxBytes,
+		PreferredMaxBytes: batchSize.PreferredMaxBytes,
+	}, nil
+}
+
+// SetBatchParameters replaces the batch timeout and batch size of the orderer,
+// with the same checks the orderer does.
+func (c *ConfigTx) SetBatchParameters(params BatchParameters) error {
+	timeout, err := time.ParseDuration(params.BatchTimeout)
+	if err != nil {
+		return errors.WithMessage(err, "invalid batch timeout")
+	}
+	if timeout <= 0 {
+		return errors.Errorf("batch timeout must be positive, got %s", params.BatchTimeout)
+	}
+	if params.MaxMessageCount == 0 {
+		return errors.New("max message count must be positive")
+	}
+	if params.AbsoluteMaxBytes == 0 {
+		return errors.New("absolute max bytes must be positive")
+	}
+	if params.PreferredMaxBytes == 0 || params.PreferredMaxBytes > params.AbsoluteMaxBytes {
+		return errors.New("preferred max bytes must be positive and not greater than absolute max bytes")
+	}
+
+	orderer, err := c.getGroup(OrdererGroupKey)
+	if err != nil {
+		return err
+	}
+	if err := setValue(orderer, BatchTimeoutKey, &ob.BatchTimeout{Timeout: params.BatchTimeout}, AdminsPolicyKey); err != nil {
+		return err
+	}
+	return setValue(orderer, BatchSizeKey, &ob.BatchSize{
+		MaxMessageCount:   params.MaxMessageCount,
+		AbsoluteMaxBytes:  params.AbsoluteMaxBytes,
+		PreferredMaxBytes: params.PreferredMaxBytes,
+	}, AdminsPolicyKey)
+}
+
+// ChannelParameters reads the capabilities of the channel, Orderer and Application groups,
+// and the batch parameters.
+func (c *ConfigTx) ChannelParameters() (*ChannelParameters, error) {
+	var err error
+	params := &ChannelParameters{}
+	if params.ChannelCapabilities, err = c.Capabilities(); err != nil {
+		return nil, err
+	}
+	if params.OrdererCapabilities, err = c.Capabilities(OrdererGroupKey); err != nil {
+		return nil, err
+	}
+	if params.ApplicationCapabilities, err = c.Capabilities(ApplicationGroupKey); err != nil {
+		return nil, err
+	}
+	if params.Batch, err = c.BatchParameters(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// SetChannelParameters applies the given capabilities and the non-zero batch fields.
+func (c *ConfigTx) SetChannelParameters(params ChannelParameters) error {
+	if params.ChannelCapabilities != nil {
+		if err := c.SetCapabilities(params.ChannelCapabilities); err != nil {
+			return err
+		}
+	}
+	if params.OrdererCapabilities != nil {
+		if err := c.SetCapabilities(params.OrdererCapabilities, OrdererGroupKey); err != nil {
+			return err
+		}
+	}
+	if params.ApplicationCapabilities != nil {
+		if err := c.SetCapabilities(params.ApplicationCapabilities, ApplicationGroupKey); err != nil {
+			return err
+		}
+	}
+
+	if params.Batch == (BatchParameters{}) {
+		return nil
+	}
+	batch, err := c.BatchParameters()
+	if err != nil {
+		return err
+	}
+	if params.Batch.BatchTimeout != "" {
+		batch.BatchTimeout = params.Batch.BatchTimeout
+	}
+	if params.Batch.MaxMessageCount != 0 {
+		batch.MaxMessageCount = params.Batch.MaxMessageCount
+	}
+	if params.Batch.AbsoluteMaxBytes != 0 {
+		batch.AbsoluteMaxBytes = params.Batch.AbsoluteMaxBytes
+	}
+	if params.Batch.PreferredMaxBytes != 0 {
+		batch.PreferredMaxBytes = params.Batch.PreferredMaxBytes
+	}
+	return c.SetBatchParameters(batch)
+}
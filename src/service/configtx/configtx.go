@@ -0,0 +1,199 @@
+// Package configtx edits channel configurations in process.
+//
+// It decodes a config block, lets the caller apply typed mutations to a copy
+// of the config, computes the ConfigUpdate delta and wraps it in an envelope
+// ready for resmgmt.SaveChannel. It replaces addorg.sh, which needed jq,
+// configtxlator and configtxgen inside the tools pod.
+//
+// ctx, _ := configtx.NewFromBlock(block)
+// _ = ctx.AddApplicationOrg(org)
+// envelope, _ := ctx.ComputeEnvelope()
+package configtx
+
+import (
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/pkg/errors"
+)
+
+// Group and value keys used in the channel config tree.
+const (
+	ApplicationGroupKey = "Application"
+	OrdererGroupKey     = "Orderer"
+	ConsortiumsGroupKey = "Consortiums"
+
+	MSPKey              = "MSP"
+	AnchorPeersKey      = "AnchorPeers"
+	EndpointsKey        = "Endpoints"
+	OrdererAddressesKey = "OrdererAddresses"
+	ConsensusTypeKey    = "ConsensusType"
+	BatchSizeKey        = "BatchSize"
+	BatchTimeoutKey     = "BatchTimeout"
+	CapabilitiesKey     = "Capabilities"
+
+	AdminsPolicyKey = "Admins"
+)
+
+type ConfigTx struct {
+	channelID string
+	sequence  uint64
+	original  *cb.Config
+	updated   *cb.Config
+}
+
+// NewFromBlockBytes decodes a marshaled config block,
+// eg: the output of ChannelService.GetChannelConfig
+func NewFromBlockBytes(bt []byte) (*ConfigTx, error) {
+	block := &cb.Block{}
+	if err := proto.Unmarshal(bt, block); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal block")
+	}
+	return NewFromBlock(block)
+}
+
+func NewFromBlock(block *cb.Block) (*ConfigTx, error) {
+	if block.Data == nil || len(block.Data.Data) == 0 {
+		return nil, errors.New("block has no data")
+	}
+
+	envelope := &cb.Envelope{}
+	if err := proto.Unmarshal(block.Data.Data[0], envelope); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal envelope")
+	}
+	payload := &cb.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal payload")
+	}
+	if payload.Header == nil {
+		return nil, errors.New("payload has no header")
+	}
+	chdr := &cb.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, chdr); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal channel header")
+	}
+	if chdr.Type != int32(cb.HeaderType_CONFIG) {
+		return nil, errors.Errorf("not a config block, header type: %d", chdr.Type)
+	}
+	configEnvelope := &cb.ConfigEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configEnvelope); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal config envelope")
+	}
+	if configEnvelope.Config == nil {
+		return nil, errors.New("config envelope has no config")
+	}
+
+	return New(chdr.ChannelId, configEnvelope.Config), nil
+}
+
+// New wraps an already decoded config. The given config is never modified.
+func New(channelID string, config *cb.Config) *ConfigTx {
+	return &ConfigTx{
+		channelID: channelID,
+		sequence:  config.Sequence,
+		original:  config,
+		updated:   proto.Clone(config).(*cb.Config),
+	}
+}
+
+func (c *ConfigTx) ChannelID() string {
+	return c.channelID
+}
+
+// Sequence returns the sequence of the config the block carried.
+// A successful update increases it by one.
+func (c *ConfigTx) Sequence() uint64 {
+	return c.sequence
+}
+
+func (c *ConfigTx) Original() *cb.Config {
+	return c.original
+}
+
+// Updated returns the config with all mutations applied so far.
+func (c *ConfigTx) Updated() *cb.Config {
+	return c.updated
+}
+
+// ComputeUpdate computes the delta between the original and the updated config.
+func (c *ConfigTx) ComputeUpdate() (*cb.ConfigUpdate, error) {
+	return computeUpdate(c.channelID, c.original, c.updated)
+}
+
+// ComputeEnvelope computes the update and wraps it in an unsigned envelope.
+// Signatures are attached by resmgmt.SaveChannel.
+func (c *ConfigTx) ComputeEnvelope() ([]byte, error) {
+	update, err := c.ComputeUpdate()
+	if err != nil {
+		return nil, err
+	}
+	return NewEnvelope(update)
+}
+
+// NewEnvelope marshals a config update into a CONFIG_UPDATE envelope.
+func NewEnvelope(update *cb.ConfigUpdate) ([]byte, error) {
+	updateBytes, err := proto.Marshal(update)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to marshal config update")
+	}
+	data, err := proto.Marshal(&cb.ConfigUpdateEnvelope{ConfigUpdate: updateBytes})
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to marshal config update envelope")
+	}
+	chdr, err := proto.Marshal(&cb.ChannelHeader{
+		Type:      int32(cb.HeaderType_CONFIG_UPDATE),
+		ChannelId: update.ChannelId,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to marshal channel header")
+	}
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: &cb.Header{ChannelHeader: chdr},
+		Data:   data,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to marshal payload")
+	}
+	return proto.Marshal(&cb.Envelope{Payload: payload})
+}
+
+// getGroup walks the updated config tree, eg: getGroup("Application", "org1MSP")
+func (c *ConfigTx) getGroup(path ...string) (*cb.ConfigGroup, error) {
+	group := c.updated.ChannelGroup
+	if group == nil {
+		return nil, errors.New("config has no channel group")
+	}
+	for i, key := range path {
+		next, ok := group.Groups[key]
+		if !ok {
+			return nil, errors.Errorf("group %v does not exist", path[:i+1])
+		}
+		group = next
+	}
+	return group, nil
+}
+
+func getValue(group *cb.ConfigGroup, key string, msg proto.Message) error {
+	value, ok := group.Values[key]
+	if !ok {
+		return errors.Errorf("value %s does not exist", key)
+	}
+	return proto.Unmarshal(value.Value, msg)
+}
+
+func setValue(group *cb.ConfigGroup, key string, msg proto.Message, modPolicy string) error {
+	bt, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.WithMessage(err, "fail to marshal "+key)
+	}
+	if group.Values == nil {
+		group.Values = make(map[string]*cb.ConfigValue)
+	}
+	// keep the version, computeUpdate bumps it
+	value, ok := group.Values[key]
+	if !ok {
+		value = &cb.ConfigValue{ModPolicy: modPolicy}
+		group.Values[key] = value
+	}
+	value.Value = bt
+	return nil
+}
@@ -0,0 +1,358 @@
+package configtx_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	ob "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"mictract/service/configtx"
+	"testing"
+	"time"
+)
+
+func newTestConfig() *cb.Config {
+	config := &cb.Config{
+		Sequence: 3,
+		ChannelGroup: &cb.ConfigGroup{
+			Groups: map[string]*cb.ConfigGroup{
+				"Application": {
+					Groups: map[string]*cb.ConfigGroup{
+						"org1MSP": {ModPolicy: "Admins"},
+					},
+					ModPolicy: "Admins",
+				},
+				"Orderer": {
+					Groups: map[string]*cb.ConfigGroup{
+						"ordererMSP": {ModPolicy: "Admins"},
+					},
+					ModPolicy: "Admins",
+				},
+			},
+			ModPolicy: "Admins",
+		},
+	}
+
+	metadata, _ := proto.Marshal(&etcdraft.ConfigMetadata{
+		Consenters: []*etcdraft.Consenter{{Host: "orderer1-net1", Port: 7050}},
+	})
+	consensusType, _ := proto.Marshal(&ob.ConsensusType{Type: "etcdraft", Metadata: metadata})
+	config.ChannelGroup.Groups["Orderer"].Values = map[string]*cb.ConfigValue{
+		"ConsensusType": {Value: consensusType, ModPolicy: "Admins"},
+	}
+	addresses, _ := proto.Marshal(&cb.OrdererAddresses{Addresses: []string{"orderer1-net1:7050"}})
+	config.ChannelGroup.Values = map[string]*cb.ConfigValue{
+		"OrdererAddresses": {Value: addresses, ModPolicy: "/Channel/Orderer/Admins"},
+	}
+	return config
+}
+
+func TestConfigTxAddApplicationOrg(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+
+	err := ctx.AddApplicationOrg(configtx.Organization{
+		MSPID:        "org2MSP",
+		RootCerts:    [][]byte{[]byte("cacert")},
+		TLSRootCerts: [][]byte{[]byte("tlscacert")},
+		NodeOUs:      true,
+		Policies: map[string]configtx.Policy{
+			"Admins": {Type: configtx.SignaturePolicyType, Rule: "OR('org2MSP.admin')"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Error(t, ctx.AddApplicationOrg(configtx.Organization{MSPID: "org2MSP"}))
+
+	update, err := ctx.ComputeUpdate()
+	assert.NoError(t, err)
+	assert.Equal(t, "channel1", update.ChannelId)
+	application := update.WriteSet.Groups["Application"]
+	assert.Equal(t, uint64(1), application.Version)
+	assert.Contains(t, application.Groups, "org1MSP")
+	assert.Contains(t, application.Groups["org2MSP"].Values, "MSP")
+	assert.NotContains(t, update.WriteSet.Groups, "Orderer")
+
+	policies, err := ctx.Policies("Application", "org2MSP")
+	assert.NoError(t, err)
+	assert.Equal(t, "OR('org2MSP.admin')", policies["Admins"].Rule)
+}
+
+func TestConfigTxSetAnchorPeers(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+
+	anchors := []configtx.Address{{Host: "peer1-org1-net1", Port: 7051}}
+	assert.NoError(t, ctx.SetAnchorPeers("org1MSP", anchors))
+	got, err := ctx.AnchorPeers("org1MSP")
+	assert.NoError(t, err)
+	assert.Equal(t, anchors, got)
+
+	update, err := ctx.ComputeUpdate()
+	assert.NoError(t, err)
+	org := update.WriteSet.Groups["Application"].Groups["org1MSP"]
+	assert.Equal(t, uint64(1), org.Version)
+	assert.Equal(t, uint64(0), update.WriteSet.Groups["Application"].Version)
+}
+
+func TestConfigTxAddConsenter(t *testing.T) {
+	ctx := configtx.New("system-channel", newTestConfig())
+
+	consenter := configtx.Consenter{Host: "orderer2-net1", Port: 7050}
+	assert.NoError(t, ctx.AddConsenter(consenter))
+	assert.Error(t, ctx.AddConsenter(consenter))
+	assert.NoError(t, ctx.SetOrdererAddresses([]string{"orderer1-net1:7050", "orderer2-net1:7050"}))
+
+	consenters, err := ctx.Consenters()
+	assert.NoError(t, err)
+	assert.Len(t, consenters, 2)
+
+	envelope, err := ctx.ComputeEnvelope()
+	assert.NoError(t, err)
+
+	env := &cb.Envelope{}
+	payload := &cb.Payload{}
+	chdr := &cb.ChannelHeader{}
+	assert.NoError(t, proto.Unmarshal(envelope, env))
+	assert.NoError(t, proto.Unmarshal(env.Payload, payload))
+	assert.NoError(t, proto.Unmarshal(payload.Header.ChannelHeader, chdr))
+	assert.Equal(t, int32(cb.HeaderType_CONFIG_UPDATE), chdr.Type)
+	assert.Equal(t, "system-channel", chdr.ChannelId)
+}
+
+func TestConfigTxRemoveConsenter(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+
+	assert.NoError(t, ctx.AddConsenter(configtx.Consenter{Host: "orderer2-net1", Port: 7050}))
+	assert.NoError(t, ctx.RemoveConsenter("orderer1-net1", 7050))
+	assert.Error(t, ctx.RemoveConsenter("orderer1-net1", 7050))
+	// the last consenter must stay
+	assert.Error(t, ctx.RemoveConsenter("orderer2-net1", 7050))
+
+	consenters, err := ctx.Consenters()
+	assert.NoError(t, err)
+	assert.Len(t, consenters, 1)
+	assert.Equal(t, "orderer2-net1:7050", consenters[0].Address())
+}
+
+func TestConfigTxPolicyTree(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+
+	writers := configtx.Policy{Type: configtx.SignaturePolicyType, Rule: "OR('org1MSP.admin', 'org1MSP.client')"}
+	assert.NoError(t, ctx.SetPolicy("Writers", writers, "Application", "org1MSP"))
+	assert.Error(t, ctx.SetPolicy("Writers", configtx.Policy{Type: "Unknown", Rule: "ANY Writers"}, "Application"))
+	assert.Error(t, ctx.SetPolicy("Writers", writers, "Application", "org2MSP"))
+
+	tree, err := ctx.PolicyTree()
+	assert.NoError(t, err)
+	assert.Empty(t, tree.Path)
+	assert.Len(t, tree.Groups, 2)
+	application := tree.Groups[0]
+	assert.Equal(t, "Application", application.Name)
+	assert.Equal(t, []string{"Application", "org1MSP"}, application.Groups[0].Path)
+	assert.Equal(t, writers, application.Groups[0].Policies["Writers"])
+
+	update, err := ctx.ComputeUpdate()
+	assert.NoError(t, err)
+	assert.Contains(t, update.WriteSet.Groups["Application"].Groups["org1MSP"].Policies, "Writers")
+}
+
+func TestConfigTxNoChange(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+	_, err := ctx.ComputeUpdate()
+	assert.Error(t, err)
+}
+
+func TestConfigTxUnsatisfiedPolicies(t *testing.T) {
+	base := configtx.New("channel1", newTestConfig())
+	assert.NoError(t, base.SetPolicy("Admins", configtx.Policy{Type: configtx.ImplicitMetaPolicyType, Rule: "MAJORITY Admins"}, "Application"))
+	assert.NoError(t, base.SetPolicy("Admins", configtx.Policy{Type: configtx.SignaturePolicyType, Rule: "OR('org1MSP.admin')"}, "Application", "org1MSP"))
+
+	ctx := configtx.New("channel1", base.Updated())
+	writers := configtx.Policy{Type: configtx.SignaturePolicyType, Rule: "OR('org1MSP.member')"}
+	assert.NoError(t, ctx.SetPolicy("Writers", writers, "Application", "org1MSP"))
+
+	envelope, err := ctx.ComputeEnvelope()
+	assert.NoError(t, err)
+	configUpdateEnvelope, err := configtx.ParseEnvelope(envelope)
+	assert.NoError(t, err)
+	assert.Empty(t, configUpdateEnvelope.Signatures)
+	update := &cb.ConfigUpdate{}
+	assert.NoError(t, proto.Unmarshal(configUpdateEnvelope.ConfigUpdate, update))
+
+	unsatisfied, err := ctx.UnsatisfiedPolicies(update, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/Channel/Application/org1MSP/Admins"}, unsatisfied)
+
+	unsatisfied, err = ctx.UnsatisfiedPolicies(update, []string{"ordererMSP"})
+	assert.NoError(t, err)
+	assert.Len(t, unsatisfied, 1)
+
+	unsatisfied, err = ctx.UnsatisfiedPolicies(update, []string{"org1MSP"})
+	assert.NoError(t, err)
+	assert.Empty(t, unsatisfied)
+}
+
+func TestConfigTxRemoveApplicationOrg(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+	endorsement := configtx.Policy{Type: configtx.SignaturePolicyType, Rule: "OR('org1MSP.peer')"}
+	assert.NoError(t, ctx.SetPolicy("Endorsement", endorsement, "Application"))
+
+	names, err := ctx.PoliciesReferencing("org1MSP", "Application")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Endorsement"}, names)
+	names, err = ctx.PoliciesReferencing("org11MSP", "Application")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	assert.NoError(t, ctx.RemoveApplicationOrg("org1MSP"))
+	assert.Error(t, ctx.RemoveApplicationOrg("org1MSP"))
+	orgs, err := ctx.ApplicationOrgs()
+	assert.NoError(t, err)
+	assert.Empty(t, orgs)
+
+	update, err := ctx.ComputeUpdate()
+	assert.NoError(t, err)
+	assert.NotContains(t, update.WriteSet.Groups["Application"].Groups, "org1MSP")
+}
+
+func TestConfigTxChannelParameters(t *testing.T) {
+	config := newTestConfig()
+	batchTimeout, _ := proto.Marshal(&ob.BatchTimeout{Timeout: "2s"})
+	batchSize, _ := proto.Marshal(&ob.BatchSize{MaxMessageCount: 10, AbsoluteMaxBytes: 99 * 1024 * 1024, PreferredMaxBytes: 512 * 1024})
+	config.ChannelGroup.Groups["Orderer"].Values["BatchTimeout"] = &cb.ConfigValue{Value: batchTimeout, ModPolicy: "Admins"}
+	config.ChannelGroup.Groups["Orderer"].Values["BatchSize"] = &cb.ConfigValue{Value: batchSize, ModPolicy: "Admins"}
+	ctx := configtx.New("channel1", config)
+
+	params, err := ctx.ChannelParameters()
+	assert.NoError(t, err)
+	assert.Empty(t, params.ApplicationCapabilities)
+	assert.Equal(t, "2s", params.Batch.BatchTimeout)
+
+	assert.Error(t, ctx.SetChannelParameters(configtx.ChannelParameters{ApplicationCapabilities: []string{"V9_9"}}))
+	assert.Error(t, ctx.SetChannelParameters(configtx.ChannelParameters{Batch: configtx.BatchParameters{PreferredMaxBytes: 100 * 1024 * 1024}}))
+	assert.NoError(t, ctx.SetChannelParameters(configtx.ChannelParameters{
+		ApplicationCapabilities: []string{"V2_0"},
+		Batch:                   configtx.BatchParameters{BatchTimeout: "500ms"},
+	}))
+
+	params, err = ctx.ChannelParameters()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"V2_0"}, params.ApplicationCapabilities)
+	assert.Equal(t, "500ms", params.Batch.BatchTimeout)
+	assert.Equal(t, uint32(10), params.Batch.MaxMessageCount)
+
+	version, err := configtx.CapabilityMinVersion("Application", "V2_5")
+	assert.NoError(t, err)
+	assert.Equal(t, -1, configtx.CompareVersions("2.2.1", version))
+	assert.Equal(t, 0, configtx.CompareVersions("2.0", "2.0.0"))
+}
+
+func TestConfigTxSetRevocationList(t *testing.T) {
+	ctx := configtx.New("channel1", newTestConfig())
+	assert.NoError(t, ctx.AddApplicationOrg(configtx.Organization{
+		MSPID:     "org2MSP",
+		RootCerts: [][]byte{[]byte("cacert")},
+	}))
+	ctx = configtx.New("channel1", ctx.Updated())
+
+	assert.Error(t, ctx.SetRevocationList([][]byte{[]byte("crl")}, "Application", "org3MSP"))
+	assert.NoError(t, ctx.SetRevocationList([][]byte{[]byte("crl")}, "Application", "org2MSP"))
+
+	update, err := ctx.ComputeUpdate()
+	assert.NoError(t, err)
+	value := update.WriteSet.Groups["Application"].Groups["org2MSP"].Values["MSP"]
+	assert.Equal(t, uint64(1), value.Version)
+	mspConfig := &mb.MSPConfig{}
+	assert.NoError(t, proto.Unmarshal(value.Value, mspConfig))
+	fabricMSPConfig := &mb.FabricMSPConfig{}
+	assert.NoError(t, proto.Unmarshal(mspConfig.Config, fabricMSPConfig))
+	assert.Equal(t, "org2MSP", fabricMSPConfig.Name)
+	assert.Equal(t, [][]byte{[]byte("crl")}, fabricMSPConfig.RevocationList)
+}
+
+// newTestCert issues a certificate with ou, self-signed if parent is nil
+func newTestCert(t *testing.T, cn, ou string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn, OrganizationalUnit: []string{ou}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestConfigSignature(t *testing.T, update []byte, mspID string, certPEM []byte, key *ecdsa.PrivateKey) *cb.ConfigSignature {
+	creator, err := proto.Marshal(&mb.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+	assert.NoError(t, err)
+	nonce := make([]byte, 24)
+	_, err = rand.Read(nonce)
+	assert.NoError(t, err)
+	header, err := proto.Marshal(&cb.SignatureHeader{Creator: creator, Nonce: nonce})
+	assert.NoError(t, err)
+	digest := sha256.Sum256(append(append([]byte{}, header...), update...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	return &cb.ConfigSignature{SignatureHeader: header, Signature: sig}
+}
+
+func TestConfigTxVerifyAdminSignature(t *testing.T) {
+	ca, caKey, caPEM := newTestCert(t, "ca.org2", "", nil, nil)
+	_, adminKey, adminPEM := newTestCert(t, "Admin@org2", "admin", ca, caKey)
+	_, clientKey, clientPEM := newTestCert(t, "User1@org2", "client", ca, caKey)
+	_, fakeKey, fakePEM := newTestCert(t, "Admin@org2", "admin", nil, nil)
+	revoked, revokedKey, revokedPEM := newTestCert(t, "Admin2@org2", "admin", ca, caKey)
+	crl, err := ca.CreateCRL(rand.Reader, caKey, []pkix.RevokedCertificate{
+		{SerialNumber: revoked.SerialNumber, RevocationTime: time.Now()},
+	}, time.Now(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	ctx := configtx.New("channel1", newTestConfig())
+	assert.NoError(t, ctx.AddApplicationOrg(configtx.Organization{
+		MSPID:          "org2MSP",
+		RootCerts:      [][]byte{caPEM},
+		NodeOUs:        true,
+		RevocationList: [][]byte{pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl})},
+	}))
+	ctx = configtx.New("channel1", ctx.Updated())
+	update := []byte("config update")
+
+	mspID, creator, err := ctx.VerifyAdminSignature(update, newTestConfigSignature(t, update, "org2MSP", adminPEM, adminKey))
+	assert.NoError(t, err)
+	assert.Equal(t, "org2MSP", mspID)
+	// signatures are randomized, the creator is the same
+	_, again, err := ctx.VerifyAdminSignature(update, newTestConfigSignature(t, update, "org2MSP", adminPEM, adminKey))
+	assert.NoError(t, err)
+	assert.Equal(t, creator, again)
+
+	_, _, err = ctx.VerifyAdminSignature([]byte("another update"), newTestConfigSignature(t, update, "org2MSP", adminPEM, adminKey))
+	assert.Error(t, err)
+	_, _, err = ctx.VerifyAdminSignature(update, newTestConfigSignature(t, update, "org2MSP", clientPEM, clientKey))
+	assert.Error(t, err)
+	_, _, err = ctx.VerifyAdminSignature(update, newTestConfigSignature(t, update, "org2MSP", fakePEM, fakeKey))
+	assert.Error(t, err)
+	_, _, err = ctx.VerifyAdminSignature(update, newTestConfigSignature(t, update, "org2MSP", revokedPEM, revokedKey))
+	assert.Error(t, err)
+	_, _, err = ctx.VerifyAdminSignature(update, newTestConfigSignature(t, update, "org3MSP", adminPEM, adminKey))
+	assert.Error(t, err)
+}
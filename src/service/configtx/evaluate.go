@@ -0,0 +1,195 @@
+package configtx
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+)
+
+const rootGroupKey = "Channel"
+
+// UnsatisfiedPolicies returns the mod policies, eg: "/Channel/Application/Admins",
+// of the elements modified by update which the signatures of mspIDs do not satisfy yet.
+// Like the orderer, policies are evaluated against the original config.
+// mspIDs are of the signers verified by VerifyAdminSignature, one for each signer.
+func (c *ConfigTx) UnsatisfiedPolicies(update *cb.ConfigUpdate, mspIDs []string) ([]string, error) {
+	if c.original.ChannelGroup == nil {
+		return nil, errors.New("config has no channel group")
+	}
+	if update.WriteSet == nil {
+		return nil, errors.New("config update has no write set")
+	}
+
+	refs := map[string]policyRef{}
+	collectModPolicies(update.WriteSet, c.original.ChannelGroup, []string{}, refs)
+
+	e := &evaluator{root: c.original.ChannelGroup, signers: mspIDs}
+	ret := []string{}
+	for key, ref := range refs {
+		ok, err := e.evaluate(ref.path, ref.name)
+		if err != nil {
+			return nil, errors.WithMessage(err, "fail to evaluate "+key)
+		}
+		if !ok {
+			ret = append(ret, key)
+		}
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// policyRef locates a policy, path is the group containing it
+type policyRef struct {
+	path []string
+	name string
+}
+
+// newPolicyRef resolves modPolicy of an element in the group at path,
+// which is either relative to the group, eg: "Admins", or absolute, eg: "/Channel/Orderer/Admins".
+func newPolicyRef(path []string, modPolicy string) (string, policyRef) {
+	ref := policyRef{path: path, name: modPolicy}
+	if strings.HasPrefix(modPolicy, "/") {
+		fields := strings.Split(strings.TrimPrefix(modPolicy, "/"), "/")
+		// fields[0] is "Channel"
+		ref = policyRef{path: fields[1 : len(fields)-1], name: fields[len(fields)-1]}
+	}
+	key := "/" + strings.Join(append(append([]string{rootGroupKey}, ref.path...), ref.name), "/")
+	return key, ref
+}
+
+// collectModPolicies walks the write set along with the original config.
+// Elements whose version is bumped need their original mod policy,
+// new elements need nothing by themselves, their parent group is bumped as well.
+func collectModPolicies(write, original *cb.ConfigGroup, path []string, refs map[string]policyRef) {
+	if original == nil {
+		return
+	}
+	add := func(modPolicy string) {
+		key, ref := newPolicyRef(path, modPolicy)
+		refs[key] = ref
+	}
+
+	if write.Version > original.Version {
+		add(original.ModPolicy)
+	}
+	for key, value := range write.Values {
+		if old, ok := original.Values[key]; ok && value.Version > old.Version {
+			add(old.ModPolicy)
+		}
+	}
+	for key, policy := range write.Policies {
+		if old, ok := original.Policies[key]; ok && policy.Version > old.Version {
+			add(old.ModPolicy)
+		}
+	}
+	for key, group := range write.Groups {
+		collectModPolicies(group, original.Groups[key], append(append([]string{}, path...), key), refs)
+	}
+}
+
+type evaluator struct {
+	root    *cb.ConfigGroup
+	signers []string
+}
+
+func (e *evaluator) evaluate(path []string, name string) (bool, error) {
+	group := e.root
+	for i, key := range path {
+		next, ok := group.Groups[key]
+		if !ok {
+			return false, errors.Errorf("group %v does not exist", path[:i+1])
+		}
+		group = next
+	}
+	configPolicy, ok := group.Policies[name]
+	if !ok || configPolicy.Policy == nil {
+		// the orderer rejects with a missing policy
+		return false, nil
+	}
+
+	switch cb.Policy_PolicyType(configPolicy.Policy.Type) {
+	case cb.Policy_IMPLICIT_META:
+		imp := &cb.ImplicitMetaPolicy{}
+		if err := proto.Unmarshal(configPolicy.Policy.Value, imp); err != nil {
+			return false, err
+		}
+		satisfied := 0
+		for key := range group.Groups {
+			ok, err := e.evaluate(append(append([]string{}, path...), key), imp.SubPolicy)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				satisfied++
+			}
+		}
+		threshold := len(group.Groups)
+		switch imp.Rule {
+		case cb.ImplicitMetaPolicy_ANY:
+			threshold = 1
+		case cb.ImplicitMetaPolicy_MAJORITY:
+			threshold = len(group.Groups)/2 + 1
+		}
+		return satisfied >= threshold, nil
+	case cb.Policy_SIGNATURE:
+		envelope := &cb.SignaturePolicyEnvelope{}
+		if err := proto.Unmarshal(configPolicy.Policy.Value, envelope); err != nil {
+			return false, err
+		}
+		used := make([]bool, len(e.signers))
+		return e.evaluateSignature(envelope.Rule, envelope.Identities, used)
+	default:
+		return false, errors.Errorf("unsupported policy type: %d", configPolicy.Policy.Type)
+	}
+}
+
+// evaluateSignature consumes one signer for every principal it satisfies,
+// the same signature can't count twice.
+func (e *evaluator) evaluateSignature(policy *cb.SignaturePolicy, identities []*mb.MSPPrincipal, used []bool) (bool, error) {
+	switch t := policy.Type.(type) {
+	case *cb.SignaturePolicy_SignedBy:
+		if int(t.SignedBy) >= len(identities) {
+			return false, errors.Errorf("identity index %d out of range", t.SignedBy)
+		}
+		principal := identities[t.SignedBy]
+		if principal.PrincipalClassification != mb.MSPPrincipal_ROLE {
+			return false, errors.Errorf("unsupported principal classification: %s", principal.PrincipalClassification)
+		}
+		role := &mb.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return false, err
+		}
+		// an admin is a member as well, but not a peer, client or orderer
+		if role.Role != mb.MSPRole_ADMIN && role.Role != mb.MSPRole_MEMBER {
+			return false, nil
+		}
+		for i, mspID := range e.signers {
+			if !used[i] && mspID == role.MspIdentifier {
+				used[i] = true
+				return true, nil
+			}
+		}
+		return false, nil
+	case *cb.SignaturePolicy_NOutOf_:
+		satisfied := 0
+		for _, rule := range t.NOutOf.Rules {
+			// signers are only consumed by the rules which are satisfied
+			tmp := append([]bool{}, used...)
+			ok, err := e.evaluateSignature(rule, identities, tmp)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				copy(used, tmp)
+				satisfied++
+			}
+		}
+		return satisfied >= int(t.NOutOf.N), nil
+	default:
+		return false, errors.New("unknown signature policy type")
+	}
+}
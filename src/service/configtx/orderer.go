@@ -0,0 +1,185 @@
+package configtx
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	ob "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
+	"github.com/pkg/errors"
+)
+
+// Consenter is a raft consenter, certificates are PEM encoded.
+type Consenter struct {
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	ClientTLSCert []byte `json:"client_tls_cert"`
+	ServerTLSCert []byte `json:"server_tls_cert"`
+}
+
+func (c Consenter) Address() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// ConsensusType returns the orderer type, eg: "etcdraft", "solo"
+func (c *ConfigTx) ConsensusType() (string, error) {
+	consensusType, err := c.getConsensusType()
+	if err != nil {
+		return "", err
+	}
+	return consensusType.Type, nil
+}
+
+// Consenters returns the raft consenter set.
+func (c *ConfigTx) Consenters() ([]Consenter, error) {
+	metadata, err := c.getRaftMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	consenters := []Consenter{}
+	for _, consenter := range metadata.Consenters {
+		consenters = append(consenters, Consenter{
+			Host:          consenter.Host,
+			Port:          int(consenter.Port),
+			ClientTLSCert: consenter.ClientTlsCert,
+			ServerTLSCert: consenter.ServerTlsCert,
+		})
+	}
+	return consenters, nil
+}
+
+// AddConsenter appends a consenter to the raft consenter set.
+func (c *ConfigTx) AddConsenter(consenter Consenter) error {
+	metadata, err := c.getRaftMetadata()
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range metadata.Consenters {
+		if existing.Host == consenter.Host && int(existing.Port) == consenter.Port {
+			return errors.Errorf("consenter %s already exists", consenter.Address())
+		}
+	}
+	metadata.Consenters = append(metadata.Consenters, &etcdraft.Consenter{
+		Host:          consenter.Host,
+		Port:          uint32(consenter.Port),
+		ClientTlsCert: consenter.ClientTLSCert,
+		ServerTlsCert: consenter.ServerTLSCert,
+	})
+	return c.setRaftMetadata(metadata)
+}
+
+// RemoveConsenter removes the consenter listening on host:port.
+func (c *ConfigTx) RemoveConsenter(host string, port int) error {
+	metadata, err := c.getRaftMetadata()
+	if err != nil {
+		return err
+	}
+
+	consenters := []*etcdraft.Consenter{}
+	for _, existing := range metadata.Consenters {
+		if existing.Host == host && int(existing.Port) == port {
+			continue
+		}
+		consenters = append(consenters, existing)
+	}
+	if len(consenters) == len(metadata.Consenters) {
+		return errors.Errorf("consenter %s:%d does not exist", host, port)
+	}
+	if len(consenters) == 0 {
+		return errors.New("can not remove the last consenter")
+	}
+	metadata.Consenters = consenters
+	return c.setRaftMetadata(metadata)
+}
+
+// OrdererAddresses returns the channel level orderer addresses.
+func (c *ConfigTx) OrdererAddresses() ([]string, error) {
+	addresses := &cb.OrdererAddresses{}
+	if err := getValue(c.updated.ChannelGroup, OrdererAddressesKey, addresses); err != nil {
+		return nil, err
+	}
+	return addresses.Addresses, nil
+}
+
+// SetOrdererAddresses replaces the channel level orderer addresses.
+func (c *ConfigTx) SetOrdererAddresses(addresses []string) error {
+	if len(addresses) == 0 {
+		return errors.New("orderer addresses can not be empty")
+	}
+	return setValue(c.updated.ChannelGroup, OrdererAddressesKey,
+		&cb.OrdererAddresses{Addresses: addresses}, "/Channel/Orderer/Admins")
+}
+
+// OrdererEndpoints returns the endpoints of an orderer org.
+func (c *ConfigTx) OrdererEndpoints(mspID string) ([]string, error) {
+	group, err := c.getGroup(OrdererGroupKey, mspID)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := &cb.OrdererAddresses{}
+	if _, ok := group.Values[EndpointsKey]; !ok {
+		return []string{}, nil
+	}
+	if err := getValue(group, EndpointsKey, endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints.Addresses, nil
+}
+
+// SetOrdererEndpoints replaces the endpoints of an orderer org.
+func (c *ConfigTx) SetOrdererEndpoints(mspID string, endpoints []string) error {
+	group, err := c.getGroup(OrdererGroupKey, mspID)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		delete(group.Values, EndpointsKey)
+		return nil
+	}
+	return setValue(group, EndpointsKey, &cb.OrdererAddresses{Addresses: endpoints}, AdminsPolicyKey)
+}
+
+func (c *ConfigTx) getConsensusType() (*ob.ConsensusType, error) {
+	orderer, err := c.getGroup(OrdererGroupKey)
+	if err != nil {
+		return nil, err
+	}
+	consensusType := &ob.ConsensusType{}
+	if err := getValue(orderer, ConsensusTypeKey, consensusType); err != nil {
+		return nil, err
+	}
+	return consensusType, nil
+}
+
+func (c *ConfigTx) getRaftMetadata() (*etcdraft.ConfigMetadata, error) {
+	consensusType, err := c.getConsensusType()
+	if err != nil {
+		return nil, err
+	}
+	if consensusType.Type != "etcdraft" {
+		return nil, errors.Errorf("consensus type is %s, not etcdraft", consensusType.Type)
+	}
+	metadata := &etcdraft.ConfigMetadata{}
+	if err := proto.Unmarshal(consensusType.Metadata, metadata); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal etcdraft metadata")
+	}
+	return metadata, nil
+}
+
+func (c *ConfigTx) setRaftMetadata(metadata *etcdraft.ConfigMetadata) error {
+	consensusType, err := c.getConsensusType()
+	if err != nil {
+		return err
+	}
+	bt, err := proto.Marshal(metadata)
+	if err != nil {
+		return errors.WithMessage(err, "fail to marshal etcdraft metadata")
+	}
+	consensusType.Metadata = bt
+
+	orderer, _ := c.getGroup(OrdererGroupKey)
+	return setValue(orderer, ConsensusTypeKey, consensusType, AdminsPolicyKey)
+}
@@ -0,0 +1,229 @@
+package configtx
+
+import (
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// Organization is the equivalent of an `Organizations` entry in configtx.yaml.
+// Certificates are PEM encoded.
+type Organization struct {
+	MSPID        string
+	RootCerts    [][]byte
+	TLSRootCerts [][]byte
+	// NodeOUs enables client/peer/admin/orderer OU classification
+	// against the first root cert, like the config.yaml in our MSP dirs.
+	NodeOUs  bool
+	Policies map[string]Policy
+	// RevocationList are the PEM encoded CRLs of the `crls` MSP folder
+	RevocationList [][]byte
+}
+
+type Address struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// AddApplicationOrg adds org to the Application group of a channel.
+func (c *ConfigTx) AddApplicationOrg(org Organization) error {
+	application, err := c.getGroup(ApplicationGroupKey)
+	if err != nil {
+		return err
+	}
+	return addOrgGroup(application, org)
+}
+
+// AddConsortiumOrg adds org to a consortium of the system channel.
+func (c *ConfigTx) AddConsortiumOrg(consortium string, org Organization) error {
+	group, err := c.getGroup(ConsortiumsGroupKey, consortium)
+	if err != nil {
+		return err
+	}
+	return addOrgGroup(group, org)
+}
+
+// RemoveApplicationOrg removes org from the Application group of a channel.
+func (c *ConfigTx) RemoveApplicationOrg(mspID string) error {
+	application, err := c.getGroup(ApplicationGroupKey)
+	if err != nil {
+		return err
+	}
+	return removeOrgGroup(application, mspID)
+}
+
+// RemoveConsortiumOrg removes org from a consortium of the system channel.
+// Channels created before keep the organization.
+func (c *ConfigTx) RemoveConsortiumOrg(consortium string, mspID string) error {
+	group, err := c.getGroup(ConsortiumsGroupKey, consortium)
+	if err != nil {
+		return err
+	}
+	return removeOrgGroup(group, mspID)
+}
+
+// ApplicationOrgs returns the MSP IDs of all the organizations in the channel.
+func (c *ConfigTx) ApplicationOrgs() ([]string, error) {
+	application, err := c.getGroup(ApplicationGroupKey)
+	if err != nil {
+		return nil, err
+	}
+	mspIDs := []string{}
+	for mspID := range application.Groups {
+		mspIDs = append(mspIDs, mspID)
+	}
+	return mspIDs, nil
+}
+
+// AnchorPeers returns the anchor peers of an application org.
+func (c *ConfigTx) AnchorPeers(mspID string) ([]Address, error) {
+	group, err := c.getGroup(ApplicationGroupKey, mspID)
+	if err != nil {
+		return nil, err
+	}
+
+	anchors := []Address{}
+	if _, ok := group.Values[AnchorPeersKey]; !ok {
+		return anchors, nil
+	}
+	anchorPeers := &pb.AnchorPeers{}
+	if err := getValue(group, AnchorPeersKey, anchorPeers); err != nil {
+		return nil, err
+	}
+	for _, anchor := range anchorPeers.AnchorPeers {
+		anchors = append(anchors, Address{Host: anchor.Host, Port: int(anchor.Port)})
+	}
+	return anchors, nil
+}
+
+// SetAnchorPeers replaces the anchor peers of an application org.
+// An empty list removes the AnchorPeers value.
+func (c *ConfigTx) SetAnchorPeers(mspID string, anchors []Address) error {
+	group, err := c.getGroup(ApplicationGroupKey, mspID)
+	if err != nil {
+		return err
+	}
+
+	if len(anchors) == 0 {
+		delete(group.Values, AnchorPeersKey)
+		return nil
+	}
+	anchorPeers := &pb.AnchorPeers{}
+	for _, anchor := range anchors {
+		anchorPeers.AnchorPeers = append(anchorPeers.AnchorPeers, &pb.AnchorPeer{
+			Host: anchor.Host,
+			Port: int32(anchor.Port),
+		})
+	}
+	return setValue(group, AnchorPeersKey, anchorPeers, AdminsPolicyKey)
+}
+
+// SetRevocationList replaces the CRLs in the MSP of the org group at path,
+// eg: SetRevocationList(crls, "Application", "org1MSP").
+func (c *ConfigTx) SetRevocationList(crls [][]byte, path ...string) error {
+	group, err := c.getGroup(path...)
+	if err != nil {
+		return err
+	}
+
+	mspConfig := &mb.MSPConfig{}
+	if err := getValue(group, MSPKey, mspConfig); err != nil {
+		return err
+	}
+	fabricMSPConfig := &mb.FabricMSPConfig{}
+	if err := proto.Unmarshal(mspConfig.Config, fabricMSPConfig); err != nil {
+		return errors.WithMessage(err, "fail to unmarshal msp config")
+	}
+	fabricMSPConfig.RevocationList = crls
+	bt, err := proto.Marshal(fabricMSPConfig)
+	if err != nil {
+		return errors.WithMessage(err, "fail to marshal msp config")
+	}
+	mspConfig.Config = bt
+	return setValue(group, MSPKey, mspConfig, AdminsPolicyKey)
+}
+
+func addOrgGroup(parent *cb.ConfigGroup, org Organization) error {
+	if _, ok := parent.Groups[org.MSPID]; ok {
+		return errors.Errorf("organization %s already exists", org.MSPID)
+	}
+	group, err := newOrgGroup(org)
+	if err != nil {
+		return err
+	}
+	if parent.Groups == nil {
+		parent.Groups = make(map[string]*cb.ConfigGroup)
+	}
+	parent.Groups[org.MSPID] = group
+	return nil
+}
+
+func removeOrgGroup(parent *cb.ConfigGroup, mspID string) error {
+	if _, ok := parent.Groups[mspID]; !ok {
+		return errors.Errorf("organization %s does not exist", mspID)
+	}
+	delete(parent.Groups, mspID)
+	return nil
+}
+
+// newOrgGroup builds the same group `configtxgen -printOrg` prints.
+func newOrgGroup(org Organization) (*cb.ConfigGroup, error) {
+	if org.MSPID == "" {
+		return nil, errors.New("organization has no MSP ID")
+	}
+	if len(org.RootCerts) == 0 {
+		return nil, errors.Errorf("organization %s has no root cert", org.MSPID)
+	}
+
+	group := newConfigGroup()
+	group.ModPolicy = AdminsPolicyKey
+
+	for name, policy := range org.Policies {
+		if err := setPolicy(group, name, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	mspConfig, err := newMSPConfig(org)
+	if err != nil {
+		return nil, err
+	}
+	if err := setValue(group, MSPKey, mspConfig, AdminsPolicyKey); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func newMSPConfig(org Organization) (*mb.MSPConfig, error) {
+	fabricMSPConfig := &mb.FabricMSPConfig{
+		Name:           org.MSPID,
+		RootCerts:      org.RootCerts,
+		TlsRootCerts:   org.TLSRootCerts,
+		RevocationList: org.RevocationList,
+		CryptoConfig: &mb.FabricCryptoConfig{
+			SignatureHashFamily:            "SHA2",
+			IdentityIdentifierHashFunction: "SHA256",
+		},
+	}
+	if org.NodeOUs {
+		cert := org.RootCerts[0]
+		fabricMSPConfig.FabricNodeOus = &mb.FabricNodeOUs{
+			Enable:              true,
+			ClientOuIdentifier:  &mb.FabricOUIdentifier{Certificate: cert, OrganizationalUnitIdentifier: "client"},
+			PeerOuIdentifier:    &mb.FabricOUIdentifier{Certificate: cert, OrganizationalUnitIdentifier: "peer"},
+			AdminOuIdentifier:   &mb.FabricOUIdentifier{Certificate: cert, OrganizationalUnitIdentifier: "admin"},
+			OrdererOuIdentifier: &mb.FabricOUIdentifier{Certificate: cert, OrganizationalUnitIdentifier: "orderer"},
+		}
+	}
+
+	bt, err := proto.Marshal(fabricMSPConfig)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fail to marshal msp config")
+	}
+	return &mb.MSPConfig{
+		Type:   0,
+		Config: bt,
+	}, nil
+}
@@ -0,0 +1,265 @@
+package configtx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/policydsl"
+	"github.com/pkg/errors"
+)
+
+const (
+	ImplicitMetaPolicyType = "ImplicitMeta"
+	SignaturePolicyType    = "Signature"
+)
+
+// Policy is written the same way as in configtx.yaml, eg:
+// Policy{Type: "Signature", Rule: "OR('org1MSP.admin')"}
+// Policy{Type: "ImplicitMeta", Rule: "MAJORITY Admins"}
+type Policy struct {
+	Type string `json:"type"`
+	Rule string `json:"rule"`
+}
+
+// PolicyGroup is a config group with its policies and sub groups,
+// eg: the channel group contains "Application" and "Orderer", which contain the organizations.
+type PolicyGroup struct {
+	Name string
+	// Path is what Policies and SetPolicy take to address the group
+	Path      []string
+	ModPolicy string
+	Policies  map[string]Policy
+	Groups    []PolicyGroup
+}
+
+// PolicyTree decodes the policies of every group, starting from the channel group.
+// Sub groups are sorted by name.
+func (c *ConfigTx) PolicyTree() (*PolicyGroup, error) {
+	group, err := c.getGroup()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := newPolicyGroup("Channel", []string{}, group)
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
+func newPolicyGroup(name string, path []string, group *cb.ConfigGroup) (PolicyGroup, error) {
+	ret := PolicyGroup{
+		Name:      name,
+		Path:      path,
+		ModPolicy: group.ModPolicy,
+		Policies:  make(map[string]Policy),
+		Groups:    []PolicyGroup{},
+	}
+	for key, configPolicy := range group.Policies {
+		policy, err := decodePolicy(configPolicy.Policy)
+		if err != nil {
+			return ret, errors.WithMessagef(err, "fail to decode policy %s of %v", key, path)
+		}
+		ret.Policies[key] = policy
+	}
+
+	keys := make([]string, 0, len(group.Groups))
+	for key := range group.Groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		subPath := append(append([]string{}, path...), key)
+		sub, err := newPolicyGroup(key, subPath, group.Groups[key])
+		if err != nil {
+			return ret, err
+		}
+		ret.Groups = append(ret.Groups, sub)
+	}
+	return ret, nil
+}
+
+// Policies returns the policies of the group at path,
+// eg: Policies("Application", "org1MSP"). An empty path means the channel group.
+func (c *ConfigTx) Policies(path ...string) (map[string]Policy, error) {
+	group, err := c.getGroup(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string]Policy)
+	for name, configPolicy := range group.Policies {
+		policy, err := decodePolicy(configPolicy.Policy)
+		if err != nil {
+			return nil, errors.WithMessage(err, "fail to decode policy "+name)
+		}
+		policies[name] = policy
+	}
+	return policies, nil
+}
+
+// PoliciesReferencing returns the names of the signature policies of the group at path
+// which name mspID, they can't be satisfied the same way once the organization is gone.
+func (c *ConfigTx) PoliciesReferencing(mspID string, path ...string) ([]string, error) {
+	policies, err := c.Policies(path...)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for name, policy := range policies {
+		if policy.Type == SignaturePolicyType && RuleReferences(policy.Rule, mspID) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RuleReferences reports whether a signature policy rule, eg: "OR('org1MSP.member')", names mspID.
+func RuleReferences(rule string, mspID string) bool {
+	return strings.Contains(rule, "'"+mspID+".")
+}
+
+// SetPolicy adds or replaces the policy named name in the group at path.
+func (c *ConfigTx) SetPolicy(name string, policy Policy, path ...string) error {
+	group, err := c.getGroup(path...)
+	if err != nil {
+		return err
+	}
+	return setPolicy(group, name, policy)
+}
+
+// RemovePolicy removes the policy named name from the group at path.
+func (c *ConfigTx) RemovePolicy(name string, path ...string) error {
+	group, err := c.getGroup(path...)
+	if err != nil {
+		return err
+	}
+	if _, ok := group.Policies[name]; !ok {
+		return errors.Errorf("policy %s does not exist", name)
+	}
+	delete(group.Policies, name)
+	return nil
+}
+
+func setPolicy(group *cb.ConfigGroup, name string, policy Policy) error {
+	encoded, err := encodePolicy(policy)
+	if err != nil {
+		return errors.WithMessage(err, "fail to encode policy "+name)
+	}
+	if group.Policies == nil {
+		group.Policies = make(map[string]*cb.ConfigPolicy)
+	}
+	configPolicy, ok := group.Policies[name]
+	if !ok {
+		configPolicy = &cb.ConfigPolicy{ModPolicy: AdminsPolicyKey}
+		group.Policies[name] = configPolicy
+	}
+	configPolicy.Policy = encoded
+	return nil
+}
+
+func encodePolicy(policy Policy) (*cb.Policy, error) {
+	switch policy.Type {
+	case ImplicitMetaPolicyType:
+		fields := strings.Fields(policy.Rule)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("invalid ImplicitMeta rule: %s", policy.Rule)
+		}
+		rule, ok := cb.ImplicitMetaPolicy_Rule_value[strings.ToUpper(fields[0])]
+		if !ok {
+			return nil, errors.Errorf("unknown ImplicitMeta rule: %s", fields[0])
+		}
+		bt, err := proto.Marshal(&cb.ImplicitMetaPolicy{
+			Rule:      cb.ImplicitMetaPolicy_Rule(rule),
+			SubPolicy: fields[1],
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &cb.Policy{Type: int32(cb.Policy_IMPLICIT_META), Value: bt}, nil
+	case SignaturePolicyType:
+		envelope, err := policydsl.FromString(policy.Rule)
+		if err != nil {
+			return nil, err
+		}
+		bt, err := proto.Marshal(envelope)
+		if err != nil {
+			return nil, err
+		}
+		return &cb.Policy{Type: int32(cb.Policy_SIGNATURE), Value: bt}, nil
+	default:
+		return nil, errors.Errorf("unknown policy type: %s", policy.Type)
+	}
+}
+
+func decodePolicy(policy *cb.Policy) (Policy, error) {
+	if policy == nil {
+		return Policy{}, errors.New("empty policy")
+	}
+	switch cb.Policy_PolicyType(policy.Type) {
+	case cb.Policy_IMPLICIT_META:
+		imp := &cb.ImplicitMetaPolicy{}
+		if err := proto.Unmarshal(policy.Value, imp); err != nil {
+			return Policy{}, err
+		}
+		return Policy{
+			Type: ImplicitMetaPolicyType,
+			Rule: fmt.Sprintf("%s %s", imp.Rule.String(), imp.SubPolicy),
+		}, nil
+	case cb.Policy_SIGNATURE:
+		envelope := &cb.SignaturePolicyEnvelope{}
+		if err := proto.Unmarshal(policy.Value, envelope); err != nil {
+			return Policy{}, err
+		}
+		rule, err := signaturePolicyToString(envelope.Rule, envelope.Identities)
+		if err != nil {
+			return Policy{}, err
+		}
+		return Policy{Type: SignaturePolicyType, Rule: rule}, nil
+	default:
+		return Policy{}, errors.Errorf("unsupported policy type: %d", policy.Type)
+	}
+}
+
+// signaturePolicyToString is the reverse of policydsl.FromString
+func signaturePolicyToString(policy *cb.SignaturePolicy, identities []*mb.MSPPrincipal) (string, error) {
+	switch t := policy.Type.(type) {
+	case *cb.SignaturePolicy_SignedBy:
+		if int(t.SignedBy) >= len(identities) {
+			return "", errors.Errorf("identity index %d out of range", t.SignedBy)
+		}
+		principal := identities[t.SignedBy]
+		if principal.PrincipalClassification != mb.MSPPrincipal_ROLE {
+			return "", errors.Errorf("unsupported principal classification: %s", principal.PrincipalClassification)
+		}
+		role := &mb.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("'%s.%s'", role.MspIdentifier, strings.ToLower(role.Role.String())), nil
+	case *cb.SignaturePolicy_NOutOf_:
+		rules := make([]string, 0, len(t.NOutOf.Rules))
+		for _, rule := range t.NOutOf.Rules {
+			st, err := signaturePolicyToString(rule, identities)
+			if err != nil {
+				return "", err
+			}
+			rules = append(rules, st)
+		}
+		n := int(t.NOutOf.N)
+		switch {
+		case n == 1:
+			return fmt.Sprintf("OR(%s)", strings.Join(rules, ", ")), nil
+		case n == len(rules):
+			return fmt.Sprintf("AND(%s)", strings.Join(rules, ", ")), nil
+		default:
+			return fmt.Sprintf("OutOf(%d, %s)", n, strings.Join(rules, ", ")), nil
+		}
+	default:
+		return "", errors.New("unknown signature policy type")
+	}
+}
@@ -0,0 +1,211 @@
+package configtx
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+)
+
+// ParseEnvelope is the reverse of NewEnvelope. Signatures attached by
+// `peer channel signconfigtx` are kept in the returned ConfigUpdateEnvelope.
+func ParseEnvelope(bt []byte) (*cb.ConfigUpdateEnvelope, error) {
+	envelope := &cb.Envelope{}
+	if err := proto.Unmarshal(bt, envelope); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal envelope")
+	}
+	payload := &cb.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal payload")
+	}
+	if payload.Header == nil {
+		return nil, errors.New("payload has no header")
+	}
+	chdr := &cb.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, chdr); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal channel header")
+	}
+	if chdr.Type != int32(cb.HeaderType_CONFIG_UPDATE) {
+		return nil, errors.Errorf("not a config update envelope, header type: %d", chdr.Type)
+	}
+	configUpdateEnvelope := &cb.ConfigUpdateEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configUpdateEnvelope); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal config update envelope")
+	}
+	return configUpdateEnvelope, nil
+}
+
+// VerifyAdminSignature checks sig is made over the marshaled config update by the certificate in its signature header,
+// and the checks of the signer's MSP in the config, like the orderer does:
+// the certificate is issued by a root or intermediate CA of the MSP, it is not revoked,
+// and it is an admin, by the admin certs, or by the admin OU with NodeOUs.
+// The returned creator, the MSP ID with the certificate, identifies the signer.
+func (c *ConfigTx) VerifyAdminSignature(update []byte, sig *cb.ConfigSignature) (mspID string, creator []byte, err error) {
+	identity, cert, err := verifySignature(update, sig)
+	if err != nil {
+		return "", nil, err
+	}
+	mspConfig, err := c.findMSPConfig(identity.Mspid)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := validateAdmin(mspConfig, cert); err != nil {
+		return "", nil, errors.WithMessage(err, fmt.Sprintf("%s is not an admin of %s", cert.Subject.CommonName, identity.Mspid))
+	}
+	creator, err = SignatureCreator(sig.SignatureHeader)
+	if err != nil {
+		return "", nil, err
+	}
+	return identity.Mspid, creator, nil
+}
+
+// SignatureCreator returns the serialized identity which made a signature with sigHeader
+func SignatureCreator(sigHeader []byte) ([]byte, error) {
+	header := &cb.SignatureHeader{}
+	if err := proto.Unmarshal(sigHeader, header); err != nil {
+		return nil, errors.WithMessage(err, "fail to unmarshal signature header")
+	}
+	return header.Creator, nil
+}
+
+func verifySignature(update []byte, sig *cb.ConfigSignature) (*mb.SerializedIdentity, *x509.Certificate, error) {
+	sigHeader := &cb.SignatureHeader{}
+	if err := proto.Unmarshal(sig.SignatureHeader, sigHeader); err != nil {
+		return nil, nil, errors.WithMessage(err, "fail to unmarshal signature header")
+	}
+	creator := &mb.SerializedIdentity{}
+	if err := proto.Unmarshal(sigHeader.Creator, creator); err != nil {
+		return nil, nil, errors.WithMessage(err, "fail to unmarshal creator")
+	}
+
+	cert, err := parseCertificate(creator.IdBytes)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "fail to parse the certificate of the creator")
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil, errors.New("only ECDSA signatures are supported")
+	}
+
+	digest := sha256.Sum256(append(append([]byte{}, sig.SignatureHeader...), update...))
+	if !ecdsa.VerifyASN1(pub, digest[:], sig.Signature) {
+		return nil, nil, errors.Errorf("invalid signature of %s", creator.Mspid)
+	}
+	return creator, cert, nil
+}
+
+func parseCertificate(pemBytes []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("not a PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// findMSPConfig looks for the MSP named mspID in the organization groups of the original config
+func (c *ConfigTx) findMSPConfig(mspID string) (*mb.FabricMSPConfig, error) {
+	var find func(group *cb.ConfigGroup) (*mb.FabricMSPConfig, error)
+	find = func(group *cb.ConfigGroup) (*mb.FabricMSPConfig, error) {
+		if _, ok := group.Values[MSPKey]; ok {
+			mspConfig := &mb.MSPConfig{}
+			if err := getValue(group, MSPKey, mspConfig); err != nil {
+				return nil, err
+			}
+			fabricMSPConfig := &mb.FabricMSPConfig{}
+			if err := proto.Unmarshal(mspConfig.Config, fabricMSPConfig); err != nil {
+				return nil, errors.WithMessage(err, "fail to unmarshal msp config")
+			}
+			if fabricMSPConfig.Name == mspID {
+				return fabricMSPConfig, nil
+			}
+		}
+		for _, sub := range group.Groups {
+			if ret, err := find(sub); ret != nil || err != nil {
+				return ret, err
+			}
+		}
+		return nil, nil
+	}
+
+	if c.original.ChannelGroup == nil {
+		return nil, errors.New("config has no channel group")
+	}
+	ret, err := find(c.original.ChannelGroup)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, errors.Errorf("MSP %s is not in the channel", mspID)
+	}
+	return ret, nil
+}
+
+// validateAdmin checks cert like the MSP does for an admin signature
+func validateAdmin(mspConfig *mb.FabricMSPConfig, cert *x509.Certificate) error {
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	for _, bt := range mspConfig.RootCerts {
+		root, err := parseCertificate(bt)
+		if err != nil {
+			return errors.WithMessage(err, "fail to parse root cert")
+		}
+		roots.AddCert(root)
+	}
+	for _, bt := range mspConfig.IntermediateCerts {
+		intermediate, err := parseCertificate(bt)
+		if err != nil {
+			return errors.WithMessage(err, "fail to parse intermediate cert")
+		}
+		intermediates.AddCert(intermediate)
+	}
+	// like the MSP, expiration is not checked
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   cert.NotBefore.Add(time.Second),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return errors.WithMessage(err, "not issued by the CA of the MSP")
+	}
+
+	if len(chains[0]) > 1 {
+		issuer := chains[0][1]
+		for _, bt := range mspConfig.RevocationList {
+			crl, err := x509.ParseCRL(bt)
+			if err != nil {
+				return errors.WithMessage(err, "fail to parse CRL")
+			}
+			if issuer.CheckCRLSignature(crl) != nil {
+				continue
+			}
+			for _, revoked := range crl.TBSCertList.RevokedCertificates {
+				if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return errors.New("the certificate has been revoked")
+				}
+			}
+		}
+	}
+
+	for _, bt := range mspConfig.Admins {
+		admin, err := parseCertificate(bt)
+		if err == nil && admin.Equal(cert) {
+			return nil
+		}
+	}
+	nodeOUs := mspConfig.FabricNodeOus
+	if nodeOUs != nil && nodeOUs.Enable && nodeOUs.AdminOuIdentifier != nil {
+		for _, ou := range cert.Subject.OrganizationalUnit {
+			if ou == nodeOUs.AdminOuIdentifier.OrganizationalUnitIdentifier {
+				return nil
+			}
+		}
+	}
+	return errors.New("neither an admin cert nor with the admin OU")
+}
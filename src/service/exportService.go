@@ -0,0 +1,83 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io"
+	"mictract/model"
+	"os"
+	"path/filepath"
+)
+
+// ExportBundle writes a zip containing the connection profile (yaml and json)
+// and the msp and tls directories of the user:
+//
+// connection-profile.yaml
+// connection-profile.json
+// User1@org1.net1.com/msp/...
+// User1@org1.net1.com/tls/...
+func (ns *NetworkService) ExportBundle(w io.Writer, profile *model.ConnectionProfile, user *model.CaUser) error {
+	if user.NetworkID != ns.net.ID {
+		return errors.New(fmt.Sprintf("%s is not in %s", user.GetName(), ns.net.GetName()))
+	}
+
+	zw := zip.NewWriter(w)
+
+	// 1. connection profile
+	profileYAML, err := yaml.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	profileJSON, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	for name, content := range map[string][]byte{
+		"connection-profile.yaml": profileYAML,
+		"connection-profile.json": profileJSON,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(content); err != nil {
+			return err
+		}
+	}
+
+	// 2. crypto material of the user
+	basePath := user.GetBasePath()
+	for _, dir := range []string{"msp", "tls"} {
+		root := filepath.Join(basePath, dir)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+		if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(basePath, path)
+			if err != nil {
+				return err
+			}
+			f, err := zw.Create(filepath.ToSlash(filepath.Join(user.GetName(), rel)))
+			if err != nil {
+				return err
+			}
+			src, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			_, err = io.Copy(f, src)
+			return err
+		}); err != nil {
+			return errors.WithMessage(err, "fail to pack crypto material of "+user.GetName())
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,4 @@
+package service
+
+// AddSignatures exposes addSignatures to tests
+var AddSignatures = (*ConfigUpdateService).addSignatures
@@ -68,6 +68,40 @@ func (cuf *CaUserFactory)NewAdminCaUser(orgID, netID int, nickname, password str
 	return cuf.newCaUser(nickname, password, "admin", orgID, netID, isInOrdererOrg)
 }
 
+// NewExternalNodeCaUser inserts a peer or orderer of an external network,
+// url is its grpcs:// address and hostOverride may be empty.
+func (cuf *CaUserFactory)NewExternalNodeCaUser(orgID, netID int, userType, name, url, hostOverride string) (*model.CaUser, error) {
+	cu := &model.CaUser{
+		Type:           			userType,
+		OrganizationID: 			orgID,
+		NetworkID:      			netID,
+		IsInOrdererOrganization: 	userType == "orderer",
+		ExternalName: 				name,
+		ExternalURL: 				url,
+		ExternalHostOverride: 		hostOverride,
+	}
+	if err := dao.InsertCaUser(cu); err != nil {
+		return &model.CaUser{}, err
+	}
+	return cu, nil
+}
+
+// NewExternalAdminCaUser inserts the system user of an external organization,
+// name is the one of its certificate, e.g. Admin@org1.example.com
+func (cuf *CaUserFactory)NewExternalAdminCaUser(orgID, netID int, name string) (*model.CaUser, error) {
+	cu := &model.CaUser{
+		Type:           			"admin",
+		Nickname: 					"system-user",
+		OrganizationID: 			orgID,
+		NetworkID:      			netID,
+		ExternalName: 				name,
+	}
+	if err := dao.InsertCaUser(cu); err != nil {
+		return &model.CaUser{}, err
+	}
+	return cu, nil
+}
+
 func (cuf *CaUserFactory)NewOrganizationCaUser(orgID, netID int, isInOrdererOrg bool) *model.CaUser {
 	return &model.CaUser{
 		OrganizationID: orgID,
@@ -20,6 +20,11 @@ func (cf *CertificationFactory) NewCACertification(org *model.Organization, cert
 	return cf.newCertification(-1, org.NetworkID,  org.GetCAID(), org.GetCAID(), cert, privkey, false)
 }
 
+// NewTLSCACertification stores the tls ca cert of an external organization's nodes
+func (cf *CertificationFactory) NewTLSCACertification(org *model.Organization, cert string) (*model.Certification, error) {
+	return cf.newCertification(-1, org.NetworkID,  org.GetCAID(), org.GetCAID(), cert, "", true)
+}
+
 func (cf *CertificationFactory) newCertification(userID, networkID int, userType, nickname, cert, privkey string, isTLS bool) (*model.Certification, error) {
 	ret := &model.Certification{
 		UserID: 		userID,
@@ -19,7 +19,7 @@ func NewChaincodeFactory() *ChaincodeFactory {
 
 // tar czf src.tar.gz src
 func (ccf *ChaincodeFactory)NewChaincode(nickname string, chID, netID int, label, policyStr, version string,
-	seq int64, initReq bool) (*model.Chaincode, error){
+	seq int64, initReq, manualApproval bool) (*model.Chaincode, error){
 	// 1. check
 	net, _ := dao.FindNetworkByID(netID)
 	if net.Status != enum.StatusRunning {
@@ -33,6 +33,7 @@ func (ccf *ChaincodeFactory)NewChaincode(nickname string, chID, netID int, label
 	cc := &model.Chaincode{
 		Nickname: nickname,
 		Status: enum.StatusUnpacking,
+		Stage: enum.StatusUnpacking,
 		ChannelID: chID,
 		NetworkID: netID,
 
@@ -41,6 +42,7 @@ func (ccf *ChaincodeFactory)NewChaincode(nickname string, chID, netID int, label
 		Version: version,
 		Sequence: seq,
 		InitRequired: initReq,
+		ManualApproval: manualApproval,
 	}
 
 	if err := global.DB.Create(&cc).Error; err != nil {
@@ -54,4 +56,24 @@ func (ccf *ChaincodeFactory)NewChaincode(nickname string, chID, netID int, label
 	}
 
 	return cc, nil
-}
\ No newline at end of file
+}
+
+// NewChaincodeVersion records the current definition of cc
+func (ccf *ChaincodeFactory)NewChaincodeVersion(cc *model.Chaincode) (*model.ChaincodeVersion, error) {
+	ccv := &model.ChaincodeVersion{
+		ChaincodeID: cc.ID,
+		NetworkID: cc.NetworkID,
+
+		Label: cc.Label,
+		PolicyStr: cc.PolicyStr,
+		Version: cc.Version,
+		Sequence: cc.Sequence,
+		InitRequired: cc.InitRequired,
+		PackageID: cc.PackageID,
+	}
+
+	if err := dao.InsertChaincodeVersion(ccv); err != nil {
+		return ccv, err
+	}
+	return ccv, nil
+}
@@ -43,6 +43,22 @@ func (cf *ChannelFactory)NewChannel(netID int, nickname string, orgIDs []int) (*
 	return ch, nil
 }
 
+// NewExternalChannel inserts an existing channel of an external network
+func (cf *ChannelFactory)NewExternalChannel(netID int, name string, orgIDs, ordererIDs []int) (*model.Channel, error) {
+	ch := &model.Channel{
+		Nickname: 			name,
+		NetworkID: 			netID,
+		Status: 			enum.StatusRunning,
+		OrganizationIDs: 	orgIDs,
+		OrdererIDs: 		ordererIDs,
+		ExternalName: 		name,
+	}
+	if err := dao.InsertChannel(ch); err != nil {
+		return &model.Channel{}, err
+	}
+	return ch, nil
+}
+
 func (cf *ChannelFactory)NewSystemChannel(netID int) *model.Channel {
 	return &model.Channel{
 		ID: -1,
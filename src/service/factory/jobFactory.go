@@ -0,0 +1,29 @@
+package factory
+
+import (
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/model"
+)
+
+type JobFactory struct {
+}
+
+func NewJobFactory() *JobFactory {
+	return &JobFactory{}
+}
+
+// NewJob inserts a pending job, targetID can be set later if the target is created by the job
+func (jf *JobFactory) NewJob(jobType string, netID, targetID int) (*model.Job, error) {
+	job := &model.Job{
+		Type:		jobType,
+		Status:		enum.StatusPending,
+		NetworkID:	netID,
+		TargetID:	targetID,
+		Steps:		[]string{},
+	}
+	if err := dao.InsertJob(job); err != nil {
+		return &model.Job{}, err
+	}
+	return job, nil
+}
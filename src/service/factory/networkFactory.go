@@ -15,10 +15,27 @@ func NewNetworkFactory() *NetworkFactory {
 	return &NetworkFactory{}
 }
 
-func (nf *NetworkFactory)NewNetwork(nickname, consensus string) (*model.Network, error) {
+// channelMode is enum.ChannelModeSystemChannel or enum.ChannelModeParticipation,
+// empty means the former, or the latter for BFT networks.
+func (nf *NetworkFactory)NewNetwork(nickname, consensus, channelMode string) (*model.Network, error) {
 	// 1. check
-	if consensus != "solo" && consensus != "etcdraft" {
-		return &model.Network{}, errors.New("only supports solo and etcdraft")
+	if consensus != enum.ConsensusSolo && consensus != enum.ConsensusEtcdraft && consensus != enum.ConsensusBFT {
+		return &model.Network{}, errors.New("only supports solo, etcdraft and BFT")
+	}
+	if channelMode == "" {
+		channelMode = enum.ChannelModeSystemChannel
+		if consensus == enum.ConsensusBFT {
+			channelMode = enum.ChannelModeParticipation
+		}
+	}
+	if channelMode != enum.ChannelModeSystemChannel && channelMode != enum.ChannelModeParticipation {
+		return &model.Network{}, errors.New("channel mode only supports system-channel and participation")
+	}
+	if channelMode == enum.ChannelModeParticipation && consensus == enum.ConsensusSolo {
+		return &model.Network{}, errors.New("channel participation only supports etcdraft and BFT")
+	}
+	if channelMode == enum.ChannelModeSystemChannel && consensus == enum.ConsensusBFT {
+		return &model.Network{}, errors.New("BFT networks have no system channel")
 	}
 
 	// 2. new
@@ -28,6 +45,7 @@ func (nf *NetworkFactory)NewNetwork(nickname, consensus string) (*model.Network,
 		Status: 	enum.StatusStarting,
 		Consensus: 	consensus,
 		TlsEnabled: true,
+		ChannelMode: channelMode,
 	}
 
 	// 3. insert into db
@@ -36,3 +54,21 @@ func (nf *NetworkFactory)NewNetwork(nickname, consensus string) (*model.Network,
 	}
 	return net, nil
 }
+
+// NewExternalNetwork inserts a network imported from a connection profile,
+// the consensus is only informative since mictract does not run its orderers.
+func (nf *NetworkFactory)NewExternalNetwork(nickname, consensus string) (*model.Network, error) {
+	net := &model.Network{
+		Nickname: 	nickname,
+		CreatedAt: 	time.Now(),
+		Status: 	enum.StatusStarting,
+		Consensus: 	consensus,
+		TlsEnabled: true,
+		External: 	true,
+	}
+
+	if err := dao.InsertNetwork(net); err != nil {
+		return &model.Network{}, errors.WithMessage(err, "Unable to insert network")
+	}
+	return net, nil
+}
@@ -46,3 +46,25 @@ func (orgf *OrganizationFactory) newOrganization(netID int, isOrdOrg bool, nickn
 
 	return org, nil
 }
+
+// NewExternalOrganization inserts an organization of an external network.
+// caName and caURL are empty if its ca is unknown.
+func (orgf *OrganizationFactory) NewExternalOrganization(netID int, isOrdOrg bool, nickname, mspID, caName, caURL string) (*model.Organization, error) {
+	org := &model.Organization{
+		NetworkID: 		netID,
+		Nickname: 		nickname,
+		Status: 		enum.StatusStarting,
+		CreatedAt: 		time.Now(),
+		IsOrdererOrg: 	isOrdOrg,
+		External: 		true,
+		ExternalMSPID: 	mspID,
+		ExternalCAName: caName,
+		ExternalCAURL: 	caURL,
+	}
+
+	if err := dao.InsertOrganization(org); err != nil {
+		return &model.Organization{}, err
+	}
+
+	return org, nil
+}
@@ -6,6 +6,8 @@ import (
 	"mictract/global"
 	"mictract/model"
 	"mictract/model/response"
+	"mictract/service/configtx"
+	"sort"
 )
 
 func NewChannel(c *model.Channel) *response.Channel {
@@ -34,4 +36,94 @@ func NewChannelWithHeight(c *model.Channel, height uint64) *response.Channel {
 		Status: 		c.Status,
 		Height: 		height,
 	}
-}
\ No newline at end of file
+}
+
+func NewChannelConsenters(c *model.Channel, consenters []configtx.Consenter, orderers []model.CaUser) *response.ChannelConsenters {
+	ret := &response.ChannelConsenters{
+		ChannelID: 		c.ID,
+		ChannelName: 	c.GetName(),
+		Consenters: 	[]response.Consenter{},
+	}
+	for _, consenter := range consenters {
+		rc := response.Consenter{
+			Host: 	consenter.Host,
+			Port: 	consenter.Port,
+		}
+		for _, orderer := range orderers {
+			if orderer.GetURL() == consenter.Host {
+				rc.OrdererID = orderer.ID
+			}
+		}
+		ret.Consenters = append(ret.Consenters, rc)
+	}
+	return ret
+}
+
+func NewChannelPolicies(c *model.Channel, sequence uint64, tree *configtx.PolicyGroup) *response.ChannelPolicies {
+	return &response.ChannelPolicies{
+		ChannelID: 		c.ID,
+		ChannelName: 	c.GetName(),
+		Sequence: 		sequence,
+		Root: 			newPolicyGroup(tree),
+	}
+}
+
+func newPolicyGroup(group *configtx.PolicyGroup) response.PolicyGroup {
+	ret := response.PolicyGroup{
+		Name: 		group.Name,
+		Path: 		group.Path,
+		ModPolicy: 	group.ModPolicy,
+		Policies: 	map[string]response.Policy{},
+		Groups: 	[]response.PolicyGroup{},
+	}
+	for name, policy := range group.Policies {
+		ret.Policies[name] = response.Policy{Type: policy.Type, Rule: policy.Rule}
+	}
+	for i := range group.Groups {
+		ret.Groups = append(ret.Groups, newPolicyGroup(&group.Groups[i]))
+	}
+	return ret
+}
+
+func NewChannelParameters(c *model.Channel, sequence uint64, params *configtx.ChannelParameters) *response.ChannelParameters {
+	return &response.ChannelParameters{
+		ChannelID: 					c.ID,
+		ChannelName: 				c.GetName(),
+		Sequence: 					sequence,
+		ChannelCapabilities: 		params.ChannelCapabilities,
+		OrdererCapabilities: 		params.OrdererCapabilities,
+		ApplicationCapabilities: 	params.ApplicationCapabilities,
+		BatchTimeout: 				params.Batch.BatchTimeout,
+		MaxMessageCount: 			params.Batch.MaxMessageCount,
+		AbsoluteMaxBytes: 			params.Batch.AbsoluteMaxBytes,
+		PreferredMaxBytes: 			params.Batch.PreferredMaxBytes,
+	}
+}
+
+// NewOrganizationAnchors takes anchor peers by organization ID, sorted by organization ID.
+// Addresses are matched with the peers of the organization.
+func NewOrganizationAnchors(anchors map[int][]configtx.Address) []response.OrganizationAnchors {
+	ret := []response.OrganizationAnchors{}
+	for orgID, addresses := range anchors {
+		peers, err := dao.FindAllPeersInOrganization(orgID)
+		if err != nil {
+			global.Logger.Error("fail to get peers", zap.Error(err))
+		}
+		orgAnchors := response.OrganizationAnchors{OrganizationID: orgID, Anchors: []response.AnchorPeer{}}
+		for _, address := range addresses {
+			anchor := response.AnchorPeer{Host: address.Host, Port: address.Port}
+			for _, peer := range peers {
+				if peer.GetURL() == address.Host {
+					anchor.PeerID = peer.ID
+					break
+				}
+			}
+			orgAnchors.Anchors = append(orgAnchors.Anchors, anchor)
+		}
+		ret = append(ret, orgAnchors)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].OrganizationID < ret[j].OrganizationID
+	})
+	return ret
+}
@@ -0,0 +1,27 @@
+package response
+
+import (
+	"mictract/model"
+	"mictract/model/response"
+)
+
+func NewConfigUpdate(cu *model.ConfigUpdate) *response.ConfigUpdate {
+	return NewConfigUpdateWithStatus(cu, []string{}, false)
+}
+
+func NewConfigUpdates(cus []model.ConfigUpdate) []response.ConfigUpdate {
+	ret := []response.ConfigUpdate{}
+	for _, cu := range cus {
+		ret = append(ret, *NewConfigUpdate(&cu))
+	}
+	return ret
+}
+
+func NewConfigUpdateWithStatus(cu *model.ConfigUpdate, unsatisfied []string, stale bool) *response.ConfigUpdate {
+	return &response.ConfigUpdate{
+		ConfigUpdate: 			*cu,
+		SignedBy: 				cu.SignedBy(),
+		UnsatisfiedPolicies: 	unsatisfied,
+		Stale: 					stale,
+	}
+}
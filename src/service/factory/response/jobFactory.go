@@ -0,0 +1,20 @@
+package response
+
+import (
+	"mictract/model"
+	"mictract/model/response"
+)
+
+func NewJob(job *model.Job) *response.Job {
+	return &response.Job{
+		Job: *job,
+	}
+}
+
+func NewJobs(jobs []model.Job) []response.Job {
+	ret := []response.Job{}
+	for _, job := range jobs {
+		ret = append(ret, *NewJob(&job))
+	}
+	return ret
+}
@@ -33,6 +33,8 @@ func NewNetwork(n *model.Network) *response.Network {
 		Consensus: 		n.Consensus,
 		TlsEnabled: 	n.TlsEnabled,
 		Status: 		n.Status,
+		External: 		n.External,
+		ChannelMode: 	n.ChannelMode,
 		CreateTime: 	strconv.FormatInt(n.CreatedAt.Unix(), 10),
 		Orderers: 		response.NewOrderers(orderers),
 		Organizations: 	NewOrgs(orgs),
@@ -0,0 +1,149 @@
+package sdk
+
+import (
+	"github.com/pkg/errors"
+	"mictract/dao"
+	"mictract/model"
+	"net/url"
+	"strings"
+)
+
+// NewConnectionProfile converts the sdk config of the whole network into a connection profile
+// for applications outside mictract.
+// addresses maps in-cluster host names (e.g. peer1-org1-net1) to external "host:port",
+// nodes without a mapping keep the in-cluster address.
+// clientOrgID chooses client.organization, 0 means the first peer organization.
+func (sdkf *SDKFactory)NewConnectionProfile(netID, clientOrgID int, addresses map[string]string) (*model.ConnectionProfile, error) {
+	net, err := dao.FindNetworkByID(netID)
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := dao.FindAllOrganizationsInNetwork(netID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) < 1 {
+		return nil, errors.New("no organization in the network")
+	}
+	orderers, err := dao.FindAllOrderersInNetwork(netID)
+	if err != nil {
+		return nil, err
+	}
+
+	sdkconfig := sdkf.newSDKConfigByNetworkID(netID)
+
+	profile := &model.ConnectionProfile{
+		Name: 					net.GetName(),
+		Version: 				"1.0.0",
+		Organizations: 			map[string]*model.ConnectionProfileOrganization{},
+		Orderers: 				map[string]*model.ConnectionProfileNode{},
+		Peers: 					map[string]*model.ConnectionProfileNode{},
+		Channels: 				map[string]*model.ConnectionProfileChannel{},
+		CertificateAuthorities: map[string]*model.ConnectionProfileCA{},
+	}
+
+	// client
+	for _, org := range orgs {
+		if org.IsOrdererOrganization() {
+			continue
+		}
+		if clientOrgID == 0 || clientOrgID == org.ID {
+			profile.Client.Organization = org.GetName()
+			break
+		}
+	}
+	if profile.Client.Organization == "" {
+		return nil, errors.New("client organization is not a peer organization of the network")
+	}
+
+	// organizations (without users and their private keys)
+	for name, org := range sdkconfig.Organizations {
+		profile.Organizations[name] = &model.ConnectionProfileOrganization{
+			Mspid:                  org.Mspid,
+			Peers:                  org.Peers,
+			CertificateAuthorities: org.CertificateAuthorities,
+		}
+	}
+
+	// nodes
+	for name, node := range sdkconfig.Orderers {
+		profile.Orderers[name] = newConnectionProfileNode(node, addresses)
+	}
+	for name, node := range sdkconfig.Peers {
+		profile.Peers[name] = newConnectionProfileNode(node, addresses)
+	}
+
+	// channels (the "_default" channel is only meaningful to mictract)
+	ordererNames := []string{}
+	for _, orderer := range orderers {
+		ordererNames = append(ordererNames, orderer.GetName())
+	}
+	for name, ch := range sdkconfig.Channels {
+		if name == "_default" {
+			continue
+		}
+		profileCh := &model.ConnectionProfileChannel{
+			Orderers: ordererNames,
+			Peers:    map[string]model.ConnectionProfilePeerRole{},
+		}
+		for peer, role := range ch.Peers {
+			profileCh.Peers[peer] = model.ConnectionProfilePeerRole(role)
+		}
+		profile.Channels[name] = profileCh
+	}
+
+	// certificate authorities (without the registrar)
+	for _, org := range orgs {
+		ca, ok := sdkconfig.CertificateAuthorities[org.GetCAID()]
+		if !ok {
+			continue
+		}
+		profileCA := &model.ConnectionProfileCA{
+			URL:         rewriteURL(ca.URL, addresses),
+			CAName:      org.GetCAID(),
+			HTTPOptions: map[string]interface{}{"verify": false},
+		}
+		if ca.CAName != "" {
+			profileCA.CAName = ca.CAName
+		}
+		profileCA.TLSCACerts.Pem = ca.TLSCACerts.Pem
+		profile.CertificateAuthorities[org.GetCAID()] = profileCA
+	}
+
+	return profile, nil
+}
+
+func newConnectionProfileNode(node *model.SDKConfigNode, addresses map[string]string) *model.ConnectionProfileNode {
+	host := node.URL
+	if u, err := url.Parse(node.URL); err == nil {
+		host = u.Hostname()
+	}
+	if override, ok := node.GRPCOptions["ssl-target-name-override"].(string); ok {
+		host = override
+	}
+	return &model.ConnectionProfileNode{
+		URL:        rewriteURL(node.URL, addresses),
+		TLSCACerts: model.SDKConfigPemJSON{Pem: node.TLSCACerts.Pem},
+		GRPCOptions: map[string]interface{}{
+			"ssl-target-name-override": host,
+			"hostnameOverride":         host,
+		},
+	}
+}
+
+// rewriteURL replaces host:port of grpcs://host:port or https://host:port if the host is mapped
+func rewriteURL(rawURL string, addresses map[string]string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	external, ok := addresses[u.Hostname()]
+	if !ok {
+		return rawURL
+	}
+	if !strings.Contains(external, ":") {
+		external = external + ":" + u.Port()
+	}
+	u.Host = external
+	return u.String()
+}
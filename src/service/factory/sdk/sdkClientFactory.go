@@ -100,5 +100,9 @@ func (sdkCF *SDKClientFactory) NewMSPClient(org *model.Organization) (*mspclient
 	if err != nil {
 		return nil, errors.WithMessage(err, "fail to get sdk")
 	}
+	if !org.HasCA() {
+		// signing identities still work, but registering and enrolling fail
+		return mspclient.New(sdk.Context(), mspclient.WithOrg(org.GetName()))
+	}
 	return mspclient.New(sdk.Context(), mspclient.WithCAInstance(org.GetCAID()), mspclient.WithOrg(org.GetName()))
 }
@@ -34,7 +34,7 @@ func (sdkCSF *SDKConfigSonFactory)NewSDKConfigOrganization(org *model.Organizati
 		Mspid:                  org.GetMSPID(),
 		Peers:                  []string{},
 		Users: 					map[string]*model.SDKConfigOrganizationUser{},
-		CertificateAuthorities: []string{org.GetCAID()},
+		CertificateAuthorities: []string{},
 		CryptoPath: filepath.Join(
 			mConfig.LOCAL_BASE_PATH,
 			model.GetNetworkNameByID(org.NetworkID),
@@ -43,6 +43,9 @@ func (sdkCSF *SDKConfigSonFactory)NewSDKConfigOrganization(org *model.Organizati
 			"users", "{username}", "msp"),
 	}
 
+	if org.HasCA() {
+		ret.CertificateAuthorities = append(ret.CertificateAuthorities, org.GetCAID())
+	}
 	for _, peer := range peers {
 		ret.Peers = append(ret.Peers, peer.GetName())
 	}
@@ -66,12 +69,12 @@ func (sdkCSF *SDKConfigSonFactory)NewSDKConfigOrganizationUser(user *model.CaUse
 }
 
 func (sdkCSF *SDKConfigSonFactory)NewSDKConfigNode(user *model.CaUser) *model.SDKConfigNode {
-	cacert, _ := dao.FindCACertByOrganizationID(user.OrganizationID)
+	cacert, _ := dao.FindTLSCACertByOrganizationID(user.OrganizationID)
 	port := 7051
 	if user.IsInOrdererOrg() {
 		port = 7050
 	}
-	return &model.SDKConfigNode{
+	ret := &model.SDKConfigNode{
 		URL: fmt.Sprintf("grpcs://%s:%d", user.GetURL(), port),
 		TLSCACerts: struct {
 			Pem string "yaml:\"pem\""
@@ -79,6 +82,15 @@ func (sdkCSF *SDKConfigSonFactory)NewSDKConfigNode(user *model.CaUser) *model.SD
 			Pem: cacert.Certification,
 		},
 	}
+	if user.ExternalURL != "" {
+		ret.URL = user.ExternalURL
+	}
+	if user.ExternalHostOverride != "" {
+		ret.GRPCOptions = map[string]interface{}{
+			"ssl-target-name-override": user.ExternalHostOverride,
+		}
+	}
+	return ret
 }
 
 func (sdkCSF *SDKConfigSonFactory)NewSDKConfigChannel(ch *model.Channel) *model.SDKConfigChannel {
@@ -122,6 +134,7 @@ func (sdkCSF *SDKConfigSonFactory)NewSDKConfigCA(org *model.Organization) *model
 	cacert, _ := dao.FindCACertByOrganizationID(org.ID)
 	return &model.SDKConfigCA{
 		URL: org.GetCAURLInK8S(),
+		CAName: org.ExternalCAName,
 		TLSCACerts: struct {
 			Pem []string "yaml:\"pem\""
 		}{Pem: []string{cacert.Certification}},
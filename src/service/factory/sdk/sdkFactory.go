@@ -74,7 +74,9 @@ func (sdkf *SDKFactory)newSDKConfigByNetworkID(netID int) *model.SDKConfig {
 
 	for _, org := range orgs {
 		sdkconfig.Organizations[org.GetName()] 			= sdkCSF.NewSDKConfigOrganization(&org)
-		sdkconfig.CertificateAuthorities[org.GetCAID()] = sdkCSF.NewSDKConfigCA(&org)
+		if org.HasCA() {
+			sdkconfig.CertificateAuthorities[org.GetCAID()] = sdkCSF.NewSDKConfigCA(&org)
+		}
 	}
 	for _, peer := range netPeers {
 		sdkconfig.Peers[peer.GetName()] = sdkCSF.NewSDKConfigNode(&peer)
@@ -90,7 +92,9 @@ func (sdkf *SDKFactory)newSDKConfigByOrganizationID(orgID int) *model.SDKConfig
 	sdkconfig 										:= sdkf.newCommonSDKConfigByNetworkID(org.NetworkID)
 	sdkconfig.Client 								= sdkCSF.NewSDKConfigClient(org)
 	sdkconfig.Organizations[org.GetName()] 			= sdkCSF.NewSDKConfigOrganization(org)
-	sdkconfig.CertificateAuthorities[org.GetCAID()] = sdkCSF.NewSDKConfigCA(org)
+	if org.HasCA() {
+		sdkconfig.CertificateAuthorities[org.GetCAID()] = sdkCSF.NewSDKConfigCA(org)
+	}
 
 	for _, peer := range peers {
 		sdkconfig.Peers[peer.GetName()] = sdkCSF.NewSDKConfigNode(&peer)
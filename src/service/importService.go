@@ -0,0 +1,373 @@
+package service
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"mictract/model/request"
+	"mictract/service/factory"
+	"mictract/service/factory/sdk"
+	"sort"
+	"strings"
+)
+
+// ParseConnectionProfile parses a connection profile in yaml or json
+func ParseConnectionProfile(raw []byte) (*model.ConnectionProfile, error) {
+	profile := &model.ConnectionProfile{}
+	if err := yaml.Unmarshal(raw, profile); err != nil {
+		return nil, errors.WithMessage(err, "fail to parse connection profile")
+	}
+	return profile, nil
+}
+
+// CheckImport checks that the connection profile and the admins are enough to import the network:
+// every organization with peers has an admin and all nodes of an organization share one tls ca cert.
+func CheckImport(profile *model.ConnectionProfile, admins []request.ImportAdminReq) error {
+	if len(profile.Orderers) < 1 {
+		return errors.New("no orderer in the connection profile")
+	}
+	if len(profile.Peers) < 1 {
+		return errors.New("no peer in the connection profile")
+	}
+
+	orderers := []*model.ConnectionProfileNode{}
+	for _, name := range sortedKeys(profile.Orderers) {
+		orderers = append(orderers, profile.Orderers[name])
+	}
+	if _, err := getSharedTLSCACert(orderers); err != nil {
+		return errors.WithMessage(err, "orderers")
+	}
+
+	hasAdmin := map[string]bool{}
+	for _, admin := range admins {
+		org, ok := profile.Organizations[admin.Organization]
+		if !ok {
+			return errors.New(fmt.Sprintf("organization %s is not in the connection profile", admin.Organization))
+		}
+		if len(org.Peers) < 1 {
+			return errors.New(fmt.Sprintf("organization %s has no peer", admin.Organization))
+		}
+		if _, err := getAdminName(admin); err != nil {
+			return err
+		}
+		hasAdmin[admin.Organization] = true
+	}
+
+	for orgName, org := range profile.Organizations {
+		if len(org.Peers) < 1 {
+			continue
+		}
+		if !hasAdmin[orgName] {
+			return errors.New(fmt.Sprintf("organization %s has no admin", orgName))
+		}
+		if org.Mspid == "" {
+			return errors.New(fmt.Sprintf("organization %s has no mspid", orgName))
+		}
+		peers := []*model.ConnectionProfileNode{}
+		for _, peerName := range org.Peers {
+			peer, ok := profile.Peers[peerName]
+			if !ok {
+				return errors.New(fmt.Sprintf("peer %s of %s is not in the connection profile", peerName, orgName))
+			}
+			peers = append(peers, peer)
+		}
+		if _, err := getSharedTLSCACert(peers); err != nil {
+			return errors.WithMessage(err, "peers of "+orgName)
+		}
+	}
+
+	return nil
+}
+
+// Import creates the records of an external network from its connection profile:
+// the ordererorg and orderers, every organization with peers (its ca, peers and the admin as system user)
+// and the channels. Nothing is started in k8s, the nodes keep running where they are.
+// At the end every channel is queried with the imported admins to make sure the crypto material works.
+// If anything fails, the records created here are removed again.
+func (ns *NetworkService) Import(profile *model.ConnectionProfile, ordererMSPID string, admins []request.ImportAdminReq) error {
+	global.Logger.Info(fmt.Sprintf("[Import %s]", ns.net.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Import %s] done!", ns.net.GetName()))
+
+	if !ns.net.External {
+		return errors.New(ns.net.GetName() + " is not an external network")
+	}
+	if err := CheckImport(profile, admins); err != nil {
+		return err
+	}
+
+	rb := NewRollback(fmt.Sprintf("import %s", ns.net.GetName()))
+	defer rb.Close()
+	rb.Add("delete imported records", ns.deleteImportedRecords)
+
+	cuf := factory.NewCaUserFactory()
+	cf := factory.NewCertificationFactory()
+
+	// 1. ordererorg and orderers
+	global.Logger.Info("1. create ordererorg and orderers")
+	ordOrg, err := factory.NewOrganizationFactory().NewExternalOrganization(ns.net.ID, true, "ordererorg", ordererMSPID, "", "")
+	if err != nil {
+		return err
+	}
+	ordererIDs := map[string]int{}
+	nodes := []*model.ConnectionProfileNode{}
+	for _, name := range sortedKeys(profile.Orderers) {
+		node := profile.Orderers[name]
+		orderer, err := cuf.NewExternalNodeCaUser(ordOrg.ID, ns.net.ID, "orderer", name,
+			getGRPCURL(node.URL), getHostOverride(node))
+		if err != nil {
+			return err
+		}
+		ordererIDs[name] = orderer.ID
+		nodes = append(nodes, node)
+	}
+	tlsCACert, _ := getSharedTLSCACert(nodes)
+	if _, err := cf.NewTLSCACertification(ordOrg, tlsCACert); err != nil {
+		return err
+	}
+
+	// 2. organizations with peers
+	global.Logger.Info("2. create organizations, peers and system users")
+	adminOf := map[string]request.ImportAdminReq{}
+	for _, admin := range admins {
+		adminOf[admin.Organization] = admin
+	}
+	orgIDOfPeer := map[string]int{}
+	orgIDs := []int{}
+	for _, orgName := range sortedKeys(profile.Organizations) {
+		profileOrg := profile.Organizations[orgName]
+		if len(profileOrg.Peers) < 1 {
+			continue
+		}
+
+		// 2.1 organization and its ca
+		var ca *model.ConnectionProfileCA
+		caName, caURL := "", ""
+		if len(profileOrg.CertificateAuthorities) > 0 {
+			if ca = profile.CertificateAuthorities[profileOrg.CertificateAuthorities[0]]; ca != nil {
+				caName, caURL = ca.CAName, ca.URL
+				if caName == "" {
+					caName = profileOrg.CertificateAuthorities[0]
+				}
+			}
+		}
+		org, err := factory.NewOrganizationFactory().
+			NewExternalOrganization(ns.net.ID, false, orgName, profileOrg.Mspid, caName, caURL)
+		if err != nil {
+			return err
+		}
+		orgIDs = append(orgIDs, org.ID)
+		if ca != nil && len(ca.TLSCACerts.Pem) > 0 {
+			if _, err := cf.NewCACertification(org, ca.TLSCACerts.Pem[0], ""); err != nil {
+				return err
+			}
+		}
+
+		// 2.2 peers
+		nodes := []*model.ConnectionProfileNode{}
+		for _, peerName := range profileOrg.Peers {
+			node := profile.Peers[peerName]
+			if _, err := cuf.NewExternalNodeCaUser(org.ID, ns.net.ID, "peer", peerName,
+				getGRPCURL(node.URL), getHostOverride(node)); err != nil {
+				return err
+			}
+			orgIDOfPeer[peerName] = org.ID
+			nodes = append(nodes, node)
+		}
+		tlsCACert, _ := getSharedTLSCACert(nodes)
+		if _, err := cf.NewTLSCACertification(org, tlsCACert); err != nil {
+			return err
+		}
+
+		// 2.3 the admin becomes the system user
+		admin := adminOf[orgName]
+		adminName, _ := getAdminName(admin)
+		adminUser, err := cuf.NewExternalAdminCaUser(org.ID, ns.net.ID, adminName)
+		if err != nil {
+			return err
+		}
+		if _, err := cf.NewCertification(adminUser, admin.Certificate, admin.PrivateKey, false); err != nil {
+			return err
+		}
+	}
+
+	// 3. channels
+	global.Logger.Info("3. create channels")
+	chs := []*model.Channel{}
+	for _, chName := range sortedKeys(profile.Channels) {
+		profileCh := profile.Channels[chName]
+		chOrgIDs := []int{}
+		for _, orgID := range orgIDs {
+			for peerName := range profileCh.Peers {
+				if orgIDOfPeer[peerName] == orgID {
+					chOrgIDs = append(chOrgIDs, orgID)
+					break
+				}
+			}
+		}
+		if len(chOrgIDs) < 1 {
+			return errors.New(fmt.Sprintf("no peer of the imported organizations in channel %s", chName))
+		}
+		chOrdererIDs := []int{}
+		for _, ordererName := range profileCh.Orderers {
+			if id, ok := ordererIDs[ordererName]; ok {
+				chOrdererIDs = append(chOrdererIDs, id)
+			}
+		}
+		if len(chOrdererIDs) < 1 {
+			for _, ordererName := range sortedKeys(profile.Orderers) {
+				chOrdererIDs = append(chOrdererIDs, ordererIDs[ordererName])
+			}
+		}
+		ch, err := factory.NewChannelFactory().NewExternalChannel(ns.net.ID, chName, chOrgIDs, chOrdererIDs)
+		if err != nil {
+			return err
+		}
+		chs = append(chs, ch)
+	}
+
+	// 4. query every channel with the imported admins
+	global.Logger.Info("4. query channels with the imported admins")
+	for _, ch := range chs {
+		if err := checkExternalChannel(ch); err != nil {
+			return err
+		}
+	}
+
+	// 5. update status
+	orgs, err := dao.FindAllOrganizationsInNetwork(ns.net.ID)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := dao.UpdateOrganizationStatusByID(org.ID, enum.StatusRunning); err != nil {
+			return err
+		}
+	}
+
+	rb.Commit()
+	return nil
+}
+
+// deleteImportedRecords is the undo of Import, the network itself is kept to show the error
+func (ns *NetworkService) deleteImportedRecords() error {
+	ns.deleteGlobalSvc()
+	for _, del := range []func(int) error{
+		dao.DeleteAllOrganizationsInNetwork,
+		dao.DeleteAllCaUserInNetwork,
+		dao.DeleteAllChannelsInNetwork,
+		dao.DeleteAllCertificationsInNetwork,
+	} {
+		if err := del(ns.net.ID); err != nil {
+			return err
+		}
+	}
+	return ns.net.RemoveAllFile()
+}
+
+// checkManaged refuses operations which need to create or delete nodes in k8s
+func (ns *NetworkService) checkManaged() error {
+	if ns.net.External {
+		return errors.New(fmt.Sprintf("%s is an external network, its nodes are not managed by mictract", ns.net.GetName()))
+	}
+	return nil
+}
+
+// checkExternalChannel queries the channel info from a peer of every organization in the channel
+func checkExternalChannel(ch *model.Channel) error {
+	for _, orgID := range ch.OrganizationIDs {
+		adminUser, err := dao.FindSystemUserInOrganization(orgID)
+		if err != nil {
+			return err
+		}
+		peers, err := dao.FindAllPeersInOrganization(orgID)
+		if err != nil {
+			return err
+		}
+		lc, err := sdk.NewSDKClientFactory().NewLedgerClient(adminUser, ch)
+		if err != nil {
+			return errors.WithMessage(err, "fail to get ledger client of "+adminUser.GetName())
+		}
+		if _, err := lc.QueryInfo(ledger.WithTargetEndpoints(peers[0].GetName())); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("%s fail to query %s from %s",
+				adminUser.GetName(), ch.GetName(), peers[0].GetName()))
+		}
+	}
+	return nil
+}
+
+// getSharedTLSCACert returns the tls ca cert of the nodes, which must be the same pem
+func getSharedTLSCACert(nodes []*model.ConnectionProfileNode) (string, error) {
+	pemStr := ""
+	for _, node := range nodes {
+		if node == nil || strings.TrimSpace(node.TLSCACerts.Pem) == "" {
+			return "", errors.New("tlsCACerts must be given as pem")
+		}
+		if pemStr != "" && strings.TrimSpace(pemStr) != strings.TrimSpace(node.TLSCACerts.Pem) {
+			return "", errors.New("nodes of an organization must share one tls ca cert")
+		}
+		pemStr = node.TLSCACerts.Pem
+	}
+	return pemStr, nil
+}
+
+// getAdminName returns admin.Name or the common name of its certificate
+func getAdminName(admin request.ImportAdminReq) (string, error) {
+	block, _ := pem.Decode([]byte(admin.Certificate))
+	if block == nil {
+		return "", errors.New(fmt.Sprintf("the certificate of %s's admin is not pem", admin.Organization))
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", errors.WithMessage(err, fmt.Sprintf("fail to parse the certificate of %s's admin", admin.Organization))
+	}
+	if admin.Name != "" {
+		return admin.Name, nil
+	}
+	if cert.Subject.CommonName == "" {
+		return "", errors.New(fmt.Sprintf("the certificate of %s's admin has no common name", admin.Organization))
+	}
+	return cert.Subject.CommonName, nil
+}
+
+func getGRPCURL(url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+	return "grpcs://" + url
+}
+
+func getHostOverride(node *model.ConnectionProfileNode) string {
+	for _, key := range []string{"ssl-target-name-override", "hostnameOverride"} {
+		if override, ok := node.GRPCOptions[key].(string); ok {
+			return override
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch m := m.(type) {
+	case map[string]*model.ConnectionProfileNode:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*model.ConnectionProfileOrganization:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*model.ConnectionProfileChannel:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"mictract/model/request"
+	"mictract/service"
+	"testing"
+	"time"
+)
+
+const testProfile = `
+name: test-network
+version: 1.0.0
+client:
+  organization: Org1
+organizations:
+  Org1:
+    mspid: Org1MSP
+    peers: [peer0.org1.example.com]
+    certificateAuthorities: [ca.org1.example.com]
+orderers:
+  orderer.example.com:
+    url: grpcs://localhost:7050
+    tlsCACerts:
+      pem: ORDERER_TLS_CA
+    grpcOptions:
+      ssl-target-name-override: orderer.example.com
+peers:
+  peer0.org1.example.com:
+    url: grpcs://localhost:7051
+    tlsCACerts:
+      pem: ORG1_TLS_CA
+channels:
+  mychannel:
+    orderers: [orderer.example.com]
+    peers:
+      peer0.org1.example.com:
+        endorsingPeer: true
+certificateAuthorities:
+  ca.org1.example.com:
+    url: https://localhost:7054
+    caName: ca-org1
+    tlsCACerts:
+      pem: [ORG1_CA]
+`
+
+func newTestAdminCert(t *testing.T, cn string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestCheckImport(t *testing.T) {
+	profile, err := service.ParseConnectionProfile([]byte(testProfile))
+	assert.NoError(t, err)
+	assert.Equal(t, "Org1MSP", profile.Organizations["Org1"].Mspid)
+	assert.Equal(t, "orderer.example.com", profile.Orderers["orderer.example.com"].GRPCOptions["ssl-target-name-override"])
+
+	admin := request.ImportAdminReq{
+		Organization: "Org1",
+		Certificate:  newTestAdminCert(t, "Admin@org1.example.com"),
+		PrivateKey:   "KEY",
+	}
+	assert.NoError(t, service.CheckImport(profile, []request.ImportAdminReq{admin}))
+
+	// every organization with peers needs an admin
+	assert.Error(t, service.CheckImport(profile, []request.ImportAdminReq{}))
+
+	// the admin must belong to an organization of the profile
+	admin.Organization = "Org2"
+	assert.Error(t, service.CheckImport(profile, []request.ImportAdminReq{admin}))
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/global"
+	"mictract/model"
+	"sync"
+	"time"
+)
+
+var ErrJobCanceled = errors.New("job canceled")
+
+// runningJobs keeps the cancel functions of jobs running in this process
+var runningJobs = struct {
+	sync.Mutex
+	cancels map[int]context.CancelFunc
+}{cancels: map[int]context.CancelFunc{}}
+
+type JobService struct {
+	job *model.Job
+	ctx context.Context
+	mu  sync.Mutex
+}
+
+func NewJobService(job *model.Job) *JobService {
+	return &JobService{
+		job: job,
+		ctx: context.Background(),
+	}
+}
+
+// Run executes fn in background under the job and records how it ends.
+// fn should call Step between its stages, which returns ErrJobCanceled once the job is canceled.
+//
+// job, _ := factory.NewJobFactory().NewJob(enum.JobAddOrg, net.ID, 0)
+// service.NewJobService(job).Run(func(jSvc *service.JobService) error { ... })
+func (jSvc *JobService) Run(fn func(jSvc *JobService) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jSvc.ctx = ctx
+
+	runningJobs.Lock()
+	runningJobs.cancels[jSvc.job.ID] = cancel
+	runningJobs.Unlock()
+
+	jSvc.job.Status = enum.StatusRunning
+	if err := dao.UpdateJobStatusByID(jSvc.job.ID, enum.StatusRunning); err != nil {
+		global.Logger.Error("fail to update job status", zap.Error(err))
+	}
+
+	go func() {
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New(fmt.Sprintf("panic: %v", r))
+			}
+			jSvc.finish(err)
+
+			runningJobs.Lock()
+			delete(runningJobs.cancels, jSvc.job.ID)
+			runningJobs.Unlock()
+			cancel()
+		}()
+
+		err = fn(jSvc)
+	}()
+}
+
+func (jSvc *JobService) finish(err error) {
+	status, message := enum.StatusSuccess, ""
+	switch {
+	case err == nil:
+	case errors.Cause(err) == ErrJobCanceled || jSvc.ctx.Err() != nil:
+		status = enum.StatusCanceled
+		message = err.Error()
+	default:
+		status = enum.StatusError
+		message = err.Error()
+	}
+
+	if status == enum.StatusSuccess {
+		global.Logger.Info(fmt.Sprintf("%s succeeded", jSvc.job.GetName()))
+	} else {
+		global.Logger.Error(fmt.Sprintf("%s %s", jSvc.job.GetName(), status), zap.Error(err))
+	}
+
+	jSvc.job.Status = status
+	jSvc.job.Error = message
+	if err := dao.FinishJobByID(jSvc.job.ID, status, message); err != nil {
+		global.Logger.Error("fail to finish job", zap.Error(err))
+	}
+}
+
+// Context is canceled when the job is canceled
+func (jSvc *JobService) Context() context.Context {
+	return jSvc.ctx
+}
+
+// Step appends a line to the job's step log.
+// It returns ErrJobCanceled instead if the job has been canceled.
+func (jSvc *JobService) Step(msg string) error {
+	if jSvc.ctx.Err() != nil {
+		return ErrJobCanceled
+	}
+	global.Logger.Info(fmt.Sprintf("[%s] %s", jSvc.job.GetName(), msg))
+
+	jSvc.mu.Lock()
+	defer jSvc.mu.Unlock()
+	jSvc.job.Steps = append(jSvc.job.Steps, fmt.Sprintf("%s %s", time.Now().Format("2006-01-02 15:04:05"), msg))
+	if err := dao.UpdateJobStepsByID(jSvc.job.ID, jSvc.job.Steps); err != nil {
+		global.Logger.Error("fail to update job steps", zap.Error(err))
+	}
+	return nil
+}
+
+// SetTargetID records the object the job created
+func (jSvc *JobService) SetTargetID(targetID int) {
+	jSvc.job.TargetID = targetID
+	if err := dao.UpdateJobTargetIDByID(jSvc.job.ID, targetID); err != nil {
+		global.Logger.Error("fail to update job target", zap.Error(err))
+	}
+}
+
+// Cancel asks a running job to stop at its next step.
+// Work already done by the job is not undone.
+func (jSvc *JobService) Cancel() error {
+	if jSvc.job.Status != enum.StatusPending && jSvc.job.Status != enum.StatusRunning {
+		return errors.New(fmt.Sprintf("%s has finished(%s)", jSvc.job.GetName(), jSvc.job.Status))
+	}
+
+	runningJobs.Lock()
+	cancel, ok := runningJobs.cancels[jSvc.job.ID]
+	runningJobs.Unlock()
+	if !ok {
+		// Not run by this process, eg: the server restarted before the job was marked
+		if ok, err := dao.FinishUnfinishedJobByID(jSvc.job.ID, enum.StatusCanceled, ErrJobCanceled.Error()); err != nil {
+			return err
+		} else if !ok {
+			return errors.New(fmt.Sprintf("%s has finished", jSvc.job.GetName()))
+		}
+		return nil
+	}
+	cancel()
+	return nil
+}
+
+// FailInterruptedJobs is called on startup.
+// Jobs left pending or running were interrupted by a restart, the goroutines running them are gone,
+// so they and the objects they were creating are marked as failed.
+func FailInterruptedJobs() {
+	jobs, err := dao.FindUnfinishedJobs()
+	if err != nil {
+		global.Logger.Error("fail to find unfinished jobs", zap.Error(err))
+		return
+	}
+
+	for _, job := range jobs {
+		global.Logger.Info(fmt.Sprintf("%s was interrupted by server restart", job.GetName()))
+		if err := dao.FinishJobByID(job.ID, enum.StatusError, "interrupted by server restart"); err != nil {
+			global.Logger.Error("fail to finish job", zap.Error(err))
+		}
+
+		if job.TargetID == 0 {
+			continue
+		}
+		switch job.Type {
+		case enum.JobCreateNetwork:
+			err = dao.UpdateNetworkStatusByID(job.TargetID, enum.StatusError)
+		case enum.JobAddOrg:
+			err = dao.UpdateOrganizationStatusByID(job.TargetID, enum.StatusError)
+		case enum.JobAddChannel:
+			err = dao.UpdateChannelStatusByID(job.TargetID, enum.StatusError)
+		case enum.JobCreateChaincode:
+			err = dao.UpdateChaincodeStatusByID(job.TargetID, enum.StatusError)
+		case enum.JobInvokeChaincode:
+			err = dao.UpdateTransactionStatusAndMessageByID(uint64(job.TargetID), enum.StatusError, "interrupted by server restart")
+		}
+		if err != nil {
+			global.Logger.Error("", zap.Error(err))
+		}
+	}
+}
@@ -1,26 +1,25 @@
 package service
 
 import (
-	"encoding/base64"
 	"fmt"
-	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
-	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	mConfig "mictract/config"
 	"mictract/dao"
 	"mictract/enum"
 	"mictract/global"
 	"mictract/model"
 	"mictract/model/kubernetes"
+	"mictract/service/configtx"
 	"mictract/service/factory"
 	"mictract/service/factory/sdk"
 	"os"
-	"path/filepath"
-	"time"
+	"strings"
 )
 
+// consortiumName must be the same as the one in configtx.yaml.tpl
+const consortiumName = "LLJConsortium"
+
 type NetworkService struct {
 	net *model.Network
 }
@@ -41,8 +40,12 @@ func (ns *NetworkService)Deploy() error {
 	global.Logger.Info(fmt.Sprintf("[Deploy %s]", ns.net.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[Deploy %s] done!", ns.net.GetName()))
 	global.Logger.Info("Deploy method is just creating a basic network containing only ordererorg(including 1 orderer)")
+	if err := ns.checkManaged(); err != nil {
+		return err
+	}
 
-	tools := kubernetes.Tools{}
+	rb := NewRollback(fmt.Sprintf("deploy %s", ns.net.GetName()))
+	defer rb.Close()
 
 	// 1. Start orderer ca and register system users and orderer nodes
 	global.Logger.Info("1. Start orderer ca and register system users and orderer nodes")
@@ -51,37 +54,69 @@ func (ns *NetworkService)Deploy() error {
 	if err != nil {
 		return err
 	}
+	rb.Add("delete ordererorg", func() error {
+		return dao.DeleteOrganizationByID(ordererOrg.ID)
+	})
 	if err := ordererOrgSvc.CreateBasicOrganizationEntity(); err != nil {
-		global.Logger.Error("fail to start ordererOrg", zap.Error(err))
+		return errors.WithMessage(err, "fail to start ordererOrg")
+	}
+	rb.Add("remove ordererorg entity and crypto", func() error {
+		ordererOrgSvc.RemoveAllEntity()
+		return ordererOrgSvc.RemoveAllCrypto()
+	})
+
+	// 2-3. render configtx.yaml and generate the genesis block of system-channel,
+	// there is no system-channel with channel participation, orderers join application channels one by one
+	if !ns.net.UsesChannelParticipation() {
+		if err := ns.generateGenesisBlock(rb); err != nil {
+			return err
+		}
 	}
 
+	// 4. start one orderer
+	global.Logger.Info("4. start one orderer")
+	if err := ordererOrgSvc.CreateNodeEntity(); err != nil {
+		return errors.WithMessage(err, "fail to start ordererOrg's node")
+	}
+
+	rb.Commit()
+	return nil
+}
+
+// generateGenesisBlock generates the genesis block of system-channel for the first orderer
+func (ns *NetworkService) generateGenesisBlock(rb *Rollback) error {
+	tools := kubernetes.Tools{}
+
 	// 2. render configtx.yaml
 	global.Logger.Info("2. Render configtx.yaml")
 	orderers, err := dao.FindAllOrderersInNetwork(ns.net.ID)
 	if err != nil {
 		return err
 	}
-	if err = orderers[0].RenderConfigtx(); err != nil {
+	ws, err := NewWorkspace(ns.net.ID, "system-channel")
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	if err = orderers[0].RenderConfigtx(ws.GetPath()); err != nil {
 		return err
 	}
 
 	// 3. generate the genesis block
 	global.Logger.Info("3. Generate the genesis block")
+	genesisBlockPath := fmt.Sprintf("/mictract/networks/net%d/genesis.block", ns.net.ID)
 	_, _, err = tools.ExecCommand("configtxgen",
-		"-configPath", fmt.Sprintf("/mictract/networks/net%d/", ns.net.ID),
+		"-configPath", ws.GetPath(),
 		"-profile", "Genesis",
 		"-channelID", "system-channel",
-		"-outputBlock", fmt.Sprintf("/mictract/networks/net%d/genesis.block", ns.net.ID),
+		"-outputBlock", genesisBlockPath,
 	)
 	if err != nil {
 		return err
 	}
-
-	// 4. start one orderer
-	global.Logger.Info("4. start one orderer")
-	if err := ordererOrgSvc.CreateNodeEntity(); err != nil {
-		global.Logger.Error("fail to start ordererOrg's node", zap.Error(err))
-	}
+	rb.Add("remove the genesis block", func() error {
+		return os.Remove(genesisBlockPath)
+	})
 
 	return nil
 }
@@ -110,6 +145,11 @@ func (ns *NetworkService)deleteGlobalSvc() {
 
 }
 
+// Delete removes everything of the network: k8s resources, chaincode directories,
+// database records and /mictract/networks/netN.
+// For an external network nothing in k8s is touched.
+// A failed step does not stop the following ones, all failures are reported in the returned error.
+// Resources that still remain can be found by FindOrphanObjects later.
 func (ns *NetworkService)Delete() error {
 	global.Logger.Info(fmt.Sprintf("[Delete %s]", ns.net.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[Delete %s] done!", ns.net.GetName()))
@@ -118,6 +158,12 @@ func (ns *NetworkService)Delete() error {
 	var ccs  []model.Chaincode
 	var err  error
 
+	failures := []string{}
+	fail := func(step string, err error) {
+		global.Logger.Error("fail to "+step, zap.Error(err))
+		failures = append(failures, fmt.Sprintf("%s: %s", step, err.Error()))
+	}
+
 	// 0.
 	ns.deleteGlobalSvc()
 
@@ -125,19 +171,34 @@ func (ns *NetworkService)Delete() error {
 	global.Logger.Info("1. remove organizations entity")
 	orgs, err = dao.FindAllOrganizationsInNetwork(ns.net.ID)
 	if err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("find organizations", err)
 	}
 	for _, org := range orgs {
-		NewOrganizationService(&org).RemoveAllEntity()
+		if !ns.net.External {
+			NewOrganizationService(&org).RemoveAllEntity()
+		}
 	}
 
-	// 2. remove chaincode entity
+	// 2. remove chaincode entity and chaincode directories
 	global.Logger.Info("2. remove chaincode entity")
 	if ccs, err = dao.FindAllChaincodesInNetwork(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("find chaincodes", err)
 	}
 	for _, cc := range ccs {
-		NewChaincodeService(&cc).RemoveEntity()
+		if !ns.net.External {
+			NewChaincodeService(&cc).RemoveEntity()
+		}
+		if err := os.RemoveAll(cc.GetCCPath()); err != nil {
+			fail("remove "+cc.GetCCPath(), err)
+		}
+	}
+
+	// 2.1 remove k8s resources missed by the steps above (configmaps, services, ingresses...)
+	global.Logger.Info("2.1 remove all k8s resources labelled with the network")
+	if !ns.net.External {
+		if err := kubernetes.DeleteAllObjectsInNetwork(ns.net.ID); err != nil {
+			fail("remove k8s resources", err)
+		}
 	}
 
 	// 3.  Delete all database records related to the network
@@ -146,37 +207,54 @@ func (ns *NetworkService)Delete() error {
 	// 3.1 delete from organizations where network_id = id
 	global.Logger.Info("3.1 delete from organizations where network_id = id")
 	if err := dao.DeleteAllOrganizationsInNetwork(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("delete organizations", err)
 	}
 	// 3.2 delete from ca_users where network_id = id
 	global.Logger.Info("3.2 delete from ca_users where network_id = id")
 	if err := dao.DeleteAllCaUserInNetwork(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("delete ca users", err)
 	}
 	// 3.3 delete from chaincode where network_id = id
 	global.Logger.Info("3.3 delete from chaincode where network_id = id")
 	if err:= dao.DeleteAllChaincodesInNetwork(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("delete chaincodes", err)
 	}
 	// 3.4 delete from channels where network_id = id
 	global.Logger.Info("3.4 delete from channels where network_id = id")
 	if err := dao.DeleteAllChannelsInNetwork(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("delete channels", err)
 	}
 	// 3.5 delete from networks where id = id
 	global.Logger.Info("3.5 delete from networks where id = id")
 	if err := dao.DeleteNetworkByID(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("delete network", err)
 	}
-	// 3.6 delete from network where network_id = id
-	global.Logger.Info("3.6 delete from network where network_id = id")
+	// 3.6 delete from certifications where network_id = id
+	global.Logger.Info("3.6 delete from certifications where network_id = id")
 	if err := dao.DeleteAllCertificationsInNetwork(ns.net.ID); err != nil {
-		global.Logger.Error("", zap.Error(err))
+		fail("delete certifications", err)
+	}
+	// 3.7 delete from jobs where network_id = id
+	global.Logger.Info("3.7 delete from jobs where network_id = id")
+	if err := dao.DeleteAllJobsInNetwork(ns.net.ID); err != nil {
+		fail("delete jobs", err)
+	}
+	// 3.8 delete from config_updates where network_id = id
+	global.Logger.Info("3.8 delete from config_updates where network_id = id")
+	if err := dao.DeleteAllConfigUpdatesInNetwork(ns.net.ID); err != nil {
+		fail("delete config updates", err)
 	}
 
-	// 4. TODO: RemoveAllFile
-	// os.RemoveAll("/mictract/network/net%d")
+	// 4. remove /mictract/networks/netN
+	global.Logger.Info(fmt.Sprintf("4. remove /mictract/networks/%s", ns.net.GetName()))
+	if err := ns.net.RemoveAllFile(); err != nil {
+		fail("remove network files", err)
+	}
 
+	if len(failures) > 0 {
+		return errors.New(fmt.Sprintf("%s is not deleted completely: %s",
+			ns.net.GetName(), strings.Join(failures, "; ")))
+	}
 	return nil
 }
 
@@ -202,92 +280,94 @@ func (ns *NetworkService)GetAllAdminSigningIdentities() ([]msp.SigningIdentity,
 func (ns *NetworkService)AddOrgToConsortium(orgID int) error {
 	global.Logger.Info(fmt.Sprintf("[Add org%d to Consortium(Write to system-channel)]", orgID))
 	defer global.Logger.Info(fmt.Sprintf("[Add org%d to Consortium(Write to system-channel)] done!", orgID))
-
-	orderers, err := dao.FindAllOrderersInNetwork(ns.net.ID)
-	if err != nil {
-		return err
+	if ns.net.UsesChannelParticipation() {
+		global.Logger.Info("no consortium without system-channel, skip")
+		return nil
 	}
+
 	org, err := dao.FindOrganizationByID(orgID)
 	if err != nil {
 		return err
 	}
-	ordOrg, err := dao.FindOrdererOrganizationInNetwork(ns.net.ID)
-	if err != nil {
-		return err
-	}
-	ordAdminUser, err := dao.FindSystemUserInOrganization(ordOrg.ID)
-	if err!= nil {
-		return err
-	}
 
-	// 1. Obtaining channel config
-	global.Logger.Info("1. Obtaining channel config")
 	sysch := factory.NewChannelFactory().NewSystemChannel(ns.net.ID)
-	if err :=NewChannelService(sysch).GetAndStoreConfig(); err != nil {
-		return err
-	}
+	syschSvc := NewChannelService(sysch)
 
-	// 2. generate configtx.yaml
-	global.Logger.Info("2. Generate configtx.yaml")
-	configtxFile, err := os.Create(filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "configtx.yaml"))
-	if err != nil {
-		return errors.WithMessage(err, "fail to open configtx.yaml")
-	}
-	_, err = configtxFile.WriteString(org.GetConfigtxFile())
+	// 1. build org definition
+	global.Logger.Info("1. Build org definition")
+	orgDef, err := NewOrganizationService(org).GetConfigtxOrganization()
 	if err != nil {
-		return errors.WithMessage(err, "fail to write configtx.yaml")
+		return err
 	}
 
-	// 3. generate org_update_in_envelope.pb
-	global.Logger.Info("3. Generate org_update_in_envelope.pb")
-	tools := kubernetes.Tools{}
-	_, _, err = tools.ExecCommand(
-		filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"),
-		"addOrgToConsortium",
-		"system-channel",
-		org.GetMSPID())
+	signs, err := syschSvc.getOrdererAdminSigningIdentity()
 	if err != nil {
-		return errors.WithMessage(err, "fail to exec addorg.sh")
+		return err
 	}
 
-	sign, err := NewOrganizationService(ordOrg).GetAdminSigningIdentity()
-	if err != nil {
-		return errors.WithMessage(err, "ordererAdmin fail to sign")
-	}
+	// 2. update system-channel config
+	global.Logger.Info("2. Add org definition to the consortium")
+	return syschSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.AddConsortiumOrg(consortiumName, orgDef)
+	}, signs)
+}
+
+// RemoveOrgFromConsortium is the inverse of AddOrgToConsortium,
+// the organization must have been removed from all channels.
+func (ns *NetworkService) RemoveOrgFromConsortium(orgID int) error {
+	global.Logger.Info(fmt.Sprintf("[Remove org%d from Consortium(Write to system-channel)]", orgID))
+	defer global.Logger.Info(fmt.Sprintf("[Remove org%d from Consortium(Write to system-channel)] done!", orgID))
 
-	// 4. update org_update_in_envelope.pb
-	global.Logger.Info("4. update org_update_in_envelope.pb")
-	envelopeFile, err := os.Open(filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "org_update_in_envelope.pb"))
+	org, err := dao.FindOrganizationByID(orgID)
 	if err != nil {
 		return err
 	}
-	defer envelopeFile.Close()
+	if org.NetworkID != ns.net.ID || org.IsOrdererOrganization() {
+		return errors.New(fmt.Sprintf("%s is not a peer organization of %s", org.GetName(), ns.net.GetName()))
+	}
 
-	rc, err := sdk.NewSDKClientFactory().NewResmgmtClient(ordAdminUser)
+	// 1. check
+	global.Logger.Info("1. Check channels of the org")
+	chs, err := dao.FindAllChannelsOfOrganization(org)
 	if err != nil {
 		return err
 	}
-
-	req := resmgmt.SaveChannelRequest{
-		ChannelID:         "system-channel",
-		ChannelConfig:     envelopeFile,
-		SigningIdentities: []msp.SigningIdentity{sign},
+	if len(chs) > 0 {
+		names := []string{}
+		for _, ch := range chs {
+			names = append(names, ch.GetName())
+		}
+		return errors.New(fmt.Sprintf("%s is still in channels: %s", org.GetName(), strings.Join(names, ", ")))
 	}
-	_, err = rc.SaveChannel(
-		req,
-		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
-		resmgmt.WithOrdererEndpoint(orderers[0].GetName()))
+	if ns.net.UsesChannelParticipation() {
+		global.Logger.Info("no consortium without system-channel, skip")
+		return nil
+	}
+
+	sysch := factory.NewChannelFactory().NewSystemChannel(ns.net.ID)
+	syschSvc := NewChannelService(sysch)
+	signs, err := syschSvc.getOrdererAdminSigningIdentity()
 	if err != nil {
-		return errors.WithMessage(err, "fail to update channel config")
+		return err
 	}
 
-	return nil
+	// 2. update system-channel config
+	global.Logger.Info("2. Remove org definition from the consortium")
+	return syschSvc.applyConfigUpdate(func(ctx *configtx.ConfigTx) error {
+		return ctx.RemoveConsortiumOrg(consortiumName, org.GetMSPID())
+	}, signs)
 }
 
 // AddOrg creates an organizational entity
 func (ns *NetworkService) AddOrg(nickname string) (*model.Organization, error) {
 	global.Logger.Info(fmt.Sprintf("[Add new org to %s]", ns.net.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[Add new org to %s] done!", ns.net.GetName()))
+	if err := ns.checkManaged(); err != nil {
+		return &model.Organization{}, err
+	}
+
+	rb := NewRollback(fmt.Sprintf("add new org to %s", ns.net.GetName()))
+	defer rb.Close()
 
 	// 1. new model.organization
 	global.Logger.Info("1. new model.organization")
@@ -295,32 +375,40 @@ func (ns *NetworkService) AddOrg(nickname string) (*model.Organization, error) {
 	if err != nil {
 		return &model.Organization{}, err
 	}
+	rb.Add(fmt.Sprintf("delete %s", org.GetName()), func() error {
+		return dao.DeleteOrganizationByID(org.ID)
+	})
 	orgSvc := NewOrganizationService(org)
 
 	// 2. create org entity(ca, peer) and system user
 	global.Logger.Info(fmt.Sprintf("2. create %s entity(ca, peer) and system user", org.GetName()))
 	if err := orgSvc.CreateBasicOrganizationEntity(); err != nil {
-		dao.UpdateOrganizationStatusByID(org.ID, enum.StatusError)
 		return &model.Organization{}, err
 	}
+	rb.Add(fmt.Sprintf("remove %s entity and crypto", org.GetName()), func() error {
+		orgSvc.RemoveAllEntity()
+		return orgSvc.RemoveAllCrypto()
+	})
 	if err := orgSvc.CreateNodeEntity(); err != nil {
-		dao.UpdateOrganizationStatusByID(org.ID, enum.StatusError)
 		return &model.Organization{}, err
 	}
 
 	// 3. Update organization to Consortium
 	global.Logger.Info("3. Update organization to Consortium")
 	if err := ns.AddOrgToConsortium(org.ID); err != nil {
-		dao.UpdateOrganizationStatusByID(org.ID, enum.StatusError)
 		return &model.Organization{}, err
 	}
 
+	rb.Commit()
 	return org, nil
 }
 
 func (ns *NetworkService) AddChannel(orgIDs []int, nickname string) (*model.Channel, error) {
 	global.Logger.Info(fmt.Sprintf("[Add new channel to %s]", ns.net.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[Add new channel to %s] done!", ns.net.GetName()))
+	if err := ns.checkManaged(); err != nil {
+		return &model.Channel{}, err
+	}
 
 	// 1. new model.Channel
 	global.Logger.Info("1. new model.Channel")
@@ -337,28 +425,71 @@ func (ns *NetworkService) AddChannel(orgIDs []int, nickname string) (*model.Chan
 
 	// 2. render configtx.yaml
 	global.Logger.Info("2. render configtx.yaml")
-	if err := chSvc.RenderConfigtx(); err != nil {
+	ws, err := NewWorkspace(ns.net.ID, ch.GetName())
+	if err != nil {
+		return ch, err
+	}
+	defer ws.Close()
+	if ns.net.UsesChannelParticipation() {
+		err = chSvc.RenderParticipationConfigtx(ws.GetPath())
+	} else {
+		err = chSvc.RenderConfigtx(ws.GetPath())
+	}
+	if err != nil {
 		return ch, errors.WithMessage(err, "fail to render configtx.yaml")
 	}
 
-	// 3. generate channel.tx
-	global.Logger.Info(fmt.Sprintf("3. generate %s.tx", ch.GetName()))
 	tools := kubernetes.Tools{}
-	global.Logger.Info("generate a default channel")
-	_, _, err = tools.ExecCommand("configtxgen",
-		"-configPath", fmt.Sprintf("/mictract/networks/net%d/", ns.net.ID),
-		"-profile", "NewChannel",
-		"-channelID", ch.GetName(),
-		"-outputCreateChannelTx", fmt.Sprintf("/mictract/networks/net%d/channel%d.tx", ns.net.ID, ch.ID),
-	)
-	if err != nil {
-		return ch, err
-	}
+	if ns.net.UsesChannelParticipation() {
+		// 3. generate the genesis block of the channel
+		global.Logger.Info(fmt.Sprintf("3. generate %s.block", ch.GetName()))
+		genesisBlockPath := ws.Join(ch.GetName() + ".block")
+		configtxgen := tools.ExecCommand
+		if ns.net.Consensus == enum.ConsensusBFT {
+			configtxgen = tools.ExecCommandV3
+		}
+		_, _, err = configtxgen("configtxgen",
+			"-configPath", ws.GetPath(),
+			"-profile", "NewChannel",
+			"-channelID", ch.GetName(),
+			"-outputBlock", genesisBlockPath,
+		)
+		if err != nil {
+			return ch, err
+		}
 
-	// 4. subbmit create channel tx
-	global.Logger.Info("4. subbmit create channel tx")
-	if err := chSvc.CreateChannel(orderers[0].GetName()); err != nil {
-		return ch, err
+		// 4. join all orderers to the channel
+		global.Logger.Info("4. join all orderers to the channel")
+		if err := chSvc.CreateChannelWithParticipation(orderers, genesisBlockPath); err != nil {
+			return ch, err
+		}
+		ordererIDs := []int{}
+		for _, orderer := range orderers {
+			ordererIDs = append(ordererIDs, orderer.ID)
+		}
+		ch.OrdererIDs = ordererIDs
+		if err := dao.UpdateOrdererIDs(ch.ID, ordererIDs); err != nil {
+			return ch, err
+		}
+	} else {
+		// 3. generate channel.tx
+		global.Logger.Info(fmt.Sprintf("3. generate %s.tx", ch.GetName()))
+		channelTxPath := ws.Join(ch.GetName() + ".tx")
+		_, _, err = tools.ExecCommand("configtxgen",
+			"-configPath", ws.GetPath(),
+			"-profile", "NewChannel",
+			"-channelID", ch.GetName(),
+			"-outputCreateChannelTx", channelTxPath,
+		)
+		if err != nil {
+			return ch, err
+		}
+
+		// 4. subbmit create channel tx
+		global.Logger.Info("4. subbmit create channel tx")
+		if err := chSvc.CreateChannel(orderers[0].GetName(), channelTxPath); err != nil {
+			return ch, err
+		}
 	}
 
 	// 5. all peer in first org join channel
@@ -377,50 +508,11 @@ func (ns *NetworkService) AddChannel(orgIDs []int, nickname string) (*model.Chan
 		return ch, err
 	}
 
-	// WTF? If you don’t wait, you will get an outdated configuration and return an error
-	time.Sleep(5 * time.Second)
-
-	// 6. Dynamically add remaining organizations to the channel
-	global.Logger.Info("6. Dynamically add remaining organizations to the channel")
+	// 6. Dynamically add remaining organizations and their peers to the channel
+	global.Logger.Info("6. Dynamically add remaining organizations and their peers to the channel")
 	for i := 1; i < len(orgIDs); i++ {
 		global.Logger.Info(fmt.Sprintf(" ┗ add org%d to channel%d", orgIDs[i], ch.ID))
-		// WTF? If you don’t wait, you will get an outdated configuration and return an error
-		time.Sleep(5 * time.Second)
-		if err := chSvc.AddOrg(orgIDs[i]); err != nil {
-			return ch, err
-		}
-	}
-	// bug: orderer处理更新配置文件需要时间, 加入通道操作在orderer还每处理完通道配置更新交易就提交上去，
-	//      会导致身份识别错误。注意这个玄学问题
-	time.Sleep(5 * time.Second)
-
-	// 7. Dynamically join remaining peer to the channel
-	/*
-		"Tried joining channel channel3 but our org( org2MSP ), isn't among the orgs of the channel: [org3MSP] , aborting."
-		If the above error occurs in the peer container
-		just fuck blockchain
-	*/
-	global.Logger.Info("7. Dynamically join remaining peer to the channel")
-	for i := 1; i < len(orgIDs); i++ {
-		global.Logger.Info("fuck fabric !!!")
-		time.Sleep(5 * time.Second)
-
-		peers, err := dao.FindAllPeersInOrganization(orgIDs[i])
-		if err != nil {
-			global.Logger.Error("", zap.Error(err))
-			return ch, err
-		}
-		for _, peer := range peers {
-			global.Logger.Info(fmt.Sprintf("%s join channel", peer.GetName()))
-			if err := NewCaUserService(&peer).JoinChannel(
-					ch.ID,
-					orderers[0].GetName()); err != nil {
-					global.Logger.Error(fmt.Sprintf("%s fail to join channel%d", peer.GetName(), ch.ID), zap.Error(err))
-				return ch, err
-			}
-		}
-		global.Logger.Info(fmt.Sprintf(" update anchors(org%d)", orgIDs[i]))
-		if err := chSvc.UpdateAnchors(orgIDs[i]); err != nil {
+		if err := chSvc.AddOrgAndJoinPeers(orgIDs[i]); err != nil {
 			return ch, err
 		}
 	}
@@ -428,149 +520,227 @@ func (ns *NetworkService) AddChannel(orgIDs []int, nickname string) (*model.Chan
 	return ch, nil
 }
 
-// AddOrderers
-// consensus must be "etcdraft"
-func (ns *NetworkService)AddOrderersToSystemChannel() error {
+// AddOrderer starts a new orderer and adds it to the consenters of system-channel
+// and every application channel.
+// consensus must be "etcdraft", or "BFT" before any application channel is created,
+// since the consenters of BFT channels are fixed in their genesis blocks.
+func (ns *NetworkService)AddOrderer() (*model.CaUser, error) {
 	global.Logger.Info(fmt.Sprintf("[Add Orderer to %s(system-channel)]", ns.net.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[Add Orderer to %s(system-channel)] done!", ns.net.GetName()))
+	if err := ns.checkManaged(); err != nil {
+		return nil, err
+	}
+	if ns.net.Consensus != "etcdraft" && ns.net.Consensus != enum.ConsensusBFT {
+		return nil, errors.New("only etcdraft and BFT support more than one orderer")
+	}
+	if ns.net.Consensus == enum.ConsensusBFT {
+		chs, err := dao.FindAllChannelsInNetwork(ns.net.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(chs) != 0 {
+			return nil, errors.New("orderers can't be added to BFT channels")
+		}
+	}
 
 	ch := factory.NewChannelFactory().NewSystemChannel(ns.net.ID)
 	chSvc := NewChannelService(ch)
-	orderers, err := dao.FindAllOrderersInNetwork(ns.net.ID)
-	if err != nil {
-		return err
-	}
 	ordOrg, err := dao.FindOrdererOrganizationInNetwork(ns.net.ID)
 	if err != nil {
-		return err
-	}
-	adminUser, err := dao.FindSystemUserInOrganization(ordOrg.ID)
-	if err != nil {
-		return err
+		return nil, err
 	}
 
+	rb := NewRollback(fmt.Sprintf("add orderer to %s", ns.net.GetName()))
+	defer rb.Close()
+
 	// 1. new model.CaUser (orderer)
 	global.Logger.Info("1. new model.CaUser (orderer)")
 	user, err := factory.NewCaUserFactory().NewOrdererCaUser(ordOrg.ID, ns.net.ID, "orderer1")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	userSvc := NewCaUserService(user)
+	rb.Add("remove "+user.GetName(), userSvc.removeCredentials)
 
-	// 2. generate config_block.pb
-	global.Logger.Info("2. Get and Store system-channel config")
-	if err := chSvc.GetAndStoreConfig(); err != nil {
-		return err
-	}
-
-	// 3. regiester new orderer
-	global.Logger.Info("3. regiester new orderer")
+	// 2. regiester new orderer
+	global.Logger.Info("2. regiester new orderer")
 	mspClient, err := sdk.NewSDKClientFactory().NewMSPClient(ordOrg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := userSvc.Register(mspClient); err != nil {
-		return err
+		return nil, err
 	}
 
-	// 4. enroll
-	global.Logger.Info("4. Enroll new orderer")
+	// 3. enroll
+	global.Logger.Info("3. Enroll new orderer")
 	if err := userSvc.Enroll(mspClient, true); err != nil {
-		return err
+		return nil, err
 	}
 	if err := userSvc.Enroll(mspClient, false); err != nil {
-		return err
+		return nil, err
+	}
+
+	// 4. create orderer entity
+	global.Logger.Info("4. create orderer entity")
+	orderer := kubernetes.NewOrderer(ns.net.ID, user.ID)
+	orderer.ChannelParticipation = ns.net.UsesChannelParticipation()
+	orderer.Consensus = ns.net.Consensus
+	rb.Add("delete "+user.GetName()+" entity", func() error {
+		orderer.Delete()
+		return nil
+	})
+	if err := orderer.AwaitableCreate(); err != nil {
+		return nil, err
+	}
+
+	// 5. add the orderer to consenters and orderer addresses
+	global.Logger.Info("5. Update system-channel config")
+	if ns.net.UsesChannelParticipation() {
+		global.Logger.Info("no system-channel, skip")
+	} else {
+		if err := chSvc.AddOrderers(*user); err != nil {
+			return nil, err
+		}
+		rb.Add("remove "+user.GetName()+" from system-channel", func() error {
+			return chSvc.RemoveOrderer(*user)
+		})
 	}
 
-	// 5. create orderer entity
-	global.Logger.Info("5. create orderer entity")
-	if err := kubernetes.NewOrderer(ns.net.ID, user.ID).AwaitableCreate(); err != nil {
-		return err
+	// 6. the same for every application channel
+	global.Logger.Info("6. Update application channels config")
+	chs, err := dao.FindAllChannelsInNetwork(ns.net.ID)
+	if err != nil {
+		return nil, err
 	}
-
-	// 6. generate ord1.json
-	global.Logger.Info("6. generate ord1.json")
-	st := `[`
-	for _, orderer := range orderers {
-		tlscert, _ := dao.FindCertByUserID(orderer.ID, true)
-		st += `{"client_tls_cert":"` + base64.StdEncoding.EncodeToString([]byte(tlscert.Certification)) +
-			`","host":"` + orderer.GetURL() +
-			`","port":7050,` +
-			`"server_tls_cert":"` + base64.StdEncoding.EncodeToString([]byte(tlscert.Certification)) + `"},`
+	for i := range chs {
+		ch := chs[i]
+		appChSvc := NewChannelService(&ch)
+		if err := appChSvc.AddOrderers(*user); err != nil {
+			return nil, errors.WithMessage(err, "fail to update "+ch.GetName())
+		}
+		rb.Add("remove "+user.GetName()+" from "+ch.GetName(), func() error {
+			return appChSvc.RemoveOrderer(*user)
+		})
+		if err := dao.UpdateOrdererIDs(ch.ID, append(ch.OrdererIDs, user.ID)); err != nil {
+			return nil, err
+		}
+		// without system-channel the orderer has to be told about the channel,
+		// it catches up from the latest config block which contains itself as a consenter
+		if ns.net.UsesChannelParticipation() {
+			block, err := appChSvc.GetConfigBlockFromOrderer()
+			if err != nil {
+				return nil, err
+			}
+			if err := appChSvc.JoinOrderer(user, block); err != nil {
+				return nil, err
+			}
+		}
 	}
-	st = st[:len(st) - 1]
-	st += "]"
-	f1, err := os.Create(filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "ord1.json"))
-	if err != nil {
+
+	rb.Commit()
+	return user, nil
+}
+
+// RemoveOrderer removes the orderer from the consenters of every application channel and system-channel,
+// then deletes its entity, revokes its certificates and deletes them.
+// consensus must be "etcdraft" and the orderer can not be the last one.
+func (ns *NetworkService) RemoveOrderer(ordererID int) error {
+	global.Logger.Info(fmt.Sprintf("[Remove orderer%d from %s]", ordererID, ns.net.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[Remove orderer%d from %s] done!", ordererID, ns.net.GetName()))
+	if err := ns.checkManaged(); err != nil {
 		return err
 	}
-	if _, err = f1.WriteString(st); err != nil {
-		return err
+	if ns.net.Consensus != "etcdraft" {
+		return errors.New("only etcdraft supports removing orderers")
 	}
-	f1.Close()
 
-	// 7. generate ord2.json
-	global.Logger.Info("7. generate ord2.json")
-	st = `[`
-	for _, orderer := range orderers {
-		st += `"` + orderer.GetURL() + `",`
-	}
-	st = st[:len(st) - 1]
-	st += "]"
-	f2, err := os.Create(filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "ord2.json"))
+	// 1. check
+	orderer, err := dao.FindCaUserByID(ordererID)
 	if err != nil {
 		return err
 	}
-	if _, err = f2.WriteString(st); err != nil {
+	if orderer.Type != "orderer" || orderer.NetworkID != ns.net.ID {
+		return errors.New(fmt.Sprintf("%s is not an orderer of %s", orderer.GetName(), ns.net.GetName()))
+	}
+	orderers, err := dao.FindAllOrderersInNetwork(ns.net.ID)
+	if err != nil {
 		return err
 	}
-	f2.Close()
+	rest := []int{}
+	for _, o := range orderers {
+		if o.ID != ordererID {
+			rest = append(rest, o.ID)
+		}
+	}
+	if len(rest) == 0 {
+		return errors.New("can not remove the last orderer")
+	}
 
-	// 8. call addorg.sh to generate org_update_in_envelope.pb
-	global.Logger.Info("8. call addorg.sh to generate org_update_in_envelope.pb")
-	tools := kubernetes.Tools{}
-	_, _, err = tools.ExecCommand(
-		filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "addorg.sh"),
-		"addOrderers",
-		"system-channel",
-	)
+	// 2. application channels, updates go to the other orderers
+	global.Logger.Info("2. Update application channels config")
+	chs, err := dao.FindAllChannelsInNetwork(ns.net.ID)
 	if err != nil {
-		return errors.WithMessage(err, "fail to exec addorg.sh")
+		return err
+	}
+	for i := range chs {
+		ch := chs[i]
+		ordererIDs := []int{}
+		for _, id := range ch.OrdererIDs {
+			if id != ordererID {
+				ordererIDs = append(ordererIDs, id)
+			}
+		}
+		if len(ordererIDs) == len(ch.OrdererIDs) {
+			// the channel was created before the orderer joined, look into the config anyway
+			global.Logger.Info(fmt.Sprintf("%s is not recorded in %s", orderer.GetName(), ch.GetName()))
+		}
+		if len(ordererIDs) == 0 {
+			ordererIDs = rest[:1]
+		}
+		ch.OrdererIDs = ordererIDs
+		if err := dao.UpdateOrdererIDs(ch.ID, ordererIDs); err != nil {
+			return err
+		}
+		consenters, err := NewChannelService(&ch).GetConsenters()
+		if err != nil {
+			return errors.WithMessage(err, "fail to get consenters of "+ch.GetName())
+		}
+		for _, consenter := range consenters {
+			if consenter.Host == orderer.GetURL() {
+				if err := NewChannelService(&ch).RemoveOrderer(*orderer); err != nil {
+					return errors.WithMessage(err, "fail to update "+ch.GetName())
+				}
+				break
+			}
+		}
 	}
 
-	// 9. sign for org_update_in_envelope.pb and update it
-	global.Logger.Info("9. sign for org_update_in_envelope.pb and update it")
-	signs := []msp.SigningIdentity{}
-	adminIdentity, err := mspClient.GetSigningIdentity(adminUser.GetName())
-	if err != nil {
-		return errors.WithMessage(err, "ordererAdmin fail to sign")
+	// 3. system-channel
+	global.Logger.Info("3. Update system-channel config")
+	if !ns.net.UsesChannelParticipation() {
+		sysCh := factory.NewChannelFactory().NewSystemChannel(ns.net.ID)
+		sysCh.OrdererIDs = rest
+		if err := NewChannelService(sysCh).RemoveOrderer(*orderer); err != nil {
+			return errors.WithMessage(err, "fail to update system-channel")
+		}
 	}
-	signs = append(signs, adminIdentity)
 
-	// 10. update org_update_in_envelope.pb
-	global.Logger.Info("10. update org_update_in_envelope.pb...")
-	envelopeFile, err := os.Open(filepath.Join(mConfig.LOCAL_SCRIPTS_PATH, "addorg", "org_update_in_envelope.pb"))
+	// 4. stop the orderer
+	global.Logger.Info("4. remove orderer entity")
+	kubernetes.NewOrderer(ns.net.ID, orderer.ID).Delete()
+
+	// 5. revoke certificates, otherwise the orderer is still a member of the orderer organization
+	global.Logger.Info("5. revoke certificates of " + orderer.GetName())
+	ordOrg, err := dao.FindOrganizationByID(orderer.OrganizationID)
 	if err != nil {
 		return err
 	}
-	defer envelopeFile.Close()
-
-	resmgmtClient, err := sdk.NewSDKClientFactory().NewResmgmtClient(adminUser)
-	if err != nil {
+	if err := NewOrganizationService(ordOrg).revoke(orderer, "cessationofoperation"); err != nil {
 		return err
 	}
 
-	req := resmgmt.SaveChannelRequest{
-		ChannelID:         "system-channel",
-		ChannelConfig:     envelopeFile,
-		SigningIdentities: signs,
-	}
-	_, err = resmgmtClient.SaveChannel(
-		req,
-		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
-		resmgmt.WithOrdererEndpoint(orderers[0].GetName()))
-	if err != nil {
-		return errors.WithMessage(err, "fail to update channel config")
-	}
-	return nil
-}
\ No newline at end of file
+	// 6. remove certificates
+	global.Logger.Info("6. remove orderer certificates")
+	return NewCaUserService(orderer).removeCredentials()
+}
@@ -11,6 +11,7 @@ import (
 	"mictract/global"
 	"mictract/model"
 	"mictract/model/kubernetes"
+	"mictract/service/configtx"
 	"mictract/service/factory"
 	"mictract/service/factory/sdk"
 	"os"
@@ -117,18 +118,211 @@ func (orgSvc *OrganizationService) GenerateOrgMSP() error {
 	return nil
 }
 
+// GetConfigtxOrganization reads the organization msp directory
+// to build the organization definition used in channel configs.
+func (orgSvc *OrganizationService) GetConfigtxOrganization() (configtx.Organization, error) {
+	mspDir := orgSvc.org.GetMSPDir()
+	mspID := orgSvc.org.GetMSPID()
+
+	cacert, err := ioutil.ReadFile(filepath.Join(mspDir, "cacerts", "ca-cert.pem"))
+	if err != nil {
+		return configtx.Organization{}, errors.WithMessage(err, "fail to read ca-cert.pem")
+	}
+	tlscacert, err := ioutil.ReadFile(filepath.Join(mspDir, "tlscacerts", "tlsca-cert.pem"))
+	if err != nil {
+		return configtx.Organization{}, errors.WithMessage(err, "fail to read tlsca-cert.pem")
+	}
+
+	policies := map[string]configtx.Policy{
+		"Readers": {
+			Type: configtx.SignaturePolicyType,
+			Rule: fmt.Sprintf("OR('%s.admin', '%s.peer', '%s.client')", mspID, mspID, mspID),
+		},
+		"Writers": {
+			Type: configtx.SignaturePolicyType,
+			Rule: fmt.Sprintf("OR('%s.admin', '%s.client')", mspID, mspID),
+		},
+		"Admins": {
+			Type: configtx.SignaturePolicyType,
+			Rule: fmt.Sprintf("OR('%s.admin')", mspID),
+		},
+		"Endorsement": {
+			Type: configtx.SignaturePolicyType,
+			Rule: fmt.Sprintf("OR('%s.peer')", mspID),
+		},
+	}
+	if orgSvc.org.IsOrdererOrganization() {
+		policies = map[string]configtx.Policy{
+			"Readers": {Type: configtx.SignaturePolicyType, Rule: fmt.Sprintf("OR('%s.member')", mspID)},
+			"Writers": {Type: configtx.SignaturePolicyType, Rule: fmt.Sprintf("OR('%s.member')", mspID)},
+			"Admins":  {Type: configtx.SignaturePolicyType, Rule: fmt.Sprintf("OR('%s.admin')", mspID)},
+		}
+	}
+
+	crls, err := orgSvc.readCRLs()
+	if err != nil {
+		return configtx.Organization{}, err
+	}
+
+	return configtx.Organization{
+		MSPID:          mspID,
+		RootCerts:      [][]byte{cacert},
+		TLSRootCerts:   [][]byte{tlscacert},
+		NodeOUs:        true,
+		Policies:       policies,
+		RevocationList: crls,
+	}, nil
+}
+
+// readCRLs reads msp/crls, which doesn't exist until a certificate is revoked
+func (orgSvc *OrganizationService) readCRLs() ([][]byte, error) {
+	files, err := ioutil.ReadDir(filepath.Join(orgSvc.org.GetMSPDir(), "crls"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	crls := [][]byte{}
+	for _, f := range files {
+		crl, err := ioutil.ReadFile(filepath.Join(orgSvc.org.GetMSPDir(), "crls", f.Name()))
+		if err != nil {
+			return nil, errors.WithMessage(err, "fail to read "+f.Name())
+		}
+		crls = append(crls, crl)
+	}
+	return crls, nil
+}
+
+// UpdateCRL writes the CRL generated by the CA into msp/crls/crl.pem,
+// then updates the organization definition in every channel containing it.
+// The CRL contains all revoked certificates of the CA, so it replaces the old one.
+func (orgSvc *OrganizationService) UpdateCRL(crl []byte) error {
+	global.Logger.Info(fmt.Sprintf("[update %s CRL]", orgSvc.org.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[update %s CRL] done!", orgSvc.org.GetName()))
+
+	// 1. msp/crls
+	global.Logger.Info("1. write msp/crls/crl.pem")
+	crlDir := filepath.Join(orgSvc.org.GetMSPDir(), "crls")
+	if err := os.MkdirAll(crlDir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(crlDir, "crl.pem"), crl, 0644); err != nil {
+		return errors.WithMessage(err, "fail to write crl.pem")
+	}
+
+	// 2. channels
+	global.Logger.Info("2. update channels config")
+	return orgSvc.DistributeCRL()
+}
+
+// DistributeCRL pushes the CRLs in msp/crls into the organization definition of every channel containing it,
+// the orderer organization is in the Orderer group of all channels.
+// It can be called again when a previous distribution failed halfway.
+func (orgSvc *OrganizationService) DistributeCRL() error {
+	crls, err := orgSvc.readCRLs()
+	if err != nil {
+		return err
+	}
+	if len(crls) == 0 {
+		return errors.New(orgSvc.org.GetName() + " has no CRL, nothing has been revoked")
+	}
+
+	net, err := dao.FindNetworkByID(orgSvc.org.NetworkID)
+	if err != nil {
+		return err
+	}
+	var chs []model.Channel
+	if orgSvc.org.IsOrdererOrganization() {
+		chs, err = dao.FindAllChannelsInNetwork(net.ID)
+	} else {
+		chs, err = dao.FindAllChannelsOfOrganization(orgSvc.org)
+	}
+	if err != nil {
+		return err
+	}
+	if !net.UsesChannelParticipation() {
+		chs = append(chs, *factory.NewChannelFactory().NewSystemChannel(net.ID))
+	}
+	for i := range chs {
+		if err := NewChannelService(&chs[i]).SetRevocationList(orgSvc.org, crls); err != nil {
+			return errors.WithMessage(err, "fail to update "+chs[i].GetName())
+		}
+	}
+	return nil
+}
+
+// revoke revokes all certificates of the user at the CA of the organization,
+// and distributes the new CRL
+func (orgSvc *OrganizationService) revoke(cu *model.CaUser, reason string) error {
+	mspClient, err := sdk.NewSDKClientFactory().NewMSPClient(orgSvc.org)
+	if err != nil {
+		return errors.WithMessage(err, "fail to get mspClient "+orgSvc.org.GetName())
+	}
+	crl, err := NewCaUserService(cu).Revoke(mspClient, reason)
+	if err != nil {
+		return err
+	}
+	return orgSvc.UpdateCRL(crl)
+}
+
+// RevokeUser revokes the certificates of a user or an admin, so that channels reject them,
+// then deletes the user.
+func (orgSvc *OrganizationService) RevokeUser(userID int) error {
+	global.Logger.Info(fmt.Sprintf("[revoke user%d of %s]", userID, orgSvc.org.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[revoke user%d of %s] done!", userID, orgSvc.org.GetName()))
+
+	// 1. check
+	global.Logger.Info("1. check")
+	if orgSvc.org.External {
+		return errors.New(orgSvc.org.GetName() + " is an external organization, its CA is not managed by mictract")
+	}
+	user, err := dao.FindCaUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.OrganizationID != orgSvc.org.ID {
+		return errors.New(fmt.Sprintf("%s is not a user of %s", user.GetName(), orgSvc.org.GetName()))
+	}
+	if user.Type != "user" && user.Type != "admin" {
+		return errors.New("only supports user and admin")
+	}
+	if user.Nickname == "system-user" {
+		return errors.New("can't revoke user(system-user)")
+	}
+
+	// 2. revoke certificates
+	global.Logger.Info("2. revoke certificates of " + user.GetName())
+	if err := orgSvc.revoke(user, "unspecified"); err != nil {
+		return err
+	}
+
+	// 3. remove certificates and db rows
+	global.Logger.Info("3. remove certificates of " + user.GetName())
+	return NewCaUserService(user).removeCredentials()
+}
+
 // CreateBasicOrganizationEntity starts a CA node,
-// and registers the node certificate and admin certificates
+// and registers the node certificate and admin certificates.
+// If it fails, everything it has created is removed.
 func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 	global.Logger.Info(fmt.Sprintf("[create basic %s entity]", orgSvc.org.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[create basic %s entity] done!", orgSvc.org.GetName()))
 
+	rb := NewRollback(fmt.Sprintf("create basic %s entity", orgSvc.org.GetName()))
+	defer rb.Close()
+
 	// 1. create ca pod
 	global.Logger.Info("1. create ca pod synchronously")
 	ca := kubernetes.NewOrdererCA(orgSvc.org.NetworkID)
 	if !orgSvc.org.IsOrdererOrganization() {
 		ca = kubernetes.NewPeerCA(orgSvc.org.NetworkID, orgSvc.org.ID)
 	}
+	// a failed creation may leave some resources
+	rb.Add("delete ca pod", func() error {
+		ca.Delete()
+		return nil
+	})
 	if err := ca.AwaitableCreate(); err != nil {
 		return err
 	}
@@ -139,6 +333,7 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 
 	// 3. 从ca的挂载目录里取出ca证书，构建组织msp, insert cert into db
 	global.Logger.Info("3. The msp of the organization is being built")
+	rb.Add("remove organization msp", orgSvc.removeMSP)
 	if err := orgSvc.GenerateOrgMSP(); err != nil {
 		return err
 	}
@@ -149,6 +344,10 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 	if err != nil {
 		return err
 	}
+	rb.Add("close organization sdk", func() error {
+		orgSvc.closeSDK()
+		return nil
+	})
 	newUser, err := factory.NewCaUserFactory().NewAdminCaUser(
 		orgSvc.org.ID,
 		orgSvc.org.NetworkID,
@@ -158,6 +357,7 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 	if err != nil {
 		return err
 	}
+	rb.Add("remove "+newUser.GetName(), NewCaUserService(newUser).removeCredentials)
 	users := []*model.CaUser{newUser}
 	if orgSvc.org.IsOrdererOrganization() {
 		newUser, err := factory.NewCaUserFactory().NewOrdererCaUser(
@@ -167,6 +367,7 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 		if err != nil {
 			return err
 		}
+		rb.Add("remove "+newUser.GetName(), NewCaUserService(newUser).removeCredentials)
 		users = append(users, newUser)
 	} else {
 		newUser, err := factory.NewCaUserFactory().NewPeerCaUser(
@@ -176,6 +377,7 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 		if err != nil {
 			return err
 		}
+		rb.Add("remove "+newUser.GetName(), NewCaUserService(newUser).removeCredentials)
 		users = append(users, newUser)
 	}
 
@@ -198,6 +400,7 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 		}
 	}
 
+	rb.Commit()
 	return nil
 }
 
@@ -205,7 +408,12 @@ func (orgSvc *OrganizationService)CreateBasicOrganizationEntity() error {
 // in the organization through the existing certificate
 func (orgSvc *OrganizationService) CreateNodeEntity() error {
 	global.Logger.Info(fmt.Sprintf("[create %s node entity]", orgSvc.org.GetName()))
-	defer global.Logger.Info(fmt.Sprintf("[create %d node entity] done!", orgSvc.org.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[create %s node entity] done!", orgSvc.org.GetName()))
+
+	net, err := dao.FindNetworkByID(orgSvc.org.NetworkID)
+	if err != nil {
+		return err
+	}
 
 	if orgSvc.org.IsOrdererOrganization() {
 		global.Logger.Info("orderer starts creating")
@@ -214,7 +422,11 @@ func (orgSvc *OrganizationService) CreateNodeEntity() error {
 		if err != nil {
 			return err
 		}
-		if err := kubernetes.NewOrderer(orgSvc.org.NetworkID, orderers[0].ID).AwaitableCreate(); err != nil {
+		orderer := kubernetes.NewOrderer(orgSvc.org.NetworkID, orderers[0].ID)
+		orderer.ChannelParticipation = net.UsesChannelParticipation()
+		orderer.Consensus = net.Consensus
+		if err := orderer.AwaitableCreate(); err != nil {
+			orderer.Delete()
 			return err
 		}
 		global.Logger.Info("orderer has been created synchronously")
@@ -225,7 +437,10 @@ func (orgSvc *OrganizationService) CreateNodeEntity() error {
 		if err != nil {
 			return err
 		}
-		if err := kubernetes.NewPeer(orgSvc.org.NetworkID, orgSvc.org.ID, peers[0].ID).AwaitableCreate(); err != nil {
+		peer := kubernetes.NewPeer(orgSvc.org.NetworkID, orgSvc.org.ID, peers[0].ID)
+		peer.Consensus = net.Consensus
+		if err := peer.AwaitableCreate(); err != nil {
+			peer.Delete()
 			return err
 		}
 		global.Logger.Info("peer has been created synchronously")
@@ -234,6 +449,47 @@ func (orgSvc *OrganizationService) CreateNodeEntity() error {
 	return nil
 }
 
+// closeSDK closes the cached sdk of the organization
+func (orgSvc *OrganizationService) closeSDK() {
+	orgSDK, ok := global.SDKs[orgSvc.org.GetName()]
+	if ok {
+		orgSDK.Close()
+		delete(global.SDKs, orgSvc.org.GetName())
+	}
+}
+
+// removeMSP removes the organization directory (msp, ca, users, nodes) and the ca cert in db
+func (orgSvc *OrganizationService) removeMSP() error {
+	if err := dao.DeleteCACertByOrganization(orgSvc.org); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Dir(orgSvc.org.GetMSPDir()))
+}
+
+// RemoveAllCrypto removes all users of the organization with their certificates,
+// and the organization directory. Together with RemoveAllEntity,
+// it undoes CreateBasicOrganizationEntity and CreateNodeEntity.
+func (orgSvc *OrganizationService) RemoveAllCrypto() error {
+	global.Logger.Info(fmt.Sprintf("[remove %s crypto]", orgSvc.org.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[remove %s crypto] done!", orgSvc.org.GetName()))
+
+	orgSvc.closeSDK()
+
+	for _, cuType := range []string{"user", "admin", "peer", "orderer"} {
+		users, err := dao.FindCaUserInOrganization(orgSvc.org.ID, cuType)
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			if err := NewCaUserService(&users[i]).removeCredentials(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return orgSvc.removeMSP()
+}
+
 func (orgSvc *OrganizationService) RemoveAllEntity() {
 	global.Logger.Info(fmt.Sprintf("[remove %s entity]", orgSvc.org.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[remove %s entity] done!", orgSvc.org.GetName()))
@@ -274,6 +530,8 @@ func (orgSvc *OrganizationService) RemoveAllEntity() {
 	}
 }
 
+// AddPeer registers, enrolls and starts a new peer,
+// if it fails, the peer's certificates and entity are removed.
 func (orgSvc *OrganizationService)AddPeer() (*model.CaUser, error) {
 	global.Logger.Info(fmt.Sprintf("[add new peer to %s]", orgSvc.org.GetName()))
 	defer global.Logger.Info(fmt.Sprintf("[add new peer to %s] done!", orgSvc.org.GetName()))
@@ -281,6 +539,12 @@ func (orgSvc *OrganizationService)AddPeer() (*model.CaUser, error) {
 	if orgSvc.org.IsOrdererOrganization() {
 		return &model.CaUser{}, errors.New("Just for peer, not orderer")
 	}
+	if orgSvc.org.External {
+		return &model.CaUser{}, errors.New(orgSvc.org.GetName() + " is an external organization, its peers are not managed by mictract")
+	}
+
+	rb := NewRollback(fmt.Sprintf("add new peer to %s", orgSvc.org.GetName()))
+	defer rb.Close()
 
 	// 3. get mspclient
 	mspClient, err := sdk.NewSDKClientFactory().NewMSPClient(orgSvc.org)
@@ -296,6 +560,7 @@ func (orgSvc *OrganizationService)AddPeer() (*model.CaUser, error) {
 	if err != nil {
 		return &model.CaUser{}, err
 	}
+	rb.Add("remove "+newPeer.GetName(), NewCaUserService(newPeer).removeCredentials)
 	if err := NewCaUserService(newPeer).Register(mspClient); err != nil {
 		return &model.CaUser{}, errors.WithMessage(err, "fail to regiester new Peer")
 	}
@@ -309,14 +574,117 @@ func (orgSvc *OrganizationService)AddPeer() (*model.CaUser, error) {
 
 	// 5. create peer entity
 	global.Logger.Info("peer starts creating")
-	if err := kubernetes.NewPeer(newPeer.NetworkID, newPeer.OrganizationID, newPeer.ID).AwaitableCreate(); err != nil {
+	net, err := dao.FindNetworkByID(newPeer.NetworkID)
+	if err != nil {
+		return &model.CaUser{}, err
+	}
+	peer := kubernetes.NewPeer(newPeer.NetworkID, newPeer.OrganizationID, newPeer.ID)
+	peer.Consensus = net.Consensus
+	rb.Add("delete "+newPeer.GetName()+" entity", func() error {
+		peer.Delete()
+		return nil
+	})
+	if err := peer.AwaitableCreate(); err != nil {
 		return &model.CaUser{}, err
 	}
 	global.Logger.Info("peer has been created synchronously")
 
+	rb.Commit()
 	return newPeer, err
 }
 
+// RemovePeer revokes the certificates of the peer, stops it and deletes it.
+// If it is an anchor peer, the other anchors, or the other peers of the organization, take its place.
+func (orgSvc *OrganizationService) RemovePeer(peerID int) error {
+	global.Logger.Info(fmt.Sprintf("[remove peer%d from %s]", peerID, orgSvc.org.GetName()))
+	defer global.Logger.Info(fmt.Sprintf("[remove peer%d from %s] done!", peerID, orgSvc.org.GetName()))
+
+	// 1. check
+	global.Logger.Info("1. check")
+	if orgSvc.org.External {
+		return errors.New(orgSvc.org.GetName() + " is an external organization, its peers are not managed by mictract")
+	}
+	peer, err := dao.FindCaUserByID(peerID)
+	if err != nil {
+		return err
+	}
+	if peer.Type != "peer" || peer.OrganizationID != orgSvc.org.ID {
+		return errors.New(fmt.Sprintf("%s is not a peer of %s", peer.GetName(), orgSvc.org.GetName()))
+	}
+	peers, err := dao.FindAllPeersInOrganization(orgSvc.org.ID)
+	if err != nil {
+		return err
+	}
+	rest := []int{}
+	for _, p := range peers {
+		if p.ID != peerID {
+			rest = append(rest, p.ID)
+		}
+	}
+	if len(rest) == 0 {
+		return errors.New("can not remove the last peer of " + orgSvc.org.GetName())
+	}
+
+	// 2. anchor peers
+	global.Logger.Info("2. replace the anchor peer")
+	chs, err := dao.FindAllChannelsOfOrganization(orgSvc.org)
+	if err != nil {
+		return err
+	}
+	for i := range chs {
+		if err := orgSvc.replaceAnchor(&chs[i], peer, peers, rest); err != nil {
+			return errors.WithMessage(err, "fail to update anchors of "+chs[i].GetName())
+		}
+	}
+
+	// 3. revoke certificates, before the peer is stopped,
+	// a failed distribution leaves the peer running and can be retried by DistributeCRL
+	global.Logger.Info("3. revoke certificates of " + peer.GetName())
+	if err := orgSvc.revoke(peer, "cessationofoperation"); err != nil {
+		return err
+	}
+
+	// 4. stop the peer
+	global.Logger.Info("4. stop " + peer.GetName())
+	if err := kubernetes.NewPeer(peer.NetworkID, peer.OrganizationID, peer.ID).AwaitableDelete(); err != nil {
+		return err
+	}
+
+	// 5. remove certificates and db rows
+	global.Logger.Info("5. remove certificates of " + peer.GetName())
+	return NewCaUserService(peer).removeCredentials()
+}
+
+// replaceAnchor drops peer from the anchors of the organization in ch,
+// rest are the IDs of the other peers, used when no anchor is left.
+func (orgSvc *OrganizationService) replaceAnchor(ch *model.Channel, peer *model.CaUser, peers []model.CaUser, rest []int) error {
+	anchors, err := NewChannelService(ch).GetAnchors()
+	if err != nil {
+		return err
+	}
+	isAnchor := false
+	anchorIDs := []int{}
+	for _, anchor := range anchors[orgSvc.org.ID] {
+		if anchor.Host == peer.GetURL() {
+			isAnchor = true
+			continue
+		}
+		for _, p := range peers {
+			if p.GetURL() == anchor.Host {
+				anchorIDs = append(anchorIDs, p.ID)
+				break
+			}
+		}
+	}
+	if !isAnchor {
+		return nil
+	}
+	if len(anchorIDs) == 0 {
+		anchorIDs = rest
+	}
+	return NewChannelService(ch).SetAnchors(orgSvc.org.ID, anchorIDs)
+}
+
 // copy file
 func copy(src, dst string) (int64, error) {
 	sourceFileStat, err := os.Stat(src)
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"mictract/dao"
+	"mictract/global"
+	"mictract/model/kubernetes"
+)
+
+// FindOrphanObjects lists k8s resources labelled app=mictract
+// whose network no longer exists in the database.
+// Resources not belonging to any network (e.g. tools) are never orphans.
+func FindOrphanObjects() ([]kubernetes.Object, error) {
+	nets, err := dao.FindAllNetworks()
+	if err != nil {
+		return []kubernetes.Object{}, err
+	}
+	exists := map[int]bool{}
+	for _, net := range nets {
+		exists[net.ID] = true
+	}
+
+	objs, err := kubernetes.ListObjects("app=mictract,net")
+	if err != nil {
+		return []kubernetes.Object{}, err
+	}
+
+	orphans := []kubernetes.Object{}
+	for _, obj := range objs {
+		if netID := obj.GetNetworkID(); netID > 0 && !exists[netID] {
+			orphans = append(orphans, obj)
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphanObjects deletes the resources found by FindOrphanObjects
+// and returns those which have been deleted.
+func RemoveOrphanObjects() ([]kubernetes.Object, error) {
+	global.Logger.Info("[Remove orphan k8s resources]")
+	defer global.Logger.Info("[Remove orphan k8s resources] done!")
+
+	orphans, err := FindOrphanObjects()
+	if err != nil {
+		return []kubernetes.Object{}, err
+	}
+
+	removed := []kubernetes.Object{}
+	for _, obj := range orphans {
+		global.Logger.Info(fmt.Sprintf(" ┗ delete %s %s", obj.Kind, obj.Name))
+		if err := kubernetes.DeleteObject(obj); err != nil {
+			global.Logger.Error(fmt.Sprintf("fail to delete %s %s", obj.Kind, obj.Name), zap.Error(err))
+			continue
+		}
+		removed = append(removed, obj)
+	}
+	return removed, nil
+}
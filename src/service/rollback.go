@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"mictract/global"
+)
+
+// Rollback collects undo actions of an operation made of several steps.
+// Every step that creates something (k8s resources, db rows, crypto files)
+// registers how to remove it; if the operation fails, Close runs the undo actions
+// in reverse order, so a failed operation leaves nothing half-built behind.
+//
+// rb := NewRollback("add org1")
+// defer rb.Close()
+// ... create something ...
+// rb.Add("delete something", func() error { ... })
+// ...
+// rb.Commit()
+type Rollback struct {
+	name      string
+	undos     []undoAction
+	committed bool
+}
+
+type undoAction struct {
+	desc string
+	fn   func() error
+}
+
+func NewRollback(name string) *Rollback {
+	return &Rollback{
+		name: name,
+	}
+}
+
+// Add registers the undo action of a step which has just succeeded
+func (rb *Rollback) Add(desc string, fn func() error) {
+	rb.undos = append(rb.undos, undoAction{desc: desc, fn: fn})
+}
+
+// Commit marks the operation as succeeded, Close won't undo anything then.
+func (rb *Rollback) Commit() {
+	rb.committed = true
+}
+
+// Close undoes all registered steps unless Commit has been called.
+// Errors of undo actions are logged and the remaining actions still run.
+func (rb *Rollback) Close() {
+	if rb.committed || len(rb.undos) == 0 {
+		return
+	}
+
+	global.Logger.Info(fmt.Sprintf("[Rollback %s]", rb.name))
+	defer global.Logger.Info(fmt.Sprintf("[Rollback %s] done!", rb.name))
+
+	for i := len(rb.undos) - 1; i >= 0; i-- {
+		global.Logger.Info(" ┗ " + rb.undos[i].desc)
+		if err := rb.undos[i].fn(); err != nil {
+			global.Logger.Error(fmt.Sprintf("fail to %s", rb.undos[i].desc), zap.Error(err))
+		}
+	}
+	rb.undos = nil
+}
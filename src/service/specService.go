@@ -0,0 +1,327 @@
+package service
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"mictract/dao"
+	"mictract/enum"
+	"mictract/model"
+	"mictract/model/request"
+)
+
+// SpecService compares a declarative NetworkSpec with the network in db,
+// and plans the operations which make the network converge to the spec.
+// Only additions are planned, removals are reported as warnings.
+type SpecService struct {
+	spec *request.NetworkSpec
+}
+
+func NewSpecService(spec *request.NetworkSpec) *SpecService {
+	return &SpecService{
+		spec: spec,
+	}
+}
+
+// Validate checks the spec itself, without looking at the network
+func (sSvc *SpecService) Validate() error {
+	spec := sSvc.spec
+
+	if spec.NetworkID == 0 {
+		if spec.Nickname == "" {
+			return errors.New("nickname is required to create a network")
+		}
+		if spec.Consensus != "solo" && spec.Consensus != "etcdraft" && spec.Consensus != enum.ConsensusBFT {
+			return errors.New("The consensus protocol only supports solo, etcdraft and BFT")
+		}
+		if spec.Orderers < 1 {
+			return errors.New("Every organization (including ordererorg) contains at least one node")
+		}
+		if spec.ChannelMode == enum.ChannelModeParticipation && spec.Consensus == "solo" {
+			return errors.New("channel participation only supports etcdraft and BFT")
+		}
+		if spec.ChannelMode == enum.ChannelModeSystemChannel && spec.Consensus == enum.ConsensusBFT {
+			return errors.New("BFT networks have no system channel")
+		}
+		if spec.Consensus == enum.ConsensusBFT && spec.Orderers < enum.BFTMinOrderers {
+			return errors.New(fmt.Sprintf("The BFT consensus needs at least %d orderers", enum.BFTMinOrderers))
+		}
+	}
+	if spec.Consensus == "solo" && spec.Orderers > 1 {
+		return errors.New("The solo consensus only supports one orderer")
+	}
+
+	orgs := map[string]bool{}
+	for _, org := range spec.Organizations {
+		if org.Nickname == "" {
+			return errors.New("organization nickname is required")
+		}
+		if orgs[org.Nickname] {
+			return errors.New(fmt.Sprintf("duplicate organization %s", org.Nickname))
+		}
+		if org.Peers < 1 {
+			return errors.New(fmt.Sprintf("organization %s contains at least one peer", org.Nickname))
+		}
+		orgs[org.Nickname] = true
+	}
+
+	chs := map[string]bool{}
+	for _, ch := range spec.Channels {
+		if ch.Nickname == "" {
+			return errors.New("channel nickname is required")
+		}
+		if chs[ch.Nickname] {
+			return errors.New(fmt.Sprintf("duplicate channel %s", ch.Nickname))
+		}
+		if len(ch.Organizations) < 1 {
+			return errors.New(fmt.Sprintf("channel %s contains at least one organization", ch.Nickname))
+		}
+		for _, org := range ch.Organizations {
+			if !orgs[org] {
+				return errors.New(fmt.Sprintf("organization %s of channel %s is not in the spec", org, ch.Nickname))
+			}
+		}
+		chs[ch.Nickname] = true
+	}
+
+	ccs := map[string]bool{}
+	for _, cc := range spec.Chaincodes {
+		if cc.Nickname == "" {
+			return errors.New("chaincode nickname is required")
+		}
+		if !chs[cc.Channel] {
+			return errors.New(fmt.Sprintf("channel %s of chaincode %s is not in the spec", cc.Channel, cc.Nickname))
+		}
+		key := cc.Channel + "/" + cc.Nickname
+		if ccs[key] {
+			return errors.New(fmt.Sprintf("duplicate chaincode %s in channel %s", cc.Nickname, cc.Channel))
+		}
+		ccs[key] = true
+	}
+
+	return nil
+}
+
+// Plan validates the spec and diffs it against the network in db
+func (sSvc *SpecService) Plan() (*model.Plan, error) {
+	if err := sSvc.Validate(); err != nil {
+		return nil, err
+	}
+	spec := sSvc.spec
+
+	plan := &model.Plan{
+		NetworkID: spec.NetworkID,
+		Actions:   []model.PlanAction{},
+		Warnings:  []string{},
+	}
+	add := func(action model.PlanAction) {
+		plan.Actions = append(plan.Actions, action)
+	}
+	warn := func(format string, a ...interface{}) {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(format, a...))
+	}
+
+	// 1. network and orderers
+	ordererCount := 0
+	if spec.NetworkID == 0 {
+		add(model.PlanAction{
+			Type:        enum.ActionCreateNetwork,
+			Description: fmt.Sprintf("create network %s(%s) with 1 orderer", spec.Nickname, spec.Consensus),
+		})
+		ordererCount = 1
+	} else {
+		net, err := dao.FindNetworkByID(spec.NetworkID)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Consensus != "" && spec.Consensus != net.Consensus {
+			warn("consensus can't be changed from %s to %s", net.Consensus, spec.Consensus)
+		}
+		if spec.ChannelMode != "" && spec.ChannelMode != net.ChannelMode {
+			warn("channel mode can't be changed from %s to %s", net.ChannelMode, spec.ChannelMode)
+		}
+		if net.Consensus == "solo" && spec.Orderers > 1 {
+			return nil, errors.New("The solo consensus only supports one orderer")
+		}
+		orderers, err := dao.FindAllOrderersInNetwork(spec.NetworkID)
+		if err != nil {
+			return nil, err
+		}
+		ordererCount = len(orderers)
+		if net.Consensus == enum.ConsensusBFT && spec.Orderers > ordererCount {
+			chs, err := dao.FindAllChannelsInNetwork(spec.NetworkID)
+			if err != nil {
+				return nil, err
+			}
+			if len(chs) != 0 {
+				return nil, errors.New("orderers can't be added to BFT channels")
+			}
+		}
+	}
+	for i := ordererCount; i < spec.Orderers; i++ {
+		add(model.PlanAction{
+			Type:        enum.ActionAddOrderer,
+			Description: "add orderer to system-channel",
+		})
+	}
+	if spec.Orderers != 0 && spec.Orderers < ordererCount {
+		warn("%d orderers exist, more than %d in the spec", ordererCount, spec.Orderers)
+	}
+
+	// 2. organizations and peers
+	specOrgs := map[string]bool{}
+	for _, orgSpec := range spec.Organizations {
+		specOrgs[orgSpec.Nickname] = true
+		peerCount := 0
+		if spec.NetworkID != 0 {
+			if org, err := dao.FindOrganizationInNetworkByNickname(spec.NetworkID, orgSpec.Nickname); err == nil {
+				peers, err := dao.FindCaUserInOrganization(org.ID, "peer")
+				if err != nil {
+					return nil, err
+				}
+				peerCount = len(peers)
+			}
+		}
+		if peerCount == 0 {
+			add(model.PlanAction{
+				Type:         enum.ActionAddOrg,
+				Description:  fmt.Sprintf("add organization %s with 1 peer", orgSpec.Nickname),
+				Organization: orgSpec.Nickname,
+			})
+			peerCount = 1
+		}
+		for i := peerCount; i < orgSpec.Peers; i++ {
+			add(model.PlanAction{
+				Type:         enum.ActionAddPeer,
+				Description:  fmt.Sprintf("add peer to organization %s", orgSpec.Nickname),
+				Organization: orgSpec.Nickname,
+			})
+		}
+		if orgSpec.Peers < peerCount {
+			warn("organization %s has %d peers, more than %d in the spec", orgSpec.Nickname, peerCount, orgSpec.Peers)
+		}
+	}
+
+	// 3. channels and members
+	specChs := map[string]bool{}
+	for _, chSpec := range spec.Channels {
+		specChs[chSpec.Nickname] = true
+		var ch *model.Channel
+		if spec.NetworkID != 0 {
+			if found, err := dao.FindChannelInNetworkByNickname(spec.NetworkID, chSpec.Nickname); err == nil {
+				ch = found
+			}
+		}
+		if ch == nil {
+			add(model.PlanAction{
+				Type:          enum.ActionAddChannel,
+				Description:   fmt.Sprintf("add channel %s with organizations %v", chSpec.Nickname, chSpec.Organizations),
+				Channel:       chSpec.Nickname,
+				Organizations: chSpec.Organizations,
+			})
+			continue
+		}
+
+		members := map[string]bool{}
+		orgs, err := dao.FindAllOrganizationsInChannel(ch)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			members[org.Nickname] = true
+		}
+		for _, orgNickname := range chSpec.Organizations {
+			if members[orgNickname] {
+				continue
+			}
+			add(model.PlanAction{
+				Type:         enum.ActionAddOrgToChannel,
+				Description:  fmt.Sprintf("add organization %s to channel %s", orgNickname, chSpec.Nickname),
+				Organization: orgNickname,
+				Channel:      chSpec.Nickname,
+			})
+		}
+		for nickname := range members {
+			if !contains(chSpec.Organizations, nickname) {
+				warn("organization %s is a member of channel %s but not in the spec", nickname, chSpec.Nickname)
+			}
+		}
+	}
+
+	// 4. chaincodes
+	for _, ccSpec := range spec.Chaincodes {
+		exists := false
+		if spec.NetworkID != 0 {
+			if ch, err := dao.FindChannelInNetworkByNickname(spec.NetworkID, ccSpec.Channel); err == nil {
+				ccs, err := dao.FindAllChaincodesInNetwork(spec.NetworkID)
+				if err != nil {
+					return nil, err
+				}
+				for _, cc := range ccs {
+					if cc.ChannelID == ch.ID && cc.Nickname == ccSpec.Nickname {
+						exists = true
+						if ccSpec.Version != "" && ccSpec.Version != cc.Version {
+							warn("chaincode %s in channel %s is version %s, upgrading to %s is not supported",
+								ccSpec.Nickname, ccSpec.Channel, cc.Version, ccSpec.Version)
+						}
+						break
+					}
+				}
+			}
+		}
+		if exists {
+			continue
+		}
+		if ccSpec.Path == "" {
+			return nil, errors.New(fmt.Sprintf("path of chaincode %s is required to create it", ccSpec.Nickname))
+		}
+		add(model.PlanAction{
+			Type:        enum.ActionCreateChaincode,
+			Description: fmt.Sprintf("create chaincode %s in channel %s", ccSpec.Nickname, ccSpec.Channel),
+			Channel:     ccSpec.Channel,
+			Chaincode:   ccSpec.Nickname,
+		})
+	}
+
+	// 5. what is not in the spec is kept
+	if spec.NetworkID != 0 {
+		orgs, err := dao.FindAllOrganizationsInNetwork(spec.NetworkID)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			if !org.IsOrdererOrganization() && !specOrgs[org.Nickname] {
+				warn("organization %s is not in the spec, it is kept", org.Nickname)
+			}
+		}
+		chs, err := dao.FindAllChannelsInNetwork(spec.NetworkID)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range chs {
+			if !specChs[ch.Nickname] {
+				warn("channel %s is not in the spec, it is kept", ch.Nickname)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// GetChaincodeSpec returns the chaincode of a create_chaincode action
+func (sSvc *SpecService) GetChaincodeSpec(channel, nickname string) (request.ChaincodeSpec, error) {
+	for _, cc := range sSvc.spec.Chaincodes {
+		if cc.Channel == channel && cc.Nickname == nickname {
+			return cc, nil
+		}
+	}
+	return request.ChaincodeSpec{}, errors.New(fmt.Sprintf("no chaincode %s of channel %s in the spec", nickname, channel))
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"mictract/model/request"
+	"mictract/service"
+	"testing"
+)
+
+const testSpec = `
+nickname: net
+consensus: etcdraft
+orderers: 3
+organizations:
+  - nickname: org1
+    peers: 2
+  - nickname: org2
+    peers: 1
+channels:
+  - nickname: channel1
+    organizations: [org1, org2]
+chaincodes:
+  - nickname: cc1
+    channel: channel1
+    path: /mictract/uploads/cc1.tar.gz
+`
+
+func TestSpecValidate(t *testing.T) {
+	var spec request.NetworkSpec
+	assert.NoError(t, yaml.Unmarshal([]byte(testSpec), &spec))
+	assert.Equal(t, 2, len(spec.Organizations))
+	assert.NoError(t, service.NewSpecService(&spec).Validate())
+
+	spec.Channels[0].Organizations = append(spec.Channels[0].Organizations, "org3")
+	assert.Error(t, service.NewSpecService(&spec).Validate())
+}
+
+func TestSpecValidateSolo(t *testing.T) {
+	spec := request.NetworkSpec{Nickname: "net", Consensus: "solo", Orderers: 2}
+	assert.Error(t, service.NewSpecService(&spec).Validate())
+}
+
+func TestSpecPlanNewNetwork(t *testing.T) {
+	var spec request.NetworkSpec
+	assert.NoError(t, yaml.Unmarshal([]byte(testSpec), &spec))
+
+	// a new network doesn't touch db
+	plan, err := service.NewSpecService(&spec).Plan()
+	assert.NoError(t, err)
+	// network, 2 orderers, 2 orgs, 1 peer, 1 channel, 1 chaincode
+	assert.Equal(t, 8, len(plan.Actions))
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"mictract/config"
+	"mictract/global"
+	"mictract/model"
+	"os"
+	"path/filepath"
+)
+
+// Workspace is a private directory for one operation, so operations running at
+// the same time never share intermediate files (configtx.yaml, channel.tx, ...).
+// It lives on NFS, so the tools pod sees it at the same path.
+//
+// ws, err := NewWorkspace(netID, "channel1")
+// defer ws.Close()
+type Workspace struct {
+	path string
+}
+
+// NewWorkspace creates a directory like /mictract/networks/net1/workspaces/channel1-123456
+func NewWorkspace(netID int, key string) (*Workspace, error) {
+	base := filepath.Join(config.LOCAL_BASE_PATH, model.GetNetworkNameByID(netID), "workspaces")
+	if err := os.MkdirAll(base, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	path, err := ioutil.TempDir(base, key+"-")
+	if err != nil {
+		return nil, err
+	}
+	// the tools pod may run as another user
+	if err := os.Chmod(path, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &Workspace{path: path}, nil
+}
+
+func (ws *Workspace) GetPath() string {
+	return ws.path
+}
+
+func (ws *Workspace) Join(elem ...string) string {
+	return filepath.Join(append([]string{ws.path}, elem...)...)
+}
+
+// Close removes the workspace and everything in it.
+func (ws *Workspace) Close() {
+	if err := os.RemoveAll(ws.path); err != nil {
+		global.Logger.Error(fmt.Sprintf("fail to remove workspace %s", ws.path), zap.Error(err))
+	}
+}
@@ -218,12 +218,12 @@ func TestCommitCC(t *testing.T)  {
 }
 
 func TestCreateCCContainer(t *testing.T)  {
-	ccc := kubernetes.NewChaincode(netID, channelID, packageID, ccID)
+	ccc := kubernetes.NewChaincode(netID, channelID, packageID, ccID, "golang", "")
 	ccc.Create()
 }
 
 func TestDeleteCCContainer(t *testing.T) {
-	ccc := kubernetes.NewChaincode(netID, channelID, packageID, ccID)
+	ccc := kubernetes.NewChaincode(netID, channelID, packageID, ccID, "golang", "")
 	ccc.Delete()
 }
 
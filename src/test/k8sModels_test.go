@@ -119,4 +119,4 @@ func TestAwaitableCreateOnCA(t *testing.T) {
 	assert.Equal(t, true, err == nil)
 
 	ca.Delete()
-}
\ No newline at end of file
+}
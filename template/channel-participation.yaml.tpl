@@ -0,0 +1,160 @@
+Organizations:
+    - &OrdererOrg
+        Name: ordererorg
+        ID: {{.OrdererOrg.GetMSPID}}
+        MSPDir: {{.OrdererOrg.GetMSPDir}}
+        Policies:
+            Readers:
+                Type: Signature
+                Rule: "OR('{{.OrdererOrg.GetMSPID}}.member')"
+            Writers:
+                Type: Signature
+                Rule: "OR('{{.OrdererOrg.GetMSPID}}.member')"
+            Admins:
+                Type: Signature
+                Rule: "OR('{{.OrdererOrg.GetMSPID}}.admin')"
+
+        OrdererEndpoints:
+{{- range .Orderers}}
+            - {{.GetURL}}:7050
+{{- end}}
+
+    - &Org{{.Org.ID}}
+        Name: {{.Org.GetMSPID}}
+        ID: {{.Org.GetMSPID}}
+        MSPDir: {{.Org.GetMSPDir}}
+        Policies:
+            Readers:
+                Type: Signature
+                Rule: "OR('{{.Org.GetMSPID}}.admin', '{{.Org.GetMSPID}}.peer', '{{.Org.GetMSPID}}.client')"
+            Writers:
+                Type: Signature
+                Rule: "OR('{{.Org.GetMSPID}}.admin', '{{.Org.GetMSPID}}.client')"
+            Admins:
+                Type: Signature
+                Rule: "OR('{{.Org.GetMSPID}}.admin')"
+            Endorsement:
+                Type: Signature
+                Rule: "OR('{{.Org.GetMSPID}}.peer')"
+
+Capabilities:
+    Channel: &ChannelCapabilities
+{{- if eq .Consensus "BFT"}}
+        V3_0: true
+{{- else}}
+        V2_0: true
+{{- end}}
+    Orderer: &OrdererCapabilities
+        V2_0: true
+    Application: &ApplicationCapabilities
+        V2_0: true
+
+Application: &ApplicationDefaults
+    Organizations:
+    Policies:
+        Readers:
+            Type: ImplicitMeta
+            Rule: "ANY Readers"
+        Writers:
+            Type: ImplicitMeta
+            Rule: "ANY Writers"
+        Admins:
+            Type: ImplicitMeta
+            Rule: "MAJORITY Admins"
+        LifecycleEndorsement:
+            Type: ImplicitMeta
+            Rule: "MAJORITY Endorsement"
+        Endorsement:
+            Type: ImplicitMeta
+            Rule: "MAJORITY Endorsement"
+
+    Capabilities:
+        <<: *ApplicationCapabilities
+
+Orderer: &OrdererDefaults
+{{- if eq .Consensus "BFT"}}
+    OrdererType: BFT
+    ConsenterMapping:
+{{- range .Orderers}}
+    - ID: {{.ID}}
+      Host: {{.GetURL}}
+      Port: 7050
+      MSPID: {{$.OrdererOrg.GetMSPID}}
+      Identity: {{.GetBasePath}}/msp/signcerts/{{.GetName}}-cert.pem
+      ClientTLSCert: {{.GetBasePath}}/tls/server.crt
+      ServerTLSCert: {{.GetBasePath}}/tls/server.crt
+{{- end}}
+    SmartBFT:
+        RequestBatchMaxCount: 100
+        RequestBatchMaxInterval: 50ms
+        RequestForwardTimeout: 2s
+        RequestComplainTimeout: 20s
+        RequestAutoRemoveTimeout: 3m0s
+        ViewChangeResendInterval: 5s
+        ViewChangeTimeout: 20s
+        LeaderHeartbeatTimeout: 1m0s
+        CollectTimeout: 1s
+        RequestBatchMaxBytes: 10485760
+        IncomingMessageBufferSize: 200
+        RequestPoolSize: 100000
+        LeaderHeartbeatCount: 10
+{{- else}}
+    OrdererType: etcdraft
+    EtcdRaft:
+        Consenters:
+{{- range .Orderers}}
+        - Host: {{.GetURL}}
+          Port: 7050
+          ClientTLSCert: {{.GetBasePath}}/tls/server.crt
+          ServerTLSCert: {{.GetBasePath}}/tls/server.crt
+{{- end}}
+{{- end}}
+    BatchTimeout: 2s
+    BatchSize:
+        MaxMessageCount: 10
+        AbsoluteMaxBytes: 99 MB
+        PreferredMaxBytes: 512 KB
+    Organizations:
+    Policies:
+        Readers:
+            Type: ImplicitMeta
+            Rule: "ANY Readers"
+        Writers:
+            Type: ImplicitMeta
+            Rule: "ANY Writers"
+        Admins:
+            Type: ImplicitMeta
+            Rule: "MAJORITY Admins"
+        BlockValidation:
+            Type: ImplicitMeta
+            Rule: "ANY Writers"
+
+Channel: &ChannelDefaults
+    Policies:
+        Readers:
+            Type: ImplicitMeta
+            Rule: "ANY Readers"
+        Writers:
+            Type: ImplicitMeta
+            Rule: "ANY Writers"
+        Admins:
+            Type: ImplicitMeta
+            Rule: "MAJORITY Admins"
+    Capabilities:
+        <<: *ChannelCapabilities
+
+Profiles:
+    NewChannel:
+        <<: *ChannelDefaults
+        Orderer:
+            <<: *OrdererDefaults
+            Organizations:
+                - *OrdererOrg
+            Capabilities:
+                <<: *OrdererCapabilities
+        Application:
+            <<: *ApplicationDefaults
+            Organizations:
+                - *Org{{.Org.ID}}
+            Capabilities:
+                <<: *ApplicationCapabilities